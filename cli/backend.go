@@ -0,0 +1,65 @@
+// Package cli provides the backend subcommand for checking the backend API.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/settings"
+	"github.com/palantir/stacktrace"
+)
+
+// backendCLI checks that the backend is reachable and has a course for a guild, or for every guild the bot is in.
+// Usage: hakase-discord backend check [-course COURSE_ID]
+func backendCLI(config settings.Config, args []string) error {
+	if len(args) == 0 || args[0] != "check" {
+		return stacktrace.NewError("usage: hakase-discord backend check [-course COURSE_ID]")
+	}
+	flags := flag.NewFlagSet("backend check", flag.ContinueOnError)
+	courseID := flags.String("course", "", "course (guild) ID to check, every guild the bot is in if empty")
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to parse flags")
+	}
+
+	bot, err := newSession(config)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to create discord session")
+	}
+	hakaseClient, err := newHakaseClient(config)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to create clients")
+	}
+	ctx, span := startSpan(bot, "backend check")
+	defer span.End()
+
+	courseIDs := []string{*courseID}
+	if *courseID == "" {
+		guilds, err := bot.UserGuilds(200, "", "", false)
+		if err != nil {
+			return stacktrace.Propagate(err, "failed to list guilds")
+		}
+		courseIDs = []string{}
+		for _, guild := range guilds {
+			courseIDs = append(courseIDs, guild.ID)
+		}
+	}
+
+	missing := 0
+	for _, courseID := range courseIDs {
+		start := time.Now()
+		err := hakaseClient.Backend.HeadCourse(ctx, courseID)
+		if err != nil {
+			fmt.Printf("course %s: error (%dms): %s\n", courseID, time.Since(start).Milliseconds(), err.Error())
+			missing++
+			continue
+		}
+		fmt.Printf("course %s: ok (%dms)\n", courseID, time.Since(start).Milliseconds())
+	}
+
+	if missing > 0 {
+		return stacktrace.NewError("%d of %d courses failed backend check", missing, len(courseIDs))
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Package cli provides the hakase-discord command line interface.
+// Running without a subcommand starts the bot, and the remaining subcommands are operational tools that share the bot's configuration.
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/settings"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/getsentry/sentry-go"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const usage = `usage: hakase-discord [-config FILE] <command> [arguments]
+
+commands:
+  run                                  start the bot (default)
+  commands sync|list|purge [-guild ID] manage registered application commands
+  stream inspect [-limit N]            show pending messages, scheduled reminders and consumer lag
+  reminders replay -course ID [-dry-run]
+                                       reschedule upcoming reminders for a course
+  config validate                      check the configuration and print it with secrets redacted
+  backend check [-course ID]           check that the backend is reachable and has a course for each guild
+
+configuration is read from the YAML file passed with -config or CONFIG_FILE, then environment variables.
+`
+
+// Run loads the configuration and executes the subcommand in args, starting the bot if there is no subcommand.
+func Run(args []string) error {
+	flags := flag.NewFlagSet("hakase-discord", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to a YAML config file, defaults to CONFIG_FILE")
+	err := flags.Parse(args)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to parse flags")
+	}
+	args = flags.Args()
+
+	if len(args) > 0 && slices.Contains([]string{"help", "-h", "-help", "--help"}, args[0]) {
+		fmt.Print(usage)
+		return nil
+	}
+
+	config, err := settings.Load(*configPath)
+	if len(args) > 0 && args[0] == "config" {
+		return configCLI(config, err, args[1:])
+	}
+	if err != nil {
+		return stacktrace.Propagate(err, "invalid configuration")
+	}
+	shutdown := setup(config)
+	defer shutdown()
+
+	if len(args) == 0 {
+		return runBot(config, args)
+	}
+
+	switch args[0] {
+	case "run":
+		return runBot(config, args[1:])
+	case "commands":
+		return commandsCLI(config, args[1:])
+	case "stream":
+		return streamCLI(config, args[1:])
+	case "reminders":
+		return remindersCLI(config, args[1:])
+	case "backend":
+		return backendCLI(config, args[1:])
+	default:
+		return stacktrace.NewError("unknown command: %s\n%s", args[0], usage)
+	}
+}
+
+// setup configures logging, Sentry and tracing from the configuration.
+// The returned function flushes spans and Sentry events, and must be called before exiting.
+func setup(config settings.Config) func() {
+	level, _ := config.Level()
+	slog.SetLogLoggerLevel(level)
+
+	sentryEnabled := false
+	if config.SentryDSN != "" {
+		err := sentry.Init(sentry.ClientOptions{
+			Dsn:              config.SentryDSN,
+			EnableTracing:    true,
+			SampleRate:       1,
+			TracesSampleRate: 1,
+			SendDefaultPII:   true,
+			EnableLogs:       true,
+			Environment:      config.Env,
+		})
+		if err != nil {
+			slog.Warn(fmt.Sprintf("error initiating sentry: %s", err))
+		} else {
+			sentryEnabled = true
+		}
+		slog.SetDefault(slog.New(slog.NewJSONHandler(io.MultiWriter(os.Stderr, sentry.NewLogger(context.Background())), &slog.HandlerOptions{AddSource: true, Level: level})))
+	}
+
+	shutdownTracing, err := tracing.Setup(config, sentryEnabled)
+	if err != nil {
+		slog.Warn(stacktrace.Propagate(err, "error setting up tracing").Error())
+		shutdownTracing = func(context.Context) error { return nil }
+	}
+
+	slog.Debug("loaded configuration", "config", config)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := shutdownTracing(ctx)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to flush spans").Error())
+		}
+		sentry.Flush(5 * time.Second)
+	}
+}
+
+// newSession creates a Discord session for the configured bot token without opening the gateway connection.
+func newSession(config settings.Config) (*discordgo.Session, error) {
+	bot, err := discordgo.New(fmt.Sprintf("Bot %s", config.DiscordBotToken))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to create discord session")
+	}
+	bot.StateEnabled = true
+	bot.ShardID = config.ShardID
+	bot.ShardCount = config.ShardCount
+	return bot, nil
+}
+
+// newHakaseClient creates the backend and notifications clients from the configuration.
+func newHakaseClient(config settings.Config) (clients.HakaseClient, error) {
+	reminders, err := clients.DefaultReminderProfiles.WithOverrides(config.Reminders)
+	if err != nil {
+		return clients.HakaseClient{}, stacktrace.Propagate(err, "invalid reminder profiles")
+	}
+
+	return clients.HakaseClient{
+		Backend: &clients.APIClient{
+			Url:        config.BackendURL,
+			APIKey:     config.BackendAPIKey,
+			HttpClient: &http.Client{Timeout: config.BackendTimeout},
+		},
+		Notifications: &clients.MQClient{
+			NATSUrl:         config.NATSUrl,
+			StreamName:      config.StreamName,
+			PublishTimeout:  config.PublishTimeout,
+			DuplicateWindow: config.DuplicateWindow,
+			ShardID:         config.ShardID,
+			ShardCount:      config.ShardCount,
+		},
+		Reminders: reminders,
+	}, nil
+}
+
+// startSpan starts a span for a CLI subcommand with the Discord session in its context.
+func startSpan(bot *discordgo.Session, name string) (context.Context, trace.Span) {
+	return tracing.Start(clients.WithSession(context.Background(), bot), fmt.Sprintf("cli %s", name))
+}
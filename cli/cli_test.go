@@ -0,0 +1,50 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/dragonejt/hakase-discord/cli"
+	"github.com/stretchr/testify/suite"
+)
+
+type CLITestSuite struct {
+	suite.Suite
+}
+
+func TestCLI(t *testing.T) {
+	suite.Run(t, new(CLITestSuite))
+}
+
+func (testSuite *CLITestSuite) SetupTest() {
+	testSuite.T().Setenv("CONFIG_FILE", "")
+	testSuite.T().Setenv("DISCORD_BOT_TOKEN", "test-discord-bot-token")
+	testSuite.T().Setenv("BACKEND_URL", "http://localhost:8000")
+	testSuite.T().Setenv("BACKEND_API_KEY", "test-backend-api-key")
+	testSuite.T().Setenv("NATS_URL", "nats://localhost:4222")
+	testSuite.T().Setenv("STREAM_NAME", "hakase_discord_test")
+}
+
+func (testSuite *CLITestSuite) TestHelp() {
+	testSuite.NoError(cli.Run([]string{"help"}))
+}
+
+func (testSuite *CLITestSuite) TestUnknownCommand() {
+	testSuite.Error(cli.Run([]string{"unknown"}))
+}
+
+func (testSuite *CLITestSuite) TestMissingSubcommand() {
+	for _, command := range []string{"commands", "stream", "reminders", "config", "backend"} {
+		testSuite.Error(cli.Run([]string{command}), command)
+	}
+}
+
+func (testSuite *CLITestSuite) TestRemindersReplayRequiresCourse() {
+	testSuite.Error(cli.Run([]string{"reminders", "replay"}))
+}
+
+func (testSuite *CLITestSuite) TestConfigValidate() {
+	testSuite.NoError(cli.Run([]string{"config", "validate"}))
+
+	testSuite.T().Setenv("BACKEND_URL", "")
+	testSuite.Error(cli.Run([]string{"config", "validate"}))
+}
@@ -0,0 +1,61 @@
+// Package cli provides the commands subcommand for managing registered application commands.
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/dragonejt/hakase-discord/commands"
+	"github.com/dragonejt/hakase-discord/settings"
+	"github.com/palantir/stacktrace"
+)
+
+// commandsCLI manages registered application commands without starting the bot.
+// Usage: hakase-discord commands sync|list|purge [-guild GUILD_ID]
+func commandsCLI(config settings.Config, args []string) error {
+	if len(args) == 0 {
+		return stacktrace.NewError("usage: hakase-discord commands sync|list|purge [-guild GUILD_ID]")
+	}
+	flags := flag.NewFlagSet("commands", flag.ContinueOnError)
+	guildID := flags.String("guild", config.DevGuildID, "guild to manage commands in, global if empty")
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to parse flags")
+	}
+
+	bot, err := newSession(config)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to create discord session")
+	}
+	botUser, err := bot.User("@me")
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to get bot user")
+	}
+
+	switch args[0] {
+	case "sync":
+		diff, err := commands.Sync(bot, botUser.ID, *guildID, commands.Commands)
+		if err != nil {
+			return stacktrace.Propagate(err, "failed to sync commands")
+		}
+		fmt.Printf("created %d, updated %d, deleted %d commands\n", len(diff.Create), len(diff.Update), len(diff.Delete))
+	case "list":
+		registered, err := commands.List(bot, botUser.ID, *guildID)
+		if err != nil {
+			return stacktrace.Propagate(err, "failed to list commands")
+		}
+		for _, command := range registered {
+			fmt.Printf("%s\t%s\t%s\n", command.ID, command.Name, command.Description)
+		}
+	case "purge":
+		err := commands.Purge(bot, botUser.ID, *guildID)
+		if err != nil {
+			return stacktrace.Propagate(err, "failed to purge commands")
+		}
+		fmt.Println("purged all commands")
+	default:
+		return stacktrace.NewError("unknown commands subcommand: %s", args[0])
+	}
+
+	return nil
+}
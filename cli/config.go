@@ -0,0 +1,30 @@
+// Package cli provides the config subcommand for validating configuration.
+package cli
+
+import (
+	"fmt"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/settings"
+	"github.com/palantir/stacktrace"
+)
+
+// configCLI reports whether the loaded configuration is valid and prints it with secrets redacted, without connecting to any service.
+// Usage: hakase-discord [-config FILE] config validate
+func configCLI(config settings.Config, loadErr error, args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		return stacktrace.NewError("usage: hakase-discord [-config FILE] config validate")
+	}
+
+	fmt.Print(config.String())
+	if loadErr != nil {
+		return stacktrace.Propagate(loadErr, "invalid configuration")
+	}
+	_, err := clients.DefaultReminderProfiles.WithOverrides(config.Reminders)
+	if err != nil {
+		return stacktrace.Propagate(err, "invalid configuration")
+	}
+
+	fmt.Println("configuration is valid")
+	return nil
+}
@@ -0,0 +1,66 @@
+// Package cli provides the reminders subcommand for rescheduling assignment reminders.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/settings"
+	"github.com/palantir/stacktrace"
+)
+
+// remindersCLI reschedules the upcoming reminders for every assignment in a course, for example after the stream was lost.
+// Usage: hakase-discord reminders replay -course COURSE_ID [-dry-run]
+func remindersCLI(config settings.Config, args []string) error {
+	if len(args) == 0 || args[0] != "replay" {
+		return stacktrace.NewError("usage: hakase-discord reminders replay -course COURSE_ID [-dry-run]")
+	}
+	flags := flag.NewFlagSet("reminders replay", flag.ContinueOnError)
+	courseID := flags.String("course", "", "course (guild) ID to replay reminders for")
+	dryRun := flags.Bool("dry-run", false, "print reminders without scheduling them")
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to parse flags")
+	}
+	if *courseID == "" {
+		return stacktrace.NewError("-course is required")
+	}
+
+	bot, err := newSession(config)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to create discord session")
+	}
+	hakaseClient, err := newHakaseClient(config)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to create clients")
+	}
+	ctx, span := startSpan(bot, "reminders replay")
+	defer span.End()
+
+	assignments, err := hakaseClient.Backend.ListAssignments(ctx, *courseID)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to list assignments for course: %s", *courseID)
+	}
+
+	notifications := []clients.AssignmentNotification{}
+	for _, assignment := range assignments {
+		assignment.CourseID = *courseID
+		for _, notification := range assignment.UpcomingReminders(hakaseClient.Reminders, time.Now()) {
+			fmt.Printf("assignment %d (%s): reminder %s before %s\n", assignment.ID, assignment.Name, notification.Before, assignment.Due.Format(time.RFC1123))
+			notifications = append(notifications, notification)
+		}
+	}
+
+	if *dryRun {
+		fmt.Printf("would schedule %d reminders\n", len(notifications))
+		return nil
+	}
+	err = hakaseClient.Notifications.ScheduleAssignmentNotifications(ctx, notifications)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to schedule reminders for course: %s", *courseID)
+	}
+	fmt.Printf("scheduled %d reminders\n", len(notifications))
+	return nil
+}
@@ -0,0 +1,159 @@
+// Package cli provides the run subcommand, which starts the bot.
+package cli
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/commands"
+	"github.com/dragonejt/hakase-discord/events"
+	"github.com/dragonejt/hakase-discord/health"
+	"github.com/dragonejt/hakase-discord/lifecycle"
+	"github.com/dragonejt/hakase-discord/settings"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/palantir/stacktrace"
+)
+
+// readinessChecks returns the checks that must pass for the bot to be ready:
+// the Discord gateway is connected, the NATS consumer is active, and the backend is reachable.
+func readinessChecks(bot *discordgo.Session, hakaseClient clients.HakaseClient) []health.Check {
+	return []health.Check{
+		{Name: "discord", Check: func(ctx context.Context) error {
+			bot.RLock()
+			defer bot.RUnlock()
+			if !bot.DataReady {
+				return stacktrace.NewError("discord gateway is not connected")
+			}
+			return nil
+		}},
+		{Name: "nats", Check: func(ctx context.Context) error {
+			return hakaseClient.Notifications.ConsumerReady()
+		}},
+		{Name: "backend", Check: func(ctx context.Context) error {
+			// readiness probes run constantly, so they are not traced
+			return hakaseClient.Backend.Ping(tracing.Unsampled(ctx))
+		}},
+	}
+}
+
+// runBot opens the Discord session, registers event handlers and commands, and runs until interrupted.
+// On SIGINT or SIGTERM it stops accepting interactions, waits up to the shutdown timeout for in-flight handlers and publishes,
+// then drains NATS publisher connections and closes the session, flushing Sentry before returning.
+func runBot(config settings.Config, _ []string) error {
+	bot, err := newSession(config)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to create discord session")
+	}
+	hakaseClient, err := newHakaseClient(config)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to create clients")
+	}
+	hakaseClient.Lifecycle = lifecycle.New()
+
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+	go func() {
+		err := health.Serve(healthCtx, config.HealthAddr, readinessChecks(bot, hakaseClient))
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "health server stopped").Error())
+		}
+	}()
+
+	stopListener := make(chan bool, 1)
+	go hakaseClient.Notifications.ListenToStream(bot, hakaseClient.Backend, stopListener)
+
+	err = bot.Open()
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to open discord session")
+	}
+
+	slog.Info("registering event handlers")
+	bot.AddHandler(func(bot *discordgo.Session, ready *discordgo.Ready) {
+		events.Ready(bot, ready, hakaseClient)
+	})
+	bot.AddHandler(func(bot *discordgo.Session, guildCreate *discordgo.GuildCreate) {
+		events.GuildCreate(bot, guildCreate, hakaseClient)
+	})
+	bot.AddHandler(func(bot *discordgo.Session, guildDelete *discordgo.GuildDelete) {
+		events.GuildDelete(bot, guildDelete, hakaseClient)
+	})
+	bot.AddHandler(func(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate) {
+		handled := hakaseClient.Lifecycle.Handle(func() {
+			events.InteractionCreate(bot, interactionCreate, hakaseClient)
+		})
+		if !handled {
+			events.InteractionUnavailable(bot, interactionCreate)
+		}
+	})
+
+	stopCountdown := make(chan bool, 1)
+	go events.Countdown(bot, hakaseClient, stopCountdown)
+	stopStudyGroups := make(chan bool, 1)
+	go events.StudyGroups(bot, hakaseClient, stopStudyGroups)
+
+	// the scheduler, leader election and focus timers use the publisher connection, so they must stop before it is drained
+	stopElection, electionStopped := make(chan bool, 1), make(chan struct{})
+	go func() {
+		defer close(electionStopped)
+		hakaseClient.Notifications.RunLeaderElection(stopElection)
+	}()
+	stopScheduler, schedulerStopped := make(chan bool, 1), make(chan struct{})
+	go func() {
+		defer close(schedulerStopped)
+		hakaseClient.Notifications.RunScheduler(stopScheduler)
+	}()
+	stopFocusTimers, focusTimersStopped := make(chan bool, 1), make(chan struct{})
+	go func() {
+		defer close(focusTimersStopped)
+		events.FocusTimers(bot, hakaseClient, stopFocusTimers)
+	}()
+
+	// commands are registered per application, so only the first shard registers them
+	if config.ShardID == 0 {
+		slog.Info("registering interactions")
+		_, err = commands.Sync(bot, bot.State.User.ID, config.DevGuildID, commands.Commands)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to register commands").Error())
+		}
+	}
+
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	<-sc
+
+	slog.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	err = hakaseClient.Lifecycle.Drain(shutdownCtx)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to drain in-flight interactions").Error())
+	}
+
+	stopListener <- true
+	stopCountdown <- true
+	stopStudyGroups <- true
+	stopScheduler <- true
+	<-schedulerStopped
+	stopFocusTimers <- true
+	<-focusTimersStopped
+	stopElection <- true
+	<-electionStopped
+
+	err = hakaseClient.Notifications.DrainPublisher(shutdownCtx)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to drain NATS publisher").Error())
+	}
+	stopHealth()
+
+	err = bot.Close()
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to close discord session")
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Package cli provides the stream subcommand for inspecting the notifications stream.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/settings"
+	"github.com/palantir/stacktrace"
+)
+
+// streamCLI inspects the notifications stream.
+// Usage: hakase-discord stream inspect [-limit N]
+func streamCLI(config settings.Config, args []string) error {
+	if len(args) == 0 || args[0] != "inspect" {
+		return stacktrace.NewError("usage: hakase-discord stream inspect [-limit N]")
+	}
+	flags := flag.NewFlagSet("stream inspect", flag.ContinueOnError)
+	limit := flags.Int("limit", 50, "maximum number of pending messages and scheduled reminders to show")
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to parse flags")
+	}
+
+	bot, err := newSession(config)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to create discord session")
+	}
+	hakaseClient, err := newHakaseClient(config)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to create clients")
+	}
+	ctx, span := startSpan(bot, "stream inspect")
+	defer span.End()
+
+	status, err := hakaseClient.Notifications.InspectStream(ctx, *limit)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to inspect stream")
+	}
+
+	fmt.Printf("stream %s: %d messages, sequences %d-%d\n", status.Stream.Config.Name, status.Stream.State.Msgs, status.Stream.State.FirstSeq, status.Stream.State.LastSeq)
+	fmt.Printf("consumer %s: %d undelivered (lag), %d awaiting ack, %d redelivered, ack floor %d\n\n", status.Consumer.Name, status.Consumer.NumPending, status.Consumer.NumAckPending, status.Consumer.NumRedelivered, status.Consumer.AckFloor.Stream)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "SEQ\tSUBJECT\tPUBLISHED\tMESSAGE")
+	for _, pending := range status.Pending {
+		message := string(pending.Data)
+		if pending.Notification != nil {
+			message = fmt.Sprintf("assignment %d in %s, %s before due", pending.Notification.AssignmentID, pending.Notification.CourseID, pending.Notification.Before)
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", pending.Sequence, pending.Subject, pending.Published.Format(time.RFC3339), message)
+	}
+	err = writer.Flush()
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to print pending messages")
+	}
+
+	fmt.Printf("\n%d scheduled reminders shown\n\n", len(status.Scheduled))
+	writer = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "FIRE AT\tLEASED UNTIL\tTYPE\tMESSAGE ID")
+	for _, reminder := range status.Scheduled {
+		leasedUntil := "-"
+		if !reminder.LeaseUntil.IsZero() {
+			leasedUntil = reminder.LeaseUntil.Format(time.RFC3339)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", reminder.FireAt.Format(time.RFC3339), leasedUntil, reminder.Type, reminder.ID)
+	}
+	return writer.Flush()
+}
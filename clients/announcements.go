@@ -0,0 +1,140 @@
+// Package clients implements backend API operations for announcements, and posting announcements to Discord.
+package clients
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/palantir/stacktrace"
+)
+
+// Announcement is a course announcement posted by staff, optionally scheduled for later.
+type Announcement struct {
+	ID           int       `json:"id,omitempty"`
+	CourseID     string    `json:"course_id"`
+	AuthorID     string    `json:"author_id"`
+	ChannelID    string    `json:"channel_id"`
+	RoleID       string    `json:"role_id,omitempty"`
+	Title        string    `json:"title"`
+	Message      string    `json:"message"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	// MessageID is the ID of the Discord message the announcement was posted as, empty until it is posted.
+	MessageID string `json:"message_id,omitempty"`
+	// Acknowledgements are the IDs of the users who acknowledged the announcement, in the order they acknowledged it.
+	Acknowledgements []string `json:"acknowledgements,omitempty"`
+}
+
+// announcementAcknowledgement is the request body acknowledging an announcement.
+type announcementAcknowledgement struct {
+	AnnouncementID string `json:"announcement_id"`
+	UserID         string `json:"user_id"`
+}
+
+// ReadAnnouncement retrieves an announcement by its ID from the backend, with its acknowledgements.
+func (backend *APIClient) ReadAnnouncement(ctx context.Context, announcementID string) (Announcement, error) {
+	ctx, span := tracing.Start(ctx, "readAnnouncement")
+	defer span.End()
+
+	announcement := Announcement{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("announcements?id=%s", announcementID), nil, http.StatusOK, &announcement)
+	return announcement, err
+}
+
+// CreateAnnouncement creates a new announcement in the backend.
+func (backend *APIClient) CreateAnnouncement(ctx context.Context, announcement Announcement) (Announcement, error) {
+	ctx, span := tracing.Start(ctx, "createAnnouncement")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPost, "announcements", announcement, http.StatusCreated, &announcement)
+	return announcement, err
+}
+
+// UpdateAnnouncement updates an existing announcement in the backend.
+func (backend *APIClient) UpdateAnnouncement(ctx context.Context, announcement Announcement) (Announcement, error) {
+	ctx, span := tracing.Start(ctx, "updateAnnouncement")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPut, "announcements", announcement, http.StatusAccepted, &announcement)
+	return announcement, err
+}
+
+// AcknowledgeAnnouncement records that a user acknowledged an announcement. Acknowledging an announcement again has no effect.
+func (backend *APIClient) AcknowledgeAnnouncement(ctx context.Context, announcementID string, userID string) error {
+	ctx, span := tracing.Start(ctx, "acknowledgeAnnouncement")
+	defer span.End()
+
+	acknowledgement := announcementAcknowledgement{AnnouncementID: announcementID, UserID: userID}
+	return backend.jsonRequest(ctx, http.MethodPost, "announcements/acknowledgements", acknowledgement, http.StatusCreated, nil)
+}
+
+// announcementMessage returns the Discord message for an announcement, mentioning its role,
+// with buttons to acknowledge it and for staff to see who acknowledged it.
+func announcementMessage(locale locales.Locale, announcement Announcement) *discordgo.MessageSend {
+	message := &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       announcement.Title,
+			Description: announcement.Message,
+			Timestamp:   announcement.ScheduledFor.Format(time.RFC3339),
+			Footer:      &discordgo.MessageEmbedFooter{Text: locale.T("announcement.footer")},
+		}},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Emoji:    &discordgo.ComponentEmoji{Name: "✅"},
+						Label:    locale.T("button.acknowledge"),
+						Style:    discordgo.SuccessButton,
+						CustomID: fmt.Sprintf("acknowledgeAnnouncement_%d", announcement.ID),
+					},
+					discordgo.Button{
+						Emoji:    &discordgo.ComponentEmoji{Name: "📊"},
+						Label:    locale.T("button.acknowledgements"),
+						Style:    discordgo.SecondaryButton,
+						CustomID: fmt.Sprintf("announcementAcknowledgements_%d", announcement.ID),
+					},
+				},
+			},
+		},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if announcement.RoleID != "" {
+		message.Content = fmt.Sprintf("<@&%s>", announcement.RoleID)
+		message.AllowedMentions.Roles = []string{announcement.RoleID}
+	}
+	return message
+}
+
+// PostAnnouncement posts an announcement to its channel in the course's default locale, and records the message it was posted as.
+// An error is returned only if the announcement was not posted.
+func PostAnnouncement(ctx context.Context, backend BackendClient, announcement Announcement) (Announcement, error) {
+	ctx, span := tracing.Start(ctx, "postAnnouncement")
+	defer span.End()
+	bot := Session(ctx)
+
+	locale := locales.Default
+	course, err := backend.ReadCourse(ctx, announcement.CourseID)
+	if err != nil {
+		slog.Warn(stacktrace.Propagate(err, "error reading course locale: %s", announcement.CourseID).Error())
+	} else {
+		locale = locales.Resolve(course.Locale)
+	}
+
+	message, err := bot.ChannelMessageSendComplex(announcement.ChannelID, announcementMessage(locale, announcement))
+	if err != nil {
+		return announcement, stacktrace.Propagate(err, "failed to post announcement %d to channel: %s", announcement.ID, announcement.ChannelID)
+	}
+
+	announcement.MessageID = message.ID
+	_, err = backend.UpdateAnnouncement(ctx, announcement)
+	if err != nil {
+		// the announcement was posted, so it must not be posted again
+		slog.Error(stacktrace.Propagate(err, "failed to record message of announcement: %d", announcement.ID).Error())
+	}
+	return announcement, nil
+}
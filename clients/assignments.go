@@ -3,48 +3,128 @@ package clients
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/getsentry/sentry-go"
+	"github.com/dragonejt/hakase-discord/tracing"
 	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/propagation"
 )
 
+const (
+	HomeworkAssignment = "homework"
+	ExamAssignment     = "exam"
+)
+
+// AssignmentKinds lists every known assignment kind.
+var AssignmentKinds = []string{HomeworkAssignment, ExamAssignment}
+
+// ReminderProfiles maps an assignment kind to the offsets before the due date at which reminders are sent.
+type ReminderProfiles map[string][]time.Duration
+
+// DefaultReminderProfiles are the reminder offsets used unless overridden by configuration.
+var DefaultReminderProfiles = ReminderProfiles{
+	HomeworkAssignment: {24 * time.Hour, time.Hour},
+	ExamAssignment:     {14 * 24 * time.Hour, 7 * 24 * time.Hour, 3 * 24 * time.Hour, 24 * time.Hour},
+}
+
+// WithOverrides returns a copy of the profiles with the offsets in overrides replacing those of the same kind.
+func (profiles ReminderProfiles) WithOverrides(overrides map[string][]time.Duration) (ReminderProfiles, error) {
+	merged := make(ReminderProfiles, len(profiles))
+	for kind, offsets := range profiles {
+		merged[kind] = offsets
+	}
+	for kind, offsets := range overrides {
+		if !slices.Contains(AssignmentKinds, kind) {
+			return nil, stacktrace.NewError("unknown assignment type in reminder profiles: %s", kind)
+		}
+		merged[kind] = offsets
+	}
+	return merged, nil
+}
+
+// For returns the reminder offsets for an assignment kind, defaulting to the homework profile, then the default homework profile.
+func (profiles ReminderProfiles) For(kind string) []time.Duration {
+	if reminders, exists := profiles[kind]; exists {
+		return reminders
+	}
+	if reminders, exists := profiles[HomeworkAssignment]; exists {
+		return reminders
+	}
+	return DefaultReminderProfiles[HomeworkAssignment]
+}
+
 type Assignment struct {
 	ID       int       `json:"id,omitempty"`
 	Course   int       `json:"course,omitempty"`
 	CourseID string    `json:"course_id,omitempty"`
 	Name     string    `json:"name,omitempty"`
+	Kind     string    `json:"kind,omitempty"`
 	Due      time.Time `json:"due,omitempty"`
 	Link     string    `json:"link,omitempty"`
 }
 
+// IsExam reports whether the assignment is an exam.
+func (assignment Assignment) IsExam() bool {
+	return assignment.Kind == ExamAssignment
+}
+
+// UpcomingReminders returns the notifications for the assignment's reminders in profiles that have not yet passed at now.
+// For example, a 2 week reminder is skipped for an exam in 5 days.
+func (assignment Assignment) UpcomingReminders(profiles ReminderProfiles, now time.Time) []AssignmentNotification {
+	notifications := []AssignmentNotification{}
+	for _, before := range profiles.For(assignment.Kind) {
+		if now.After(assignment.Due.Add(-1 * before)) {
+			continue
+		}
+		notifications = append(notifications, AssignmentNotification{
+			AssignmentID: assignment.ID,
+			CourseID:     assignment.CourseID,
+			Before:       before,
+			Due:          assignment.Due,
+		})
+	}
+	return notifications
+}
+
+// ParseAssignmentKind normalizes user input into a known assignment kind. Empty input defaults to homework.
+func ParseAssignmentKind(kind string) (string, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" {
+		return HomeworkAssignment, nil
+	}
+	if !slices.Contains(AssignmentKinds, kind) {
+		return "", stacktrace.NewError("unknown assignment type: %s", kind)
+	}
+	return kind, nil
+}
+
 // ReadAssignment retrieves an assignment by its ID from the backend.
-func (backend *APIClient) ReadAssignment(span *sentry.Span, assignmentID string) (Assignment, error) {
-	span = span.StartChild("readAssignment")
-	defer span.Finish()
+func (backend *APIClient) ReadAssignment(ctx context.Context, assignmentID string) (Assignment, error) {
+	ctx, span := tracing.Start(ctx, "readAssignment")
+	defer span.End()
 
 	assignment := Assignment{}
 
-	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/assignments?id=%s", backend.Url, assignmentID), nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/assignments?id=%s", backend.Url, assignmentID), nil)
 	if err != nil {
 		return assignment, stacktrace.Propagate(err, "failed to create API request")
 	}
 	request.Header.Add("accept", "application/json")
 	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
-	request.Header.Add(sentry.SentryTraceHeader, sentry.CurrentHub().GetTraceparent())
-	request.Header.Add(sentry.SentryBaggageHeader, sentry.CurrentHub().GetBaggage())
+	tracing.Inject(ctx, propagation.HeaderCarrier(request.Header))
 
-	response, err := backend.HttpClient.Do(request)
+	response, err := backend.execute(request, http.StatusOK)
 	if err != nil {
-		return assignment, stacktrace.Propagate(err, "failed to execute API request")
-	}
-	if response.StatusCode != http.StatusOK {
-		return assignment, stacktrace.Propagate(err, "failed status code API response: %d", response.StatusCode)
+		return assignment, stacktrace.Propagate(err, "failed API request")
 	}
+	defer response.Body.Close()
 
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
@@ -60,53 +140,47 @@ func (backend *APIClient) ReadAssignment(span *sentry.Span, assignmentID string)
 }
 
 // HeadAssignment checks if an assignment exists in the backend.
-func (backend *APIClient) HeadAssignment(span *sentry.Span, assignmentID string) error {
-	span = span.StartChild("headAssignment")
-	defer span.Finish()
+func (backend *APIClient) HeadAssignment(ctx context.Context, assignmentID string) error {
+	ctx, span := tracing.Start(ctx, "headAssignment")
+	defer span.End()
 
-	request, err := http.NewRequest(http.MethodHead, fmt.Sprintf("%s/assignments?id=%s", backend.Url, assignmentID), nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, fmt.Sprintf("%s/assignments?id=%s", backend.Url, assignmentID), nil)
 	if err != nil {
 		return stacktrace.Propagate(err, "failed to create API request")
 	}
 	request.Header.Add("accept", "application/json")
 	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
-	request.Header.Add(sentry.SentryTraceHeader, sentry.CurrentHub().GetTraceparent())
-	request.Header.Add(sentry.SentryBaggageHeader, sentry.CurrentHub().GetBaggage())
+	tracing.Inject(ctx, propagation.HeaderCarrier(request.Header))
 
-	response, err := backend.HttpClient.Do(request)
+	response, err := backend.execute(request, http.StatusOK)
 	if err != nil {
-		return stacktrace.Propagate(err, "failed to execute API request")
-	}
-	if response.StatusCode != http.StatusOK {
-		return stacktrace.Propagate(err, "failed status code API response: %d", response.StatusCode)
+		return stacktrace.Propagate(err, "failed API request")
 	}
+	defer response.Body.Close()
 
 	return nil
 }
 
 // ListAssignments lists all assignments for a course.
-func (backend *APIClient) ListAssignments(span *sentry.Span, courseID string) ([]Assignment, error) {
-	span = span.StartChild("listAssignments")
-	defer span.Finish()
+func (backend *APIClient) ListAssignments(ctx context.Context, courseID string) ([]Assignment, error) {
+	ctx, span := tracing.Start(ctx, "listAssignments")
+	defer span.End()
 
 	assignments := []Assignment{}
 
-	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/assignments?course_id=%s", backend.Url, courseID), nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/assignments?course_id=%s", backend.Url, courseID), nil)
 	if err != nil {
 		return assignments, stacktrace.Propagate(err, "failed to create API request")
 	}
 	request.Header.Add("accept", "application/json")
 	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
-	request.Header.Add(sentry.SentryTraceHeader, sentry.CurrentHub().GetTraceparent())
-	request.Header.Add(sentry.SentryBaggageHeader, sentry.CurrentHub().GetBaggage())
+	tracing.Inject(ctx, propagation.HeaderCarrier(request.Header))
 
-	response, err := backend.HttpClient.Do(request)
+	response, err := backend.execute(request, http.StatusOK)
 	if err != nil {
-		return assignments, stacktrace.Propagate(err, "failed to execute API request")
-	}
-	if response.StatusCode != http.StatusOK {
-		return assignments, stacktrace.Propagate(err, "failed status code API response: %d", response.StatusCode)
+		return assignments, stacktrace.Propagate(err, "failed API request")
 	}
+	defer response.Body.Close()
 
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
@@ -122,32 +196,29 @@ func (backend *APIClient) ListAssignments(span *sentry.Span, courseID string) ([
 }
 
 // CreateAssignment creates a new assignment in the backend.
-func (backend *APIClient) CreateAssignment(span *sentry.Span, assignment Assignment) (Assignment, error) {
-	span = span.StartChild("createAssignment")
-	defer span.Finish()
+func (backend *APIClient) CreateAssignment(ctx context.Context, assignment Assignment) (Assignment, error) {
+	ctx, span := tracing.Start(ctx, "createAssignment")
+	defer span.End()
 
 	jsonBody, err := json.Marshal(assignment)
 	if err != nil {
 		return Assignment{}, stacktrace.Propagate(err, "failed to marshal assignment")
 	}
 
-	request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/assignments", backend.Url), bytes.NewReader(jsonBody))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/assignments", backend.Url), bytes.NewReader(jsonBody))
 	if err != nil {
 		return Assignment{}, stacktrace.Propagate(err, "failed to create API request")
 	}
 	request.Header.Add("accept", "application/json")
 	request.Header.Add("content-type", "application/json")
 	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
-	request.Header.Add(sentry.SentryTraceHeader, sentry.CurrentHub().GetTraceparent())
-	request.Header.Add(sentry.SentryBaggageHeader, sentry.CurrentHub().GetBaggage())
+	tracing.Inject(ctx, propagation.HeaderCarrier(request.Header))
 
-	response, err := backend.HttpClient.Do(request)
+	response, err := backend.execute(request, http.StatusCreated)
 	if err != nil {
-		return Assignment{}, stacktrace.Propagate(err, "failed to execute API request")
-	}
-	if response.StatusCode != http.StatusCreated {
-		return Assignment{}, stacktrace.Propagate(err, "failed status code API response: %d", response.StatusCode)
+		return Assignment{}, stacktrace.Propagate(err, "failed API request")
 	}
+	defer response.Body.Close()
 
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
@@ -163,32 +234,29 @@ func (backend *APIClient) CreateAssignment(span *sentry.Span, assignment Assignm
 }
 
 // UpdateAssignment updates an existing assignment in the backend.
-func (backend *APIClient) UpdateAssignment(span *sentry.Span, assignment Assignment) (Assignment, error) {
-	span = span.StartChild("updateAssignment")
-	defer span.Finish()
+func (backend *APIClient) UpdateAssignment(ctx context.Context, assignment Assignment) (Assignment, error) {
+	ctx, span := tracing.Start(ctx, "updateAssignment")
+	defer span.End()
 
 	jsonBody, err := json.Marshal(assignment)
 	if err != nil {
 		return Assignment{}, stacktrace.Propagate(err, "failed to marshal assignment")
 	}
 
-	request, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/assignments", backend.Url), bytes.NewReader(jsonBody))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/assignments", backend.Url), bytes.NewReader(jsonBody))
 	if err != nil {
 		return Assignment{}, stacktrace.Propagate(err, "failed to create API request")
 	}
 	request.Header.Add("accept", "application/json")
 	request.Header.Add("content-type", "application/json")
 	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
-	request.Header.Add(sentry.SentryTraceHeader, sentry.CurrentHub().GetTraceparent())
-	request.Header.Add(sentry.SentryBaggageHeader, sentry.CurrentHub().GetBaggage())
+	tracing.Inject(ctx, propagation.HeaderCarrier(request.Header))
 
-	response, err := backend.HttpClient.Do(request)
+	response, err := backend.execute(request, http.StatusAccepted)
 	if err != nil {
-		return Assignment{}, stacktrace.Propagate(err, "failed to execute API request")
-	}
-	if response.StatusCode != http.StatusAccepted {
-		return Assignment{}, stacktrace.Propagate(err, "failed status code API response: %d", response.StatusCode)
+		return Assignment{}, stacktrace.Propagate(err, "failed API request")
 	}
+	defer response.Body.Close()
 
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
@@ -204,25 +272,22 @@ func (backend *APIClient) UpdateAssignment(span *sentry.Span, assignment Assignm
 }
 
 // DeleteAssignment deletes an assignment from the backend.
-func (backend *APIClient) DeleteAssignment(span *sentry.Span, assignmentID string) error {
-	span = span.StartChild("deleteAssignment")
-	defer span.Finish()
+func (backend *APIClient) DeleteAssignment(ctx context.Context, assignmentID string) error {
+	ctx, span := tracing.Start(ctx, "deleteAssignment")
+	defer span.End()
 
-	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/assignments?id=%s", backend.Url, assignmentID), nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/assignments?id=%s", backend.Url, assignmentID), nil)
 	if err != nil {
 		return stacktrace.Propagate(err, "failed to create API request")
 	}
 	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
-	request.Header.Add(sentry.SentryTraceHeader, sentry.CurrentHub().GetTraceparent())
-	request.Header.Add(sentry.SentryBaggageHeader, sentry.CurrentHub().GetBaggage())
+	tracing.Inject(ctx, propagation.HeaderCarrier(request.Header))
 
-	response, err := backend.HttpClient.Do(request)
+	response, err := backend.execute(request, http.StatusNoContent)
 	if err != nil {
-		return stacktrace.Propagate(err, "failed to execute API request")
-	}
-	if response.StatusCode != http.StatusNoContent {
-		return stacktrace.Propagate(err, "failed status code API response: %d", response.StatusCode)
+		return stacktrace.Propagate(err, "failed API request")
 	}
+	defer response.Body.Close()
 
 	return nil
 }
@@ -0,0 +1,80 @@
+package clients_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/stretchr/testify/suite"
+)
+
+type AssignmentsTestSuite struct {
+	suite.Suite
+}
+
+func TestAssignments(t *testing.T) {
+	suite.Run(t, new(AssignmentsTestSuite))
+}
+
+func (testSuite *AssignmentsTestSuite) TestParseAssignmentKind() {
+	kind, err := clients.ParseAssignmentKind(" Exam ")
+	testSuite.NoError(err)
+	testSuite.Equal(clients.ExamAssignment, kind)
+
+	kind, err = clients.ParseAssignmentKind("")
+	testSuite.NoError(err)
+	testSuite.Equal(clients.HomeworkAssignment, kind)
+
+	_, err = clients.ParseAssignmentKind("quiz")
+	testSuite.Error(err)
+}
+
+func (testSuite *AssignmentsTestSuite) TestReminderProfilesFor() {
+	testSuite.Equal(clients.DefaultReminderProfiles[clients.ExamAssignment], clients.DefaultReminderProfiles.For(clients.ExamAssignment))
+	// unknown kinds fall back to the homework profile
+	testSuite.Equal(clients.DefaultReminderProfiles[clients.HomeworkAssignment], clients.DefaultReminderProfiles.For("quiz"))
+
+	homework := []time.Duration{2 * time.Hour}
+	testSuite.Equal(homework, clients.ReminderProfiles{clients.HomeworkAssignment: homework}.For(clients.ExamAssignment))
+	testSuite.Equal(clients.DefaultReminderProfiles[clients.HomeworkAssignment], clients.ReminderProfiles{}.For(clients.ExamAssignment))
+}
+
+func (testSuite *AssignmentsTestSuite) TestReminderProfilesWithOverrides() {
+	exam := []time.Duration{48 * time.Hour}
+	profiles, err := clients.DefaultReminderProfiles.WithOverrides(map[string][]time.Duration{clients.ExamAssignment: exam})
+	testSuite.Require().NoError(err)
+	testSuite.Equal(exam, profiles.For(clients.ExamAssignment))
+	testSuite.Equal(clients.DefaultReminderProfiles[clients.HomeworkAssignment], profiles.For(clients.HomeworkAssignment))
+	// the defaults are not changed
+	testSuite.Len(clients.DefaultReminderProfiles[clients.ExamAssignment], 4)
+
+	_, err = clients.DefaultReminderProfiles.WithOverrides(map[string][]time.Duration{"quiz": exam})
+	testSuite.Error(err)
+}
+
+func (testSuite *AssignmentsTestSuite) TestUpcomingReminders() {
+	due := time.Date(2025, 2, 10, 9, 0, 0, 0, time.UTC)
+	assignment := clients.Assignment{ID: 1, CourseID: "course", Kind: clients.ExamAssignment, Due: due}
+
+	// the 14 day reminder has already passed
+	reminders := assignment.UpcomingReminders(clients.DefaultReminderProfiles, due.AddDate(0, 0, -10))
+	testSuite.Len(reminders, 3)
+	testSuite.Equal(7*24*time.Hour, reminders[0].Before)
+	testSuite.Equal(due, reminders[0].Due)
+}
+
+func (testSuite *AssignmentsTestSuite) TestReadAssignmentNotFound() {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	backend := &clients.APIClient{Url: server.URL, HttpClient: server.Client()}
+
+	_, err := backend.ReadAssignment(context.Background(), "1")
+	testSuite.Error(err)
+	testSuite.True(clients.IsNotFound(err))
+
+	_, err = backend.ReadCourse(context.Background(), "course")
+	testSuite.True(clients.IsNotFound(err))
+}
@@ -0,0 +1,242 @@
+// Package clients implements backend API operations for attendance sessions, and their reports.
+package clients
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// attendanceCodeAlphabet has 32 characters, leaving out 0, O, 1 and I which are easily confused when read off a screen.
+	attendanceCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	attendanceCodeLength   = 6
+)
+
+// AttendanceSession is a section's attendance, taken by students checking in with its code or button until it expires.
+type AttendanceSession struct {
+	ID       int    `json:"id,omitempty"`
+	CourseID string `json:"course_id"`
+	HostID   string `json:"host_id"`
+	// ChannelID and MessageID locate the check-in message, which is only posted for sessions taken with a button.
+	ChannelID string    `json:"channel_id"`
+	MessageID string    `json:"message_id,omitempty"`
+	Code      string    `json:"code"`
+	StartedAt time.Time `json:"started_at"`
+	// LateAfter is when check-ins start counting as late, and is zero if they never do.
+	LateAfter time.Time `json:"late_after,omitzero"`
+	// ExpiresAt is when students can no longer check in. Staff ending a session early moves it to when they ended it.
+	ExpiresAt time.Time           `json:"expires_at"`
+	CheckIns  []AttendanceCheckIn `json:"check_ins,omitempty"`
+}
+
+// AttendanceCheckIn is a student checking in to an attendance session.
+type AttendanceCheckIn struct {
+	SessionID   int       `json:"session_id,omitempty"`
+	UserID      string    `json:"user_id"`
+	CheckedInAt time.Time `json:"checked_in_at"`
+	Late        bool      `json:"late"`
+}
+
+// StudentAttendance counts a student's attendance over every session of a course.
+type StudentAttendance struct {
+	UserID  string
+	Present int
+	Late    int
+	// Absent counts the sessions the student did not check in to.
+	Absent int
+}
+
+// NewAttendanceCode returns a random code for students to check in to an attendance session with.
+func NewAttendanceCode() (string, error) {
+	random := make([]byte, attendanceCodeLength)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to generate attendance code")
+	}
+	code := make([]byte, attendanceCodeLength)
+	for index, value := range random {
+		code[index] = attendanceCodeAlphabet[int(value)%len(attendanceCodeAlphabet)]
+	}
+	return string(code), nil
+}
+
+// Active reports whether students can check in to the session at now.
+func (session AttendanceSession) Active(now time.Time) bool {
+	return now.Before(session.ExpiresAt)
+}
+
+// IsLate reports whether a check-in at a time counts as late.
+func (session AttendanceSession) IsLate(at time.Time) bool {
+	return !session.LateAfter.IsZero() && at.After(session.LateAfter)
+}
+
+// CheckIn returns a student's check-in to the session, and whether they checked in.
+func (session AttendanceSession) CheckIn(userID string) (AttendanceCheckIn, bool) {
+	index := slices.IndexFunc(session.CheckIns, func(checkIn AttendanceCheckIn) bool { return checkIn.UserID == userID })
+	if index < 0 {
+		return AttendanceCheckIn{}, false
+	}
+	return session.CheckIns[index], true
+}
+
+// AttendanceSessionByCode returns the most recently started session among sessions with a code, and whether there is one.
+// Codes are matched case insensitively, since students type them in.
+func AttendanceSessionByCode(sessions []AttendanceSession, code string) (AttendanceSession, bool) {
+	found, exists := AttendanceSession{}, false
+	for _, session := range sessions {
+		if strings.EqualFold(session.Code, strings.TrimSpace(code)) && (!exists || session.StartedAt.After(found.StartedAt)) {
+			found, exists = session, true
+		}
+	}
+	return found, exists
+}
+
+// AttendanceRoster returns the user IDs of the students among a guild's members: every member other than bots, the owner
+// and members with a role granting Administrator, who are the course's staff.
+func AttendanceRoster(guild *discordgo.Guild, members []*discordgo.Member) []string {
+	staffRoles := map[string]bool{}
+	for _, role := range guild.Roles {
+		if role.Permissions&discordgo.PermissionAdministrator != 0 {
+			staffRoles[role.ID] = true
+		}
+	}
+	// the @everyone role has the guild's ID, and is not listed in members' roles
+	everyoneStaff := staffRoles[guild.ID]
+
+	roster := []string{}
+	for _, member := range members {
+		if member.User.Bot || member.User.ID == guild.OwnerID || everyoneStaff {
+			continue
+		}
+		if !slices.ContainsFunc(member.Roles, func(roleID string) bool { return staffRoles[roleID] }) {
+			roster = append(roster, member.User.ID)
+		}
+	}
+	return roster
+}
+
+// SummarizeAttendance counts the attendance of every student in roster, and of every student who checked in to any of sessions
+// but is no longer in roster, sorted by user ID. Students in roster who never checked in are absent from every session.
+func SummarizeAttendance(sessions []AttendanceSession, roster []string) []StudentAttendance {
+	students := map[string]*StudentAttendance{}
+	for _, userID := range roster {
+		students[userID] = &StudentAttendance{UserID: userID}
+	}
+	for _, session := range sessions {
+		for _, checkIn := range session.CheckIns {
+			student, exists := students[checkIn.UserID]
+			if !exists {
+				student = &StudentAttendance{UserID: checkIn.UserID}
+				students[checkIn.UserID] = student
+			}
+			if checkIn.Late {
+				student.Late++
+			} else {
+				student.Present++
+			}
+		}
+	}
+
+	summary := make([]StudentAttendance, 0, len(students))
+	for _, student := range students {
+		student.Absent = len(sessions) - student.Present - student.Late
+		summary = append(summary, *student)
+	}
+	slices.SortFunc(summary, func(a StudentAttendance, b StudentAttendance) int { return cmp.Compare(a.UserID, b.UserID) })
+	return summary
+}
+
+// ReportCSV returns the session's check-ins as CSV, in the order students checked in.
+func (session AttendanceSession) ReportCSV() ([]byte, error) {
+	rows := [][]string{{"user_id", "checked_in_at", "status"}}
+	for _, checkIn := range session.CheckIns {
+		status := "present"
+		if checkIn.Late {
+			status = "late"
+		}
+		rows = append(rows, []string{checkIn.UserID, checkIn.CheckedInAt.Format(time.RFC3339), status})
+	}
+	return writeCSV(rows, fmt.Sprintf("attendance session %d", session.ID))
+}
+
+// StudentAttendanceCSV returns the attendance of every student in roster, and every student who checked in, over sessions as CSV.
+// Attendance is the percentage of sessions the student checked in to, on time or late.
+func StudentAttendanceCSV(sessions []AttendanceSession, roster []string) ([]byte, error) {
+	rows := [][]string{{"user_id", "present", "late", "absent", "attendance"}}
+	for _, student := range SummarizeAttendance(sessions, roster) {
+		attendance := int(math.Round(float64(student.Present+student.Late) * 100 / float64(len(sessions))))
+		rows = append(rows, []string{student.UserID, strconv.Itoa(student.Present), strconv.Itoa(student.Late), strconv.Itoa(student.Absent), strconv.Itoa(attendance)})
+	}
+	return writeCSV(rows, "student attendance")
+}
+
+// writeCSV returns rows as CSV, naming what the rows are in the error if writing them fails.
+func writeCSV(rows [][]string, name string) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+	err := writer.WriteAll(rows)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to write %s", name)
+	}
+	return buffer.Bytes(), nil
+}
+
+// ReadAttendanceSession retrieves an attendance session by its ID from the backend, with its check-ins.
+func (backend *APIClient) ReadAttendanceSession(ctx context.Context, sessionID string) (AttendanceSession, error) {
+	ctx, span := tracing.Start(ctx, "readAttendanceSession")
+	defer span.End()
+
+	session := AttendanceSession{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("attendance/sessions?id=%s", sessionID), nil, http.StatusOK, &session)
+	return session, err
+}
+
+// ListAttendanceSessions lists all attendance sessions for a course, with their check-ins.
+func (backend *APIClient) ListAttendanceSessions(ctx context.Context, courseID string) ([]AttendanceSession, error) {
+	ctx, span := tracing.Start(ctx, "listAttendanceSessions")
+	defer span.End()
+
+	sessions := []AttendanceSession{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("attendance/sessions?course_id=%s", courseID), nil, http.StatusOK, &sessions)
+	return sessions, err
+}
+
+// CreateAttendanceSession creates a new attendance session in the backend.
+func (backend *APIClient) CreateAttendanceSession(ctx context.Context, session AttendanceSession) (AttendanceSession, error) {
+	ctx, span := tracing.Start(ctx, "createAttendanceSession")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPost, "attendance/sessions", session, http.StatusCreated, &session)
+	return session, err
+}
+
+// UpdateAttendanceSession updates an existing attendance session in the backend, such as to end it early. Check-ins are not updated.
+func (backend *APIClient) UpdateAttendanceSession(ctx context.Context, session AttendanceSession) (AttendanceSession, error) {
+	ctx, span := tracing.Start(ctx, "updateAttendanceSession")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPut, "attendance/sessions", session, http.StatusAccepted, &session)
+	return session, err
+}
+
+// CheckInAttendance records a student checking in to an attendance session. The backend rejects a second check-in by the same student.
+func (backend *APIClient) CheckInAttendance(ctx context.Context, checkIn AttendanceCheckIn) error {
+	ctx, span := tracing.Start(ctx, "checkInAttendance")
+	defer span.End()
+
+	return backend.jsonRequest(ctx, http.MethodPost, "attendance/checkins", checkIn, http.StatusCreated, nil)
+}
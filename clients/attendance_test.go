@@ -0,0 +1,132 @@
+package clients_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/stretchr/testify/suite"
+)
+
+type AttendanceTestSuite struct {
+	suite.Suite
+	startedAt time.Time
+	sessions  []clients.AttendanceSession
+}
+
+func TestAttendance(t *testing.T) {
+	suite.Run(t, new(AttendanceTestSuite))
+}
+
+func (testSuite *AttendanceTestSuite) SetupTest() {
+	testSuite.startedAt = time.Date(2025, 1, 30, 14, 0, 0, 0, time.UTC)
+	testSuite.sessions = []clients.AttendanceSession{
+		{
+			ID:        1,
+			Code:      "ABC234",
+			StartedAt: testSuite.startedAt,
+			LateAfter: testSuite.startedAt.Add(5 * time.Minute),
+			ExpiresAt: testSuite.startedAt.Add(15 * time.Minute),
+			CheckIns: []clients.AttendanceCheckIn{
+				{UserID: "student1", CheckedInAt: testSuite.startedAt.Add(time.Minute)},
+				{UserID: "student2", CheckedInAt: testSuite.startedAt.Add(10 * time.Minute), Late: true},
+			},
+		},
+		{
+			ID:        2,
+			Code:      "XYZ789",
+			StartedAt: testSuite.startedAt.Add(7 * 24 * time.Hour),
+			ExpiresAt: testSuite.startedAt.Add(7*24*time.Hour + 15*time.Minute),
+			CheckIns: []clients.AttendanceCheckIn{
+				{UserID: "student1", CheckedInAt: testSuite.startedAt.Add(7*24*time.Hour + time.Minute)},
+			},
+		},
+	}
+}
+
+func (testSuite *AttendanceTestSuite) TestNewAttendanceCode() {
+	code, err := clients.NewAttendanceCode()
+	testSuite.Require().NoError(err)
+	testSuite.Len(code, 6)
+	testSuite.NotContains(code, "0", "codes leave out characters that are easily confused")
+	testSuite.Equal(strings.ToUpper(code), code)
+}
+
+func (testSuite *AttendanceTestSuite) TestCheckIn() {
+	session := testSuite.sessions[0]
+	testSuite.True(session.Active(testSuite.startedAt.Add(14 * time.Minute)))
+	testSuite.False(session.Active(testSuite.startedAt.Add(15 * time.Minute)))
+	testSuite.False(session.IsLate(testSuite.startedAt.Add(5 * time.Minute)))
+	testSuite.True(session.IsLate(testSuite.startedAt.Add(6 * time.Minute)))
+	testSuite.False(testSuite.sessions[1].IsLate(testSuite.sessions[1].ExpiresAt))
+
+	checkIn, exists := session.CheckIn("student2")
+	testSuite.True(exists)
+	testSuite.True(checkIn.Late)
+	_, exists = session.CheckIn("student3")
+	testSuite.False(exists)
+}
+
+func (testSuite *AttendanceTestSuite) TestAttendanceSessionByCode() {
+	session, exists := clients.AttendanceSessionByCode(testSuite.sessions, " abc234 ")
+	testSuite.True(exists)
+	testSuite.Equal(1, session.ID)
+
+	// a code reused by a later session finds the later session
+	testSuite.sessions[1].Code = "ABC234"
+	session, exists = clients.AttendanceSessionByCode(testSuite.sessions, "ABC234")
+	testSuite.True(exists)
+	testSuite.Equal(2, session.ID)
+
+	_, exists = clients.AttendanceSessionByCode(testSuite.sessions, "QQQQQQ")
+	testSuite.False(exists)
+}
+
+func (testSuite *AttendanceTestSuite) TestSummarizeAttendance() {
+	testSuite.Equal([]clients.StudentAttendance{
+		{UserID: "student1", Present: 2, Late: 0, Absent: 0},
+		{UserID: "student2", Present: 0, Late: 1, Absent: 1},
+	}, clients.SummarizeAttendance(testSuite.sessions, []string{}))
+}
+
+func (testSuite *AttendanceTestSuite) TestSummarizeAttendanceRoster() {
+	// student3 never checked in, and student2 has left the course but still checked in
+	testSuite.Equal([]clients.StudentAttendance{
+		{UserID: "student1", Present: 2, Late: 0, Absent: 0},
+		{UserID: "student2", Present: 0, Late: 1, Absent: 1},
+		{UserID: "student3", Present: 0, Late: 0, Absent: 2},
+	}, clients.SummarizeAttendance(testSuite.sessions, []string{"student3", "student1"}))
+}
+
+func (testSuite *AttendanceTestSuite) TestReports() {
+	report, err := testSuite.sessions[0].ReportCSV()
+	testSuite.Require().NoError(err)
+	testSuite.Equal("user_id,checked_in_at,status\nstudent1,2025-01-30T14:01:00Z,present\nstudent2,2025-01-30T14:10:00Z,late\n", string(report))
+
+	report, err = clients.StudentAttendanceCSV(testSuite.sessions, []string{"student1", "student3"})
+	testSuite.Require().NoError(err)
+	testSuite.Equal("user_id,present,late,absent,attendance\nstudent1,2,0,0,100\nstudent2,0,1,1,50\nstudent3,0,0,2,0\n", string(report))
+}
+
+func (testSuite *AttendanceTestSuite) TestAttendanceRoster() {
+	guild := &discordgo.Guild{
+		ID:      "guild",
+		OwnerID: "owner",
+		Roles: []*discordgo.Role{
+			{ID: "guild"},
+			{ID: "instructors", Permissions: discordgo.PermissionAdministrator},
+			{ID: "students", Permissions: discordgo.PermissionSendMessages},
+		},
+	}
+	members := []*discordgo.Member{
+		{User: &discordgo.User{ID: "owner"}},
+		{User: &discordgo.User{ID: "instructor"}, Roles: []string{"instructors"}},
+		{User: &discordgo.User{ID: "student1"}, Roles: []string{"students"}},
+		{User: &discordgo.User{ID: "student2"}},
+		{User: &discordgo.User{ID: "hakase", Bot: true}},
+	}
+
+	testSuite.Equal([]string{"student1", "student2"}, clients.AttendanceRoster(guild, members))
+}
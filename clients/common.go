@@ -2,40 +2,118 @@
 package clients
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/getsentry/sentry-go"
+	"github.com/dragonejt/hakase-discord/lifecycle"
+	"github.com/dragonejt/hakase-discord/metrics"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 type HakaseClient struct {
 	Backend       BackendClient
 	Notifications NotificationsClient
+	Reminders     ReminderProfiles
+	Lifecycle     *lifecycle.Manager
 }
 
 type BackendClient interface {
 	// Course APIs
-	ReadCourse(span *sentry.Span, courseID string) (Course, error)
-	HeadCourse(span *sentry.Span, courseID string) error
-	CreateCourse(span *sentry.Span, course Course) error
-	UpdateCourse(span *sentry.Span, course Course) error
-	DeleteCourse(span *sentry.Span, courseID string) error
+	ReadCourse(ctx context.Context, courseID string) (Course, error)
+	HeadCourse(ctx context.Context, courseID string) error
+	CreateCourse(ctx context.Context, course Course) error
+	UpdateCourse(ctx context.Context, course Course) error
+	DeleteCourse(ctx context.Context, courseID string) error
 	// Assignment APIs
-	ReadAssignment(span *sentry.Span, assignmentID string) (Assignment, error)
-	HeadAssignment(span *sentry.Span, assignmentID string) error
-	ListAssignments(span *sentry.Span, courseID string) ([]Assignment, error)
-	CreateAssignment(span *sentry.Span, assignment Assignment) (Assignment, error)
-	UpdateAssignment(span *sentry.Span, assignment Assignment) (Assignment, error)
-	DeleteAssignment(span *sentry.Span, assignmentID string) error
+	ReadAssignment(ctx context.Context, assignmentID string) (Assignment, error)
+	HeadAssignment(ctx context.Context, assignmentID string) error
+	ListAssignments(ctx context.Context, courseID string) ([]Assignment, error)
+	CreateAssignment(ctx context.Context, assignment Assignment) (Assignment, error)
+	UpdateAssignment(ctx context.Context, assignment Assignment) (Assignment, error)
+	DeleteAssignment(ctx context.Context, assignmentID string) error
+	// Announcement APIs
+	ReadAnnouncement(ctx context.Context, announcementID string) (Announcement, error)
+	CreateAnnouncement(ctx context.Context, announcement Announcement) (Announcement, error)
+	UpdateAnnouncement(ctx context.Context, announcement Announcement) (Announcement, error)
+	AcknowledgeAnnouncement(ctx context.Context, announcementID string, userID string) error
+	// Office Hours APIs
+	ReadOfficeHours(ctx context.Context, officeHoursID string) (OfficeHours, error)
+	ListOfficeHours(ctx context.Context, courseID string) ([]OfficeHours, error)
+	CreateOfficeHours(ctx context.Context, officeHours OfficeHours) (OfficeHours, error)
+	UpdateOfficeHours(ctx context.Context, officeHours OfficeHours) (OfficeHours, error)
+	JoinOfficeHoursQueue(ctx context.Context, officeHoursID string, userID string, topic string) error
+	LeaveOfficeHoursQueue(ctx context.Context, officeHoursID string, userID string) error
+	NextInOfficeHoursQueue(ctx context.Context, officeHoursID string, helperID string) (QueueEntry, error)
+	// Question APIs
+	ReadQuestion(ctx context.Context, questionID string) (Question, error)
+	CreateQuestion(ctx context.Context, question Question, askerID string) (Question, error)
+	UpdateQuestion(ctx context.Context, question Question) (Question, error)
+	ReadQuestionAudit(ctx context.Context, courseID string, questionID string, viewerID string) (QuestionAudit, error)
+	// Q&A APIs
+	ReadQAThread(ctx context.Context, threadID string) (QAThread, error)
+	CreateQAThread(ctx context.Context, thread QAThread) (QAThread, error)
+	ListFAQ(ctx context.Context, courseID string) ([]FAQEntry, error)
+	AcceptAnswer(ctx context.Context, entry FAQEntry) (FAQEntry, error)
+	// Poll APIs
+	ReadPoll(ctx context.Context, pollID string) (Poll, error)
+	CreatePoll(ctx context.Context, poll Poll) (Poll, error)
+	UpdatePoll(ctx context.Context, poll Poll) (Poll, error)
+	VotePoll(ctx context.Context, pollID string, userID string, options []int) error
+	// Attendance APIs
+	ReadAttendanceSession(ctx context.Context, sessionID string) (AttendanceSession, error)
+	ListAttendanceSessions(ctx context.Context, courseID string) ([]AttendanceSession, error)
+	CreateAttendanceSession(ctx context.Context, session AttendanceSession) (AttendanceSession, error)
+	UpdateAttendanceSession(ctx context.Context, session AttendanceSession) (AttendanceSession, error)
+	CheckInAttendance(ctx context.Context, checkIn AttendanceCheckIn) error
+	// Study Group APIs
+	ListStudyGroupRequests(ctx context.Context, courseID string) ([]StudyGroupRequest, error)
+	CreateStudyGroupRequest(ctx context.Context, request StudyGroupRequest) (StudyGroupRequest, error)
+	UpdateStudyGroupRequest(ctx context.Context, request StudyGroupRequest) (StudyGroupRequest, error)
+	DeleteStudyGroupRequest(ctx context.Context, requestID string) error
+	ListStudyGroups(ctx context.Context, courseID string) ([]StudyGroup, error)
+	CreateStudyGroup(ctx context.Context, group StudyGroup) (StudyGroup, error)
+	UpdateStudyGroup(ctx context.Context, group StudyGroup) (StudyGroup, error)
+	// Flashcard APIs
+	ReadFlashcardDeck(ctx context.Context, deckID string) (FlashcardDeck, error)
+	ListFlashcardDecks(ctx context.Context, courseID string, userID string) ([]FlashcardDeck, error)
+	CreateFlashcardDeck(ctx context.Context, deck FlashcardDeck) (FlashcardDeck, error)
+	DeleteFlashcardDeck(ctx context.Context, deckID string) error
+	ListFlashcardReviews(ctx context.Context, courseID string, userID string) ([]FlashcardReview, error)
+	UpdateFlashcardReview(ctx context.Context, review FlashcardReview) (FlashcardReview, error)
+	// Health APIs
+	Ping(ctx context.Context) error
 }
 
 type NotificationsClient interface {
 	ListenToStream(bot *discordgo.Session, hakaseClient BackendClient, stopListener chan bool)
-	PublishNotification(span *sentry.Span, notification string)
-	PublishAssignmentNotification(span *sentry.Span, notification AssignmentNotification)
-	PublishStudySessionNotification(span *sentry.Span, notification StudySessionNotification)
+	PublishNotification(ctx context.Context, notification string) error
+	ScheduleAssignmentNotifications(ctx context.Context, notifications []AssignmentNotification) error
+	ScheduleAnnouncement(ctx context.Context, notification AnnouncementNotification) error
+	SchedulePollClose(ctx context.Context, notification PollCloseNotification) error
+	ScheduleFlashcardReminder(ctx context.Context, notification FlashcardReminderNotification) error
+	ReadFocusTimer(ctx context.Context, courseID string, channelID string) (FocusTimer, bool, error)
+	ListFocusTimers(ctx context.Context, courseIDs []string) ([]FocusTimer, error)
+	SaveFocusTimer(ctx context.Context, timer FocusTimer) (FocusTimer, error)
+	DeleteFocusTimer(ctx context.Context, timer FocusTimer) error
+	RunScheduler(stopScheduler chan bool)
+	RunLeaderElection(stopElection chan bool)
+	IsLeader() bool
+	PublishStudySessionNotification(ctx context.Context, notification StudySessionNotification) error
+	InspectStream(ctx context.Context, limit int) (StreamStatus, error)
+	ConsumerReady() error
+	DrainPublisher(ctx context.Context) error
 }
 
 type APIClient struct {
@@ -47,21 +125,174 @@ type APIClient struct {
 
 type MQClient struct {
 	NotificationsClient
-	NATSUrl       string
-	StreamName    string
-	PublisherPool sync.Pool
+	NATSUrl         string
+	StreamName      string
+	PublishTimeout  time.Duration
+	DuplicateWindow time.Duration
+	ShardID         int
+	ShardCount      int
+	consumerState   atomic.Int32
+	leader          atomic.Bool
+	publisherLock   sync.Mutex
+	publisher       jetstream.JetStream
+	schedule        jetstream.KeyValue
+	focus           jetstream.KeyValue
 }
 
+// DiscordSession is the context key for the Discord session.
 type DiscordSession struct{}
 
+// WithSession returns a copy of ctx carrying the Discord session, for handlers that need the session deeper in the call stack.
+func WithSession(ctx context.Context, bot *discordgo.Session) context.Context {
+	return context.WithValue(ctx, DiscordSession{}, bot)
+}
+
+// Session returns the Discord session carried by ctx, or nil if there is none.
+func Session(ctx context.Context) *discordgo.Session {
+	bot, _ := ctx.Value(DiscordSession{}).(*discordgo.Session)
+	return bot
+}
+
 type AssignmentNotification struct {
 	AssignmentID int
 	CourseID     string
 	Before       time.Duration
+	Due          time.Time
+}
+
+// MessageID returns the deterministic ID of the reminder, from the assignment ID, reminder offset and due date.
+// It is used as the Nats-Msg-Id so that JetStream drops duplicate publishes, and as the key recording delivered reminders.
+func (notification AssignmentNotification) MessageID() string {
+	return fmt.Sprintf("assignment-%d-%d-%d", notification.AssignmentID, int64(notification.Before.Seconds()), notification.Due.Unix())
+}
+
+// AnnouncementNotification is a scheduled announcement, published to the stream when it is due to be posted.
+type AnnouncementNotification struct {
+	AnnouncementID int       `json:"announcement_id"`
+	CourseID       string    `json:"course_id"`
+	ScheduledFor   time.Time `json:"scheduled_for"`
+}
+
+// MessageID returns the deterministic ID of the announcement, from the announcement ID and the time it is scheduled for.
+func (notification AnnouncementNotification) MessageID() string {
+	return fmt.Sprintf("announcement-%d-%d", notification.AnnouncementID, notification.ScheduledFor.Unix())
+}
+
+// PollCloseNotification is a poll's closing time, published to the stream when the poll is due to close.
+type PollCloseNotification struct {
+	PollID   int       `json:"poll_id"`
+	CourseID string    `json:"course_id"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+// MessageID returns the deterministic ID of the poll closing, from the poll ID and the time it closes at.
+func (notification PollCloseNotification) MessageID() string {
+	return fmt.Sprintf("poll-%d-%d", notification.PollID, notification.ClosesAt.Unix())
+}
+
+// FlashcardReminderNotification is a daily reminder for a student to review their flashcards in a course,
+// published to the stream when their first card is due that day.
+type FlashcardReminderNotification struct {
+	CourseID string    `json:"course_id"`
+	UserID   string    `json:"user_id"`
+	RemindAt time.Time `json:"remind_at"`
+}
+
+// flashcardReminderHour is the hour of the day, in UTC, that flashcard reminders are sent at.
+const flashcardReminderHour = 16
+
+// NewFlashcardReminderNotification returns the reminder for a student's card due at dueAt: the first reminder time at or after dueAt.
+// Every card due between two reminder times has the same reminder, so that a student has one reminder scheduled per day
+// however many cards they review.
+func NewFlashcardReminderNotification(courseID string, userID string, dueAt time.Time) FlashcardReminderNotification {
+	dueAt = dueAt.UTC()
+	remindAt := time.Date(dueAt.Year(), dueAt.Month(), dueAt.Day(), flashcardReminderHour, 0, 0, 0, time.UTC)
+	if remindAt.Before(dueAt) {
+		remindAt = remindAt.AddDate(0, 0, 1)
+	}
+	return FlashcardReminderNotification{CourseID: courseID, UserID: userID, RemindAt: remindAt}
+}
+
+// MessageID returns the deterministic ID of the flashcard reminder, from the course, the student and the day it is sent,
+// so that a student is reminded at most once a day however many of their cards are due that day.
+func (notification FlashcardReminderNotification) MessageID() string {
+	return fmt.Sprintf("flashcards-%s-%s-%s", notification.CourseID, notification.UserID, notification.RemindAt.UTC().Format("20060102"))
 }
 
 type StudySessionNotification struct {
-	SessionID int
-	CourseID  string
-	Timestamp time.Time
+	SessionID int       `json:"session_id"`
+	CourseID  string    `json:"course_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ErrCodeNotFound is the code of errors from API requests for something that does not exist, such as a deleted assignment.
+const ErrCodeNotFound stacktrace.ErrorCode = http.StatusNotFound
+
+// IsNotFound reports whether err is from an API request for something that does not exist.
+func IsNotFound(err error) bool {
+	return stacktrace.GetCode(err) == ErrCodeNotFound
+}
+
+// execute sends an API request and checks the response status code, recording failures in metrics.
+// The caller must close the response body.
+func (backend *APIClient) execute(request *http.Request, expectedStatus int) (*http.Response, error) {
+	response, err := backend.HttpClient.Do(request)
+	if err != nil {
+		metrics.BackendErrors.Inc("transport")
+		return nil, stacktrace.Propagate(err, "failed to execute API request")
+	}
+	if response.StatusCode != expectedStatus {
+		_ = response.Body.Close()
+		metrics.BackendErrors.Inc(strconv.Itoa(response.StatusCode))
+		if response.StatusCode == http.StatusNotFound {
+			return nil, stacktrace.NewErrorWithCode(ErrCodeNotFound, "failed status code API response: %d", response.StatusCode)
+		}
+		return nil, stacktrace.NewError("failed status code API response: %d", response.StatusCode)
+	}
+	return response, nil
+}
+
+// jsonRequest sends an API request to path with body, if it is not nil,
+// and unmarshals the response into result, if it is not nil.
+func (backend *APIClient) jsonRequest(ctx context.Context, method string, path string, body any, expectedStatus int, result any) error {
+	var requestBody io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return stacktrace.Propagate(err, "failed to marshal API request")
+		}
+		requestBody = bytes.NewReader(jsonBody)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/%s", backend.Url, path), requestBody)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to create API request")
+	}
+	request.Header.Add("accept", "application/json")
+	if body != nil {
+		request.Header.Add("content-type", "application/json")
+	}
+	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
+	tracing.Inject(ctx, propagation.HeaderCarrier(request.Header))
+
+	response, err := backend.execute(request, expectedStatus)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed API request")
+	}
+	defer response.Body.Close()
+	if result == nil {
+		return nil
+	}
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed reading API response body: %d", response.StatusCode)
+	}
+
+	err = json.Unmarshal(responseBody, result)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to unmarshal API response: %s", string(responseBody))
+	}
+
+	return nil
 }
@@ -3,46 +3,68 @@ package clients
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 
-	"github.com/getsentry/sentry-go"
+	"github.com/dragonejt/hakase-discord/tracing"
 	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 type Course struct {
-	ID            int    `json:"id,omitempty"`
-	Platform      int    `json:"platform,omitempty"`
-	CourseID      string `json:"course_id"`
-	NotifyChannel string `json:"notify_channel,omitempty"`
-	NotifyGroup   string `json:"notify_group,omitempty"`
+	ID                  int    `json:"id,omitempty"`
+	Platform            int    `json:"platform,omitempty"`
+	CourseID            string `json:"course_id"`
+	NotifyChannel       string `json:"notify_channel,omitempty"`
+	NotifyGroup         string `json:"notify_group,omitempty"`
+	CountdownChannel    string `json:"countdown_channel,omitempty"`
+	CountdownPinChannel string `json:"countdown_pin_channel,omitempty"`
+	CountdownPinMessage string `json:"countdown_pin_message,omitempty"`
+	// Locale is the course's default locale, used for reminders and for users whose Discord locale is not supported.
+	Locale string `json:"locale,omitempty"`
+	// QuestionChannel is where anonymous questions from /ask are posted, each with a thread for answers.
+	QuestionChannel string `json:"question_channel,omitempty"`
+	// QuestionReviewChannel is where staff approve questions before they are posted, and questions are posted without review if it is empty.
+	// It is a pointer so that updates leave it unchanged when it is nil, and turn review off when it is empty.
+	QuestionReviewChannel *string `json:"question_review_channel,omitempty"`
+	// StudyGroupChannel is the category study groups get channels in, or the channel they get threads in, depending on StudyGroupMode.
+	// Students are only matched into study groups once it is set.
+	StudyGroupChannel string `json:"study_group_channel,omitempty"`
+	StudyGroupMode    string `json:"study_group_mode,omitempty"`
+	StudyGroupSize    int    `json:"study_group_size,omitempty"`
+}
+
+// ReviewChannel returns the channel where staff review questions before they are posted, or an empty string if questions are not reviewed.
+func (course Course) ReviewChannel() string {
+	if course.QuestionReviewChannel == nil {
+		return ""
+	}
+	return *course.QuestionReviewChannel
 }
 
 // ReadCourse retrieves a course by its ID from the backend.
-func (backend *APIClient) ReadCourse(span *sentry.Span, courseID string) (Course, error) {
-	span = span.StartChild("readCourse")
-	defer span.Finish()
+func (backend *APIClient) ReadCourse(ctx context.Context, courseID string) (Course, error) {
+	ctx, span := tracing.Start(ctx, "readCourse")
+	defer span.End()
 
 	course := Course{}
 
-	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/courses?course_id=%s", backend.Url, courseID), nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/courses?course_id=%s", backend.Url, courseID), nil)
 	if err != nil {
 		return course, stacktrace.Propagate(err, "failed to create API request")
 	}
 	request.Header.Add("accept", "application/json")
 	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
-	request.Header.Add(sentry.SentryTraceHeader, sentry.CurrentHub().GetTraceparent())
-	request.Header.Add(sentry.SentryBaggageHeader, sentry.CurrentHub().GetBaggage())
+	tracing.Inject(ctx, propagation.HeaderCarrier(request.Header))
 
-	response, err := backend.HttpClient.Do(request)
+	response, err := backend.execute(request, http.StatusOK)
 	if err != nil {
-		return course, stacktrace.Propagate(err, "failed to execute API request")
-	}
-	if response.StatusCode != http.StatusOK {
-		return course, stacktrace.Propagate(err, "failed status code API response: %d", response.StatusCode)
+		return course, stacktrace.Propagate(err, "failed API request")
 	}
+	defer response.Body.Close()
 
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
@@ -58,57 +80,51 @@ func (backend *APIClient) ReadCourse(span *sentry.Span, courseID string) (Course
 }
 
 // HeadCourse checks if a course exists in the backend.
-func (backend *APIClient) HeadCourse(span *sentry.Span, courseID string) error {
-	span = span.StartChild("headCourse")
-	defer span.Finish()
+func (backend *APIClient) HeadCourse(ctx context.Context, courseID string) error {
+	ctx, span := tracing.Start(ctx, "headCourse")
+	defer span.End()
 
-	request, err := http.NewRequest(http.MethodHead, fmt.Sprintf("%s/courses?course_id=%s", backend.Url, courseID), nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, fmt.Sprintf("%s/courses?course_id=%s", backend.Url, courseID), nil)
 	if err != nil {
 		return stacktrace.Propagate(err, "failed to create API request")
 	}
 	request.Header.Add("accept", "application/json")
 	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
-	request.Header.Add(sentry.SentryTraceHeader, sentry.CurrentHub().GetTraceparent())
-	request.Header.Add(sentry.SentryBaggageHeader, sentry.CurrentHub().GetBaggage())
+	tracing.Inject(ctx, propagation.HeaderCarrier(request.Header))
 
-	response, err := backend.HttpClient.Do(request)
+	response, err := backend.execute(request, http.StatusOK)
 	if err != nil {
-		return stacktrace.Propagate(err, "failed to execute API request")
-	}
-	if response.StatusCode != http.StatusOK {
-		return stacktrace.Propagate(err, "failed status code API response: %d", response.StatusCode)
+		return stacktrace.Propagate(err, "failed API request")
 	}
+	defer response.Body.Close()
 
 	return nil
 }
 
 // CreateCourse creates a new course in the backend.
-func (backend *APIClient) CreateCourse(span *sentry.Span, course Course) error {
-	span = span.StartChild("createCourse")
-	defer span.Finish()
+func (backend *APIClient) CreateCourse(ctx context.Context, course Course) error {
+	ctx, span := tracing.Start(ctx, "createCourse")
+	defer span.End()
 
 	jsonBody, err := json.Marshal(course)
 	if err != nil {
 		return stacktrace.Propagate(err, "failed to marshal course")
 	}
 
-	request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/courses", backend.Url), bytes.NewReader(jsonBody))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/courses", backend.Url), bytes.NewReader(jsonBody))
 	if err != nil {
 		return stacktrace.Propagate(err, "failed to create API request")
 	}
 	request.Header.Add("accept", "application/json")
 	request.Header.Add("content-type", "application/json")
 	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
-	request.Header.Add(sentry.SentryTraceHeader, sentry.CurrentHub().GetTraceparent())
-	request.Header.Add(sentry.SentryBaggageHeader, sentry.CurrentHub().GetBaggage())
+	tracing.Inject(ctx, propagation.HeaderCarrier(request.Header))
 
-	response, err := backend.HttpClient.Do(request)
+	response, err := backend.execute(request, http.StatusCreated)
 	if err != nil {
-		return stacktrace.Propagate(err, "failed to execute API request")
-	}
-	if response.StatusCode != http.StatusCreated {
-		return stacktrace.Propagate(err, "failed status code API response: %d", response.StatusCode)
+		return stacktrace.Propagate(err, "failed API request")
 	}
+	defer response.Body.Close()
 
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
@@ -124,32 +140,29 @@ func (backend *APIClient) CreateCourse(span *sentry.Span, course Course) error {
 }
 
 // UpdateCourse updates an existing course in the backend.
-func (backend *APIClient) UpdateCourse(span *sentry.Span, course Course) error {
-	span = span.StartChild("updateCourse")
-	defer span.Finish()
+func (backend *APIClient) UpdateCourse(ctx context.Context, course Course) error {
+	ctx, span := tracing.Start(ctx, "updateCourse")
+	defer span.End()
 
 	jsonBody, err := json.Marshal(course)
 	if err != nil {
 		return stacktrace.Propagate(err, "failed to marshal course")
 	}
 
-	request, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/courses", backend.Url), bytes.NewReader(jsonBody))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/courses", backend.Url), bytes.NewReader(jsonBody))
 	if err != nil {
 		return stacktrace.Propagate(err, "failed to create API request")
 	}
 	request.Header.Add("accept", "application/json")
 	request.Header.Add("content-type", "application/json")
 	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
-	request.Header.Add(sentry.SentryTraceHeader, sentry.CurrentHub().GetTraceparent())
-	request.Header.Add(sentry.SentryBaggageHeader, sentry.CurrentHub().GetBaggage())
+	tracing.Inject(ctx, propagation.HeaderCarrier(request.Header))
 
-	response, err := backend.HttpClient.Do(request)
+	response, err := backend.execute(request, http.StatusAccepted)
 	if err != nil {
-		return stacktrace.Propagate(err, "failed to execute API request")
-	}
-	if response.StatusCode != http.StatusAccepted {
-		return stacktrace.Propagate(err, "failed status code API response: %d", response.StatusCode)
+		return stacktrace.Propagate(err, "failed API request")
 	}
+	defer response.Body.Close()
 
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
@@ -165,25 +178,22 @@ func (backend *APIClient) UpdateCourse(span *sentry.Span, course Course) error {
 }
 
 // DeleteCourse deletes a course from the backend.
-func (backend *APIClient) DeleteCourse(span *sentry.Span, courseID string) error {
-	span = span.StartChild("deleteCourse")
-	defer span.Finish()
+func (backend *APIClient) DeleteCourse(ctx context.Context, courseID string) error {
+	ctx, span := tracing.Start(ctx, "deleteCourse")
+	defer span.End()
 
-	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/courses?course_id=%s", backend.Url, courseID), nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/courses?course_id=%s", backend.Url, courseID), nil)
 	if err != nil {
 		return stacktrace.Propagate(err, "failed to create API request")
 	}
 	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
-	request.Header.Add(sentry.SentryTraceHeader, sentry.CurrentHub().GetTraceparent())
-	request.Header.Add(sentry.SentryBaggageHeader, sentry.CurrentHub().GetBaggage())
+	tracing.Inject(ctx, propagation.HeaderCarrier(request.Header))
 
-	response, err := backend.HttpClient.Do(request)
+	response, err := backend.execute(request, http.StatusNoContent)
 	if err != nil {
-		return stacktrace.Propagate(err, "failed to execute API request")
-	}
-	if response.StatusCode != http.StatusNoContent {
-		return stacktrace.Propagate(err, "failed status code API response: %d", response.StatusCode)
+		return stacktrace.Propagate(err, "failed API request")
 	}
+	defer response.Body.Close()
 
 	return nil
 }
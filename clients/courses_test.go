@@ -0,0 +1,35 @@
+package clients_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/stretchr/testify/suite"
+)
+
+type CourseTestSuite struct {
+	suite.Suite
+}
+
+func TestCourse(t *testing.T) {
+	suite.Run(t, new(CourseTestSuite))
+}
+
+func (testSuite *CourseTestSuite) TestReviewChannel() {
+	testSuite.Equal("", clients.Course{}.ReviewChannel())
+
+	reviewChannel := "0987654321"
+	testSuite.Equal(reviewChannel, clients.Course{QuestionReviewChannel: &reviewChannel}.ReviewChannel())
+}
+
+func (testSuite *CourseTestSuite) TestReviewChannelUpdate() {
+	unchanged, err := json.Marshal(clients.Course{CourseID: "1234567890"})
+	testSuite.Require().NoError(err)
+	testSuite.NotContains(string(unchanged), "question_review_channel")
+
+	noReview := ""
+	turnedOff, err := json.Marshal(clients.Course{CourseID: "1234567890", QuestionReviewChannel: &noReview})
+	testSuite.Require().NoError(err)
+	testSuite.Contains(string(turnedOff), `"question_review_channel":""`)
+}
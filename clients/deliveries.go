@@ -0,0 +1,59 @@
+// Package clients provides the record of delivered reminders, which makes reminder delivery idempotent.
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/palantir/stacktrace"
+)
+
+// deliveryLogTTL is how long delivered reminders are remembered, longer than a delivered message stays unacknowledged.
+const deliveryLogTTL = 7 * 24 * time.Hour
+
+// deliveryLog records delivered reminders by message ID in a JetStream KV bucket,
+// so that a reminder redelivered after a crash or failed ACK is not posted twice.
+type deliveryLog struct {
+	kv jetstream.KeyValue
+}
+
+// createDeliveryLog creates or updates the KV bucket recording delivered reminders for the stream.
+func (mqClient *MQClient) createDeliveryLog(ctx context.Context, js jetstream.JetStream) (*deliveryLog, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	bucket := fmt.Sprintf("%s_delivered", mqClient.StreamName)
+	kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      bucket,
+		Description: "reminders delivered to Discord, by message ID",
+		TTL:         deliveryLogTTL,
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error creating key value bucket: %s", bucket)
+	}
+	return &deliveryLog{kv: kv}, nil
+}
+
+// Delivered reports whether the reminder with the message ID was already delivered.
+func (deliveries *deliveryLog) Delivered(ctx context.Context, messageID string) (bool, error) {
+	_, err := deliveries.kv.Get(ctx, messageID)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, stacktrace.Propagate(err, "error reading delivery of message: %s", messageID)
+	}
+	return true, nil
+}
+
+// Record records that the reminder with the message ID was delivered.
+func (deliveries *deliveryLog) Record(ctx context.Context, messageID string) error {
+	_, err := deliveries.kv.Put(ctx, messageID, []byte(time.Now().UTC().Format(time.RFC3339)))
+	if err != nil {
+		return stacktrace.Propagate(err, "error recording delivery of message: %s", messageID)
+	}
+	return nil
+}
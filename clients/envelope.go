@@ -0,0 +1,356 @@
+// Package clients provides the versioned envelope and payload encoding of NATS messages.
+package clients
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// LegacyMessageVersion is the version of messages published as bare payloads, before messages had an envelope.
+	LegacyMessageVersion = 1
+	// MessageVersion is the version of the envelope and payloads published by hakase, documented in docs/messages.md.
+	MessageVersion = 2
+)
+
+// The message types, one for each kind of subject.
+const (
+	NotificationMessage       = "notification"
+	AssignmentReminderMessage = "assignment_reminder"
+	StudySessionMessage       = "study_session"
+	AnnouncementMessage       = "announcement"
+	PollCloseMessage          = "poll_close"
+	FlashcardReminderMessage  = "flashcard_reminder"
+)
+
+// messageTypes maps subject kinds to the type of the messages published to them.
+var messageTypes = map[string]string{
+	"notifications":  NotificationMessage,
+	"assignments":    AssignmentReminderMessage,
+	"study_sessions": StudySessionMessage,
+	"announcements":  AnnouncementMessage,
+	"polls":          PollCloseMessage,
+	"flashcards":     FlashcardReminderMessage,
+}
+
+// messageTypeKind returns the subject kind that messages of messageType are published to.
+func messageTypeKind(messageType string) (string, bool) {
+	for kind, kindType := range messageTypes {
+		if kindType == messageType {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// Envelope wraps the payload of every NATS message with its type, schema version, ID and creation time.
+type Envelope struct {
+	Type      string          `json:"type"`
+	Version   int             `json:"version"`
+	ID        string          `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// payload is the payload of a message, which validates itself.
+type payload interface {
+	Validate() error
+}
+
+// NewEnvelope returns an envelope of the current version wrapping payload.
+func NewEnvelope(messageType string, id string, createdAt time.Time, payload any) (Envelope, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, stacktrace.Propagate(err, "error marshalling %s payload", messageType)
+	}
+	return Envelope{
+		Type:      messageType,
+		Version:   MessageVersion,
+		ID:        id,
+		CreatedAt: createdAt.UTC(),
+		Payload:   data,
+	}, nil
+}
+
+// DecodeEnvelope decodes and validates a message published to a subject of kind, such as assignments.
+// Legacy messages, which are bare payloads, are wrapped in an envelope of LegacyMessageVersion with the type of kind.
+func DecodeEnvelope(kind string, data []byte) (Envelope, error) {
+	messageType, exists := messageTypes[kind]
+	if !exists {
+		return Envelope{}, stacktrace.NewError("unknown message kind: %s", kind)
+	}
+
+	envelope := Envelope{}
+	fields := map[string]json.RawMessage{}
+	if json.Unmarshal(data, &fields) == nil && fields["type"] != nil && fields["payload"] != nil {
+		err := json.Unmarshal(data, &envelope)
+		if err != nil {
+			return envelope, stacktrace.Propagate(err, "error unmarshalling message envelope")
+		}
+		if envelope.Version != MessageVersion {
+			return envelope, stacktrace.NewError("unsupported message version: %d", envelope.Version)
+		}
+		if envelope.Type != messageType {
+			return envelope, stacktrace.NewError("message type %s does not match subject kind: %s", envelope.Type, kind)
+		}
+	} else {
+		envelope = Envelope{Type: messageType, Version: LegacyMessageVersion, Payload: data}
+		if messageType == NotificationMessage {
+			// legacy notifications are plain text
+			notification, err := json.Marshal(Notification{Message: string(data)})
+			if err != nil {
+				return envelope, stacktrace.Propagate(err, "error marshalling legacy notification")
+			}
+			envelope.Payload = notification
+		}
+	}
+
+	return envelope, envelope.Validate()
+}
+
+// Validate returns an error listing every invalid field of the envelope and its payload.
+func (envelope Envelope) Validate() error {
+	errs := []error{}
+
+	if envelope.Version != MessageVersion && envelope.Version != LegacyMessageVersion {
+		errs = append(errs, fmt.Errorf("unsupported message version: %d", envelope.Version))
+	}
+	if envelope.Version != LegacyMessageVersion {
+		if envelope.ID == "" {
+			errs = append(errs, errors.New("message id is not set"))
+		}
+		if envelope.CreatedAt.IsZero() {
+			errs = append(errs, errors.New("message created_at is not set"))
+		}
+	}
+
+	var decoded payload
+	switch envelope.Type {
+	case NotificationMessage:
+		decoded = &Notification{}
+	case AssignmentReminderMessage:
+		decoded = &AssignmentNotification{}
+	case StudySessionMessage:
+		decoded = &StudySessionNotification{}
+	case AnnouncementMessage:
+		decoded = &AnnouncementNotification{}
+	case PollCloseMessage:
+		decoded = &PollCloseNotification{}
+	case FlashcardReminderMessage:
+		decoded = &FlashcardReminderNotification{}
+	default:
+		errs = append(errs, fmt.Errorf("unknown message type: %s", envelope.Type))
+	}
+	if decoded != nil {
+		err := json.Unmarshal(envelope.Payload, decoded)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s payload: %w", envelope.Type, err))
+		} else if err := decoded.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Decode unmarshals the payload of the envelope into payload.
+func (envelope Envelope) Decode(payload any) error {
+	err := json.Unmarshal(envelope.Payload, payload)
+	if err != nil {
+		return stacktrace.Propagate(err, "error unmarshalling %s payload", envelope.Type)
+	}
+	return nil
+}
+
+// Notification is the payload of a plain text notification.
+type Notification struct {
+	Message string `json:"message"`
+}
+
+// Validate returns an error if the notification is empty.
+func (notification Notification) Validate() error {
+	if notification.Message == "" {
+		return errors.New("notification message is not set")
+	}
+	return nil
+}
+
+// assignmentNotificationJSON is the JSON encoding of an AssignmentNotification, with the reminder offset in seconds.
+type assignmentNotificationJSON struct {
+	AssignmentID  int        `json:"assignment_id"`
+	CourseID      string     `json:"course_id"`
+	BeforeSeconds int64      `json:"before_seconds"`
+	Due           *time.Time `json:"due,omitempty"`
+}
+
+// legacyAssignmentNotification is the legacy JSON encoding of an AssignmentNotification, with Go field names and the reminder offset in nanoseconds.
+type legacyAssignmentNotification struct {
+	AssignmentID int
+	CourseID     string
+	Before       time.Duration
+	Due          time.Time
+}
+
+// MarshalJSON encodes the notification with its reminder offset in seconds, omitting the due date if it is unknown.
+func (notification AssignmentNotification) MarshalJSON() ([]byte, error) {
+	encoded := assignmentNotificationJSON{
+		AssignmentID:  notification.AssignmentID,
+		CourseID:      notification.CourseID,
+		BeforeSeconds: int64(notification.Before.Seconds()),
+	}
+	if !notification.Due.IsZero() {
+		encoded.Due = &notification.Due
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes the notification from its JSON encoding or its legacy encoding.
+func (notification *AssignmentNotification) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+
+	if _, legacy := fields["AssignmentID"]; legacy {
+		decoded := legacyAssignmentNotification{}
+		err = json.Unmarshal(data, &decoded)
+		*notification = AssignmentNotification(decoded)
+		return err
+	}
+
+	decoded := assignmentNotificationJSON{}
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+	*notification = AssignmentNotification{
+		AssignmentID: decoded.AssignmentID,
+		CourseID:     decoded.CourseID,
+		Before:       time.Duration(decoded.BeforeSeconds) * time.Second,
+	}
+	if decoded.Due != nil {
+		notification.Due = *decoded.Due
+	}
+	return nil
+}
+
+// Validate returns an error listing every invalid field of the notification.
+// The due date is optional, since reminders published before it was added do not have it.
+func (notification AssignmentNotification) Validate() error {
+	errs := []error{}
+	if notification.AssignmentID <= 0 {
+		errs = append(errs, fmt.Errorf("assignment_id must be positive: %d", notification.AssignmentID))
+	}
+	if notification.CourseID == "" {
+		errs = append(errs, errors.New("course_id is not set"))
+	}
+	if notification.Before <= 0 {
+		errs = append(errs, fmt.Errorf("before_seconds must be positive: %d", int64(notification.Before.Seconds())))
+	}
+	return errors.Join(errs...)
+}
+
+// legacyStudySessionNotification is the legacy JSON encoding of a StudySessionNotification, with Go field names.
+type legacyStudySessionNotification struct {
+	SessionID int
+	CourseID  string
+	Timestamp time.Time
+}
+
+// UnmarshalJSON decodes the notification from its JSON encoding or its legacy encoding.
+func (notification *StudySessionNotification) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+
+	if _, legacy := fields["SessionID"]; legacy {
+		decoded := legacyStudySessionNotification{}
+		err = json.Unmarshal(data, &decoded)
+		*notification = StudySessionNotification(decoded)
+		return err
+	}
+
+	// the alias has the same fields and tags without this method, so decoding it does not recurse
+	type studySessionNotification StudySessionNotification
+	return json.Unmarshal(data, (*studySessionNotification)(notification))
+}
+
+// Validate returns an error listing every invalid field of the notification.
+func (notification StudySessionNotification) Validate() error {
+	errs := []error{}
+	if notification.SessionID <= 0 {
+		errs = append(errs, fmt.Errorf("session_id must be positive: %d", notification.SessionID))
+	}
+	if notification.CourseID == "" {
+		errs = append(errs, errors.New("course_id is not set"))
+	}
+	if notification.Timestamp.IsZero() {
+		errs = append(errs, errors.New("timestamp is not set"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate returns an error listing every invalid field of the notification.
+func (notification AnnouncementNotification) Validate() error {
+	errs := []error{}
+	if notification.AnnouncementID <= 0 {
+		errs = append(errs, fmt.Errorf("announcement_id must be positive: %d", notification.AnnouncementID))
+	}
+	if notification.CourseID == "" {
+		errs = append(errs, errors.New("course_id is not set"))
+	}
+	if notification.ScheduledFor.IsZero() {
+		errs = append(errs, errors.New("scheduled_for is not set"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate returns an error listing every invalid field of the notification.
+func (notification PollCloseNotification) Validate() error {
+	errs := []error{}
+	if notification.PollID <= 0 {
+		errs = append(errs, fmt.Errorf("poll_id must be positive: %d", notification.PollID))
+	}
+	if notification.CourseID == "" {
+		errs = append(errs, errors.New("course_id is not set"))
+	}
+	if notification.ClosesAt.IsZero() {
+		errs = append(errs, errors.New("closes_at is not set"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate returns an error listing every invalid field of the notification.
+func (notification FlashcardReminderNotification) Validate() error {
+	errs := []error{}
+	if notification.CourseID == "" {
+		errs = append(errs, errors.New("course_id is not set"))
+	}
+	if notification.UserID == "" {
+		errs = append(errs, errors.New("user_id is not set"))
+	}
+	if notification.RemindAt.IsZero() {
+		errs = append(errs, errors.New("remind_at is not set"))
+	}
+	return errors.Join(errs...)
+}
+
+// newOutgoingMessage returns a message wrapping payload in an envelope of messageType, to publish to subject with id.
+func newOutgoingMessage(subject string, messageType string, id string, payload any) (outgoingMessage, error) {
+	envelope, err := NewEnvelope(messageType, id, time.Now(), payload)
+	if err != nil {
+		return outgoingMessage{}, stacktrace.Propagate(err, "error creating %s envelope", messageType)
+	}
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return outgoingMessage{}, stacktrace.Propagate(err, "error marshalling %s envelope", messageType)
+	}
+	return outgoingMessage{subject: subject, id: id, data: data}, nil
+}
@@ -0,0 +1,167 @@
+package clients_test
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/stretchr/testify/suite"
+)
+
+// update rewrites the golden files with the current encoding, with go test ./clients -update.
+var update = flag.Bool("update", false, "update golden files")
+
+type EnvelopeTestSuite struct {
+	suite.Suite
+	createdAt time.Time
+	due       time.Time
+}
+
+func TestEnvelope(t *testing.T) {
+	suite.Run(t, new(EnvelopeTestSuite))
+}
+
+func (testSuite *EnvelopeTestSuite) SetupTest() {
+	testSuite.createdAt = time.Date(2025, 1, 30, 23, 59, 0, 0, time.UTC)
+	testSuite.due = time.Date(2025, 1, 31, 23, 59, 0, 0, time.UTC)
+}
+
+// golden compares data to the golden file at path, or rewrites the file if -update is set.
+func (testSuite *EnvelopeTestSuite) golden(path string, data []byte) {
+	path = filepath.Join("testdata", "messages", path)
+	if *update {
+		testSuite.Require().NoError(os.WriteFile(path, data, 0o644))
+	}
+	expected, err := os.ReadFile(path)
+	testSuite.Require().NoError(err)
+	testSuite.JSONEq(string(expected), string(data))
+}
+
+// read returns the contents of the test message at path.
+func (testSuite *EnvelopeTestSuite) read(path string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", "messages", path))
+	testSuite.Require().NoError(err)
+	return data
+}
+
+func (testSuite *EnvelopeTestSuite) TestEncodeGolden() {
+	payloads := []struct {
+		file        string
+		messageType string
+		id          string
+		payload     any
+	}{
+		{"assignment_reminder.json", clients.AssignmentReminderMessage, "assignment-1-86400-1738367940", clients.AssignmentNotification{AssignmentID: 1, CourseID: "123456789012345678", Before: 24 * time.Hour, Due: testSuite.due}},
+		{"study_session.json", clients.StudySessionMessage, "study-session-1", clients.StudySessionNotification{SessionID: 1, CourseID: "123456789012345678", Timestamp: testSuite.due}},
+		{"notification.json", clients.NotificationMessage, "notification-1", clients.Notification{Message: "logged in as hakase#0000"}},
+		{"announcement.json", clients.AnnouncementMessage, "announcement-1-1738367940", clients.AnnouncementNotification{AnnouncementID: 1, CourseID: "123456789012345678", ScheduledFor: testSuite.due}},
+		{"poll_close.json", clients.PollCloseMessage, "poll-1-1738367940", clients.PollCloseNotification{PollID: 1, CourseID: "123456789012345678", ClosesAt: testSuite.due}},
+		{"flashcard_reminder.json", clients.FlashcardReminderMessage, "flashcards-123456789012345678-234567890123456789-20250131", clients.FlashcardReminderNotification{CourseID: "123456789012345678", UserID: "234567890123456789", RemindAt: testSuite.due}},
+	}
+
+	for _, test := range payloads {
+		envelope, err := clients.NewEnvelope(test.messageType, test.id, testSuite.createdAt, test.payload)
+		testSuite.Require().NoError(err)
+		data, err := json.MarshalIndent(envelope, "", "  ")
+		testSuite.Require().NoError(err)
+		testSuite.golden(test.file, append(data, '\n'))
+
+		decoded, err := clients.DecodeEnvelope(map[string]string{
+			clients.AssignmentReminderMessage: "assignments",
+			clients.StudySessionMessage:       "study_sessions",
+			clients.NotificationMessage:       "notifications",
+			clients.AnnouncementMessage:       "announcements",
+			clients.PollCloseMessage:          "polls",
+			clients.FlashcardReminderMessage:  "flashcards",
+		}[test.messageType], data)
+		testSuite.Require().NoError(err, test.file)
+		testSuite.Equal(clients.MessageVersion, decoded.Version)
+		testSuite.Equal(test.id, decoded.ID)
+		testSuite.True(testSuite.createdAt.Equal(decoded.CreatedAt))
+	}
+}
+
+func (testSuite *EnvelopeTestSuite) TestDecodeAssignmentReminder() {
+	for _, file := range []string{"assignment_reminder.json", "legacy/assignment_reminder.json"} {
+		envelope, err := clients.DecodeEnvelope("assignments", testSuite.read(file))
+		testSuite.Require().NoError(err, file)
+
+		notification := clients.AssignmentNotification{}
+		testSuite.Require().NoError(envelope.Decode(&notification))
+		testSuite.Equal(1, notification.AssignmentID)
+		testSuite.Equal("123456789012345678", notification.CourseID)
+		testSuite.Equal(24*time.Hour, notification.Before)
+		testSuite.True(testSuite.due.Equal(notification.Due))
+		testSuite.Equal("assignment-1-86400-1738367940", notification.MessageID())
+	}
+}
+
+func (testSuite *EnvelopeTestSuite) TestDecodeLegacy() {
+	envelope, err := clients.DecodeEnvelope("study_sessions", testSuite.read("legacy/study_session.json"))
+	testSuite.Require().NoError(err)
+	testSuite.Equal(clients.LegacyMessageVersion, envelope.Version)
+	session := clients.StudySessionNotification{}
+	testSuite.Require().NoError(envelope.Decode(&session))
+	testSuite.Equal(clients.StudySessionNotification{SessionID: 1, CourseID: "123456789012345678", Timestamp: testSuite.due}, session)
+
+	envelope, err = clients.DecodeEnvelope("notifications", testSuite.read("legacy/notification.txt"))
+	testSuite.Require().NoError(err)
+	notification := clients.Notification{}
+	testSuite.Require().NoError(envelope.Decode(&notification))
+	testSuite.Equal("logged in as hakase#0000", notification.Message)
+}
+
+func (testSuite *EnvelopeTestSuite) TestDecodeInvalid() {
+	invalid := []struct {
+		file string
+		kind string
+	}{
+		{"invalid/missing_fields.json", "assignments"},
+		{"invalid/unsupported_version.json", "assignments"},
+		{"invalid/wrong_type.json", "assignments"},
+		{"invalid/legacy_missing_fields.json", "assignments"},
+		{"invalid/announcement_missing_fields.json", "announcements"},
+		{"invalid/poll_close_missing_fields.json", "polls"},
+		{"invalid/flashcard_reminder_missing_fields.json", "flashcards"},
+		{"assignment_reminder.json", "unknown"},
+	}
+
+	for _, test := range invalid {
+		_, err := clients.DecodeEnvelope(test.kind, testSuite.read(test.file))
+		testSuite.Error(err, test.file)
+	}
+}
+
+func (testSuite *EnvelopeTestSuite) TestDecodeAnnouncement() {
+	envelope, err := clients.DecodeEnvelope("announcements", testSuite.read("announcement.json"))
+	testSuite.Require().NoError(err)
+
+	notification := clients.AnnouncementNotification{}
+	testSuite.Require().NoError(envelope.Decode(&notification))
+	testSuite.Equal(clients.AnnouncementNotification{AnnouncementID: 1, CourseID: "123456789012345678", ScheduledFor: testSuite.due}, notification)
+	testSuite.Equal(envelope.ID, notification.MessageID())
+}
+
+func (testSuite *EnvelopeTestSuite) TestDecodePollClose() {
+	envelope, err := clients.DecodeEnvelope("polls", testSuite.read("poll_close.json"))
+	testSuite.Require().NoError(err)
+
+	notification := clients.PollCloseNotification{}
+	testSuite.Require().NoError(envelope.Decode(&notification))
+	testSuite.Equal(clients.PollCloseNotification{PollID: 1, CourseID: "123456789012345678", ClosesAt: testSuite.due}, notification)
+	testSuite.Equal(envelope.ID, notification.MessageID())
+}
+
+func (testSuite *EnvelopeTestSuite) TestDecodeFlashcardReminder() {
+	envelope, err := clients.DecodeEnvelope("flashcards", testSuite.read("flashcard_reminder.json"))
+	testSuite.Require().NoError(err)
+
+	notification := clients.FlashcardReminderNotification{}
+	testSuite.Require().NoError(envelope.Decode(&notification))
+	testSuite.Equal(clients.FlashcardReminderNotification{CourseID: "123456789012345678", UserID: "234567890123456789", RemindAt: testSuite.due}, notification)
+	testSuite.Equal(envelope.ID, notification.MessageID())
+}
@@ -0,0 +1,76 @@
+// Package clients implements backend API operations for Q&A threads, and the FAQ of their accepted answers.
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/tracing"
+)
+
+// QAThread is a question asked with /question, answered in its own Discord thread.
+type QAThread struct {
+	ID       int    `json:"id,omitempty"`
+	CourseID string `json:"course_id"`
+	AskerID  string `json:"asker_id"`
+	Title    string `json:"title"`
+	Question string `json:"question,omitempty"`
+	// ChannelID is the channel the thread was started in, and ThreadID is the Discord thread itself.
+	ChannelID string    `json:"channel_id"`
+	ThreadID  string    `json:"thread_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FAQEntry is the accepted answer to a Q&A thread, searchable with /faq. A thread has at most one accepted answer.
+type FAQEntry struct {
+	ID       int    `json:"id,omitempty"`
+	CourseID string `json:"course_id"`
+	ThreadID string `json:"thread_id"`
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+	// AnswerAuthorID is who wrote the answer, and AnswerURL links to the answer in its thread.
+	AnswerAuthorID string    `json:"answer_author_id"`
+	AnswerURL      string    `json:"answer_url"`
+	AcceptedBy     string    `json:"accepted_by"`
+	AcceptedAt     time.Time `json:"accepted_at"`
+}
+
+// ReadQAThread retrieves a Q&A thread by its Discord thread ID from the backend.
+func (backend *APIClient) ReadQAThread(ctx context.Context, threadID string) (QAThread, error) {
+	ctx, span := tracing.Start(ctx, "readQAThread")
+	defer span.End()
+
+	thread := QAThread{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("qa/threads?thread_id=%s", threadID), nil, http.StatusOK, &thread)
+	return thread, err
+}
+
+// CreateQAThread creates a new Q&A thread in the backend.
+func (backend *APIClient) CreateQAThread(ctx context.Context, thread QAThread) (QAThread, error) {
+	ctx, span := tracing.Start(ctx, "createQAThread")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPost, "qa/threads", thread, http.StatusCreated, &thread)
+	return thread, err
+}
+
+// ListFAQ lists the accepted answers of every Q&A thread in a course.
+func (backend *APIClient) ListFAQ(ctx context.Context, courseID string) ([]FAQEntry, error) {
+	ctx, span := tracing.Start(ctx, "listFAQ")
+	defer span.End()
+
+	entries := []FAQEntry{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("qa/faq?course_id=%s", courseID), nil, http.StatusOK, &entries)
+	return entries, err
+}
+
+// AcceptAnswer stores the accepted answer to a Q&A thread in the backend, replacing any answer accepted before.
+func (backend *APIClient) AcceptAnswer(ctx context.Context, entry FAQEntry) (FAQEntry, error) {
+	ctx, span := tracing.Start(ctx, "acceptAnswer")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPut, "qa/faq", entry, http.StatusAccepted, &entry)
+	return entry, err
+}
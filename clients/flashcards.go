@@ -0,0 +1,341 @@
+// Package clients implements backend API operations for flashcard decks, and their spaced repetition schedule.
+package clients
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+)
+
+const (
+	// MaxFlashcards is the most cards in a deck.
+	MaxFlashcards = 500
+	// maxFlashcardLength is the longest side of a card in characters, so that both sides fit in an embed.
+	maxFlashcardLength = 1000
+	// maxFlashcardErrors is the most errors listed when parsing cards, so that they fit in a message.
+	maxFlashcardErrors = 10
+	// DefaultFlashcardEase is the ease of cards that have not been reviewed, as in SM-2.
+	DefaultFlashcardEase = 2.5
+	minFlashcardEase     = 1.3
+	// flashcardRelearn is how soon a card graded again is shown again, so that it is relearned in the same session.
+	flashcardRelearn = 10 * time.Minute
+)
+
+// The grades of a flashcard review, from forgetting the card to recalling it easily.
+const (
+	FlashcardAgain = "again"
+	FlashcardGood  = "good"
+	FlashcardEasy  = "easy"
+)
+
+// flashcardQuality maps grades to SM-2 response qualities, from 0 to 5.
+var flashcardQuality = map[string]float64{FlashcardAgain: 1, FlashcardGood: 4, FlashcardEasy: 5}
+
+// FlashcardDeck is a deck of flashcards, shared with the course or personal to a student.
+type FlashcardDeck struct {
+	ID       int    `json:"id,omitempty"`
+	CourseID string `json:"course_id"`
+	// OwnerID is the student a personal deck belongs to, and is empty for decks shared with the course.
+	OwnerID   string      `json:"owner_id,omitempty"`
+	Name      string      `json:"name"`
+	Cards     []Flashcard `json:"cards"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Flashcard is a card with a prompt on its front and the answer on its back.
+type Flashcard struct {
+	ID    int    `json:"id,omitempty"`
+	Front string `json:"front"`
+	Back  string `json:"back"`
+}
+
+// FlashcardReview is a student's SM-2 schedule for a card. Cards a student has not reviewed have no review.
+type FlashcardReview struct {
+	CourseID    string `json:"course_id"`
+	DeckID      int    `json:"deck_id"`
+	CardID      int    `json:"card_id"`
+	UserID      string `json:"user_id"`
+	Repetitions int    `json:"repetitions"`
+	// IntervalDays is the days between the last review and DueAt, which is 0 while the card is being relearned.
+	IntervalDays int       `json:"interval_days"`
+	Ease         float64   `json:"ease"`
+	DueAt        time.Time `json:"due_at"`
+	ReviewedAt   time.Time `json:"reviewed_at"`
+}
+
+// Shared reports whether the deck is shared with the course rather than personal.
+func (deck FlashcardDeck) Shared() bool {
+	return deck.OwnerID == ""
+}
+
+// VisibleTo reports whether a student can study the deck: decks shared with their course, and their own decks.
+func (deck FlashcardDeck) VisibleTo(courseID string, userID string) bool {
+	return deck.CourseID == courseID && (deck.Shared() || deck.OwnerID == userID)
+}
+
+// Card returns a card of the deck by its ID, and whether it is in the deck.
+func (deck FlashcardDeck) Card(cardID int) (Flashcard, bool) {
+	index := slices.IndexFunc(deck.Cards, func(card Flashcard) bool { return card.ID == cardID })
+	if index < 0 {
+		return Flashcard{}, false
+	}
+	return deck.Cards[index], true
+}
+
+// NewFlashcardReview returns the review of a card a student has not reviewed yet.
+func NewFlashcardReview(deck FlashcardDeck, cardID int, userID string) FlashcardReview {
+	return FlashcardReview{CourseID: deck.CourseID, DeckID: deck.ID, CardID: cardID, UserID: userID, Ease: DefaultFlashcardEase}
+}
+
+// Grade returns the review after grading the card at now, scheduling it with SM-2.
+// Cards graded again restart their repetitions and are shown again after flashcardRelearn.
+// Cards recalled are next due after 1 day, then 6 days, then their last interval multiplied by their ease.
+func (review FlashcardReview) Grade(grade string, now time.Time) FlashcardReview {
+	quality := flashcardQuality[grade]
+	review.Ease = max(review.Ease+0.1-(5-quality)*(0.08+(5-quality)*0.02), minFlashcardEase)
+	review.ReviewedAt = now.Truncate(time.Second)
+
+	if grade == FlashcardAgain {
+		review.Repetitions = 0
+		review.IntervalDays = 0
+		review.DueAt = review.ReviewedAt.Add(flashcardRelearn)
+		return review
+	}
+
+	switch review.Repetitions {
+	case 0:
+		review.IntervalDays = 1
+	case 1:
+		review.IntervalDays = 6
+	default:
+		review.IntervalDays = int(math.Round(float64(review.IntervalDays) * review.Ease))
+	}
+	review.Repetitions++
+	review.DueAt = review.ReviewedAt.AddDate(0, 0, review.IntervalDays)
+	return review
+}
+
+// Due reports whether the card is due for review at now.
+func (review FlashcardReview) Due(now time.Time) bool {
+	return !review.DueAt.After(now)
+}
+
+// NextFlashcard returns the next card of the deck for a student to study at now, with how many cards are left to study including it,
+// and whether there is one. Reviewed cards that are due come first, most overdue first, then cards the student has not reviewed, in deck order.
+func NextFlashcard(deck FlashcardDeck, reviews []FlashcardReview, now time.Time) (Flashcard, int, bool) {
+	reviewed := map[int]FlashcardReview{}
+	for _, review := range reviews {
+		if review.DeckID == deck.ID {
+			reviewed[review.CardID] = review
+		}
+	}
+
+	due, unreviewed := []Flashcard{}, []Flashcard{}
+	for _, card := range deck.Cards {
+		review, exists := reviewed[card.ID]
+		if !exists {
+			unreviewed = append(unreviewed, card)
+		} else if review.Due(now) {
+			due = append(due, card)
+		}
+	}
+	slices.SortStableFunc(due, func(a Flashcard, b Flashcard) int { return reviewed[a.ID].DueAt.Compare(reviewed[b.ID].DueAt) })
+
+	cards := append(due, unreviewed...)
+	if len(cards) == 0 {
+		return Flashcard{}, 0, false
+	}
+	return cards[0], len(cards), true
+}
+
+// NextFlashcardReview returns when the next card a student has reviewed is due, among reviews, and whether they have reviewed any.
+func NextFlashcardReview(reviews []FlashcardReview) (time.Time, bool) {
+	if len(reviews) == 0 {
+		return time.Time{}, false
+	}
+	return slices.MinFunc(reviews, func(a FlashcardReview, b FlashcardReview) int { return a.DueAt.Compare(b.DueAt) }).DueAt, true
+}
+
+// DueFlashcards counts the reviewed cards due at now among reviews.
+func DueFlashcards(reviews []FlashcardReview, now time.Time) int {
+	count := 0
+	for _, review := range reviews {
+		if review.Due(now) {
+			count++
+		}
+	}
+	return count
+}
+
+// ParseFlashcardLines parses cards written one per line as "front | back", ignoring blank lines.
+// Errors are written in locale, to show the member who wrote the cards.
+func ParseFlashcardLines(locale locales.Locale, text string) ([]Flashcard, error) {
+	cards := []Flashcard{}
+	errs := []string{}
+	for index, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		front, back, found := strings.Cut(line, "|")
+		if !found {
+			errs = append(errs, locale.T("flashcards.line_no_separator", index+1))
+			continue
+		}
+		card, err := newFlashcard(locale, index+1, front, back)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		cards = append(cards, card)
+	}
+	return cards, validateFlashcards(locale, cards, errs)
+}
+
+// ParseFlashcardCSV parses cards from CSV with the front in the first column and the back in the second.
+// A first row of "front,back" is treated as a header. Errors are written in locale, to show the member who uploaded the cards.
+func ParseFlashcardCSV(locale locales.Locale, data []byte) ([]Flashcard, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	cards := []Flashcard{}
+	errs := []string{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		parseErr := &csv.ParseError{}
+		if errors.As(err, &parseErr) {
+			return nil, errors.New(locale.T("flashcards.invalid_csv", parseErr.Line))
+		}
+		if err != nil {
+			return nil, errors.New(locale.T("flashcards.invalid_csv", line))
+		}
+		if line == 1 && len(record) >= 2 && strings.EqualFold(strings.TrimSpace(record[0]), "front") && strings.EqualFold(strings.TrimSpace(record[1]), "back") {
+			continue
+		}
+		if len(record) < 2 {
+			errs = append(errs, locale.T("flashcards.line_no_back", line))
+			continue
+		}
+		card, err := newFlashcard(locale, line, record[0], record[1])
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		cards = append(cards, card)
+	}
+	return cards, validateFlashcards(locale, cards, errs)
+}
+
+// newFlashcard returns a card from the front and back on a line, trimming spaces.
+func newFlashcard(locale locales.Locale, line int, front string, back string) (Flashcard, error) {
+	card := Flashcard{Front: strings.TrimSpace(front), Back: strings.TrimSpace(back)}
+	if card.Front == "" || card.Back == "" {
+		return card, errors.New(locale.T("flashcards.line_missing_side", line))
+	}
+	if utf8.RuneCountInString(card.Front) > maxFlashcardLength || utf8.RuneCountInString(card.Back) > maxFlashcardLength {
+		return card, errors.New(locale.T("flashcards.line_too_long", line, maxFlashcardLength))
+	}
+	return card, nil
+}
+
+// validateFlashcards returns an error listing the errors parsing cards, and whether there are too few or too many cards.
+// Only the first maxFlashcardErrors errors are listed, followed by how many more there are.
+func validateFlashcards(locale locales.Locale, cards []Flashcard, errs []string) error {
+	if len(cards) == 0 && len(errs) == 0 {
+		errs = append(errs, locale.T("flashcards.no_cards"))
+	}
+	if len(cards) > MaxFlashcards {
+		errs = append(errs, locale.T("flashcards.too_many_cards", MaxFlashcards, len(cards)))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) > maxFlashcardErrors {
+		errs = append(errs[:maxFlashcardErrors], locale.T("flashcards.more_errors", len(errs)-maxFlashcardErrors))
+	}
+	return errors.New(strings.Join(errs, "\n"))
+}
+
+// SortFlashcardDecks sorts decks with decks shared with the course first, then by name.
+func SortFlashcardDecks(decks []FlashcardDeck) {
+	slices.SortFunc(decks, func(a FlashcardDeck, b FlashcardDeck) int {
+		if a.Shared() != b.Shared() {
+			if a.Shared() {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+}
+
+// ReadFlashcardDeck retrieves a flashcard deck by its ID from the backend, with its cards.
+func (backend *APIClient) ReadFlashcardDeck(ctx context.Context, deckID string) (FlashcardDeck, error) {
+	ctx, span := tracing.Start(ctx, "readFlashcardDeck")
+	defer span.End()
+
+	deck := FlashcardDeck{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("flashcards/decks?id=%s", deckID), nil, http.StatusOK, &deck)
+	return deck, err
+}
+
+// ListFlashcardDecks lists the decks a student can study in a course: decks shared with the course, and their own decks.
+func (backend *APIClient) ListFlashcardDecks(ctx context.Context, courseID string, userID string) ([]FlashcardDeck, error) {
+	ctx, span := tracing.Start(ctx, "listFlashcardDecks")
+	defer span.End()
+
+	decks := []FlashcardDeck{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("flashcards/decks?course_id=%s&user_id=%s", courseID, userID), nil, http.StatusOK, &decks)
+	return decks, err
+}
+
+// CreateFlashcardDeck creates a new flashcard deck in the backend, with its cards.
+func (backend *APIClient) CreateFlashcardDeck(ctx context.Context, deck FlashcardDeck) (FlashcardDeck, error) {
+	ctx, span := tracing.Start(ctx, "createFlashcardDeck")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPost, "flashcards/decks", deck, http.StatusCreated, &deck)
+	return deck, err
+}
+
+// DeleteFlashcardDeck deletes a flashcard deck from the backend, with its cards and their reviews.
+func (backend *APIClient) DeleteFlashcardDeck(ctx context.Context, deckID string) error {
+	ctx, span := tracing.Start(ctx, "deleteFlashcardDeck")
+	defer span.End()
+
+	return backend.jsonRequest(ctx, http.MethodDelete, fmt.Sprintf("flashcards/decks?id=%s", deckID), nil, http.StatusNoContent, nil)
+}
+
+// ListFlashcardReviews lists a student's reviews of cards in every deck of a course.
+func (backend *APIClient) ListFlashcardReviews(ctx context.Context, courseID string, userID string) ([]FlashcardReview, error) {
+	ctx, span := tracing.Start(ctx, "listFlashcardReviews")
+	defer span.End()
+
+	reviews := []FlashcardReview{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("flashcards/reviews?course_id=%s&user_id=%s", courseID, userID), nil, http.StatusOK, &reviews)
+	return reviews, err
+}
+
+// UpdateFlashcardReview records a student's review of a card, creating it the first time they review the card.
+func (backend *APIClient) UpdateFlashcardReview(ctx context.Context, review FlashcardReview) (FlashcardReview, error) {
+	ctx, span := tracing.Start(ctx, "updateFlashcardReview")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPut, "flashcards/reviews", review, http.StatusAccepted, &review)
+	return review, err
+}
@@ -0,0 +1,139 @@
+package clients_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/stretchr/testify/suite"
+)
+
+type FlashcardsTestSuite struct {
+	suite.Suite
+	now  time.Time
+	deck clients.FlashcardDeck
+}
+
+func TestFlashcards(t *testing.T) {
+	suite.Run(t, new(FlashcardsTestSuite))
+}
+
+func (testSuite *FlashcardsTestSuite) SetupTest() {
+	testSuite.now = time.Date(2025, 2, 3, 9, 0, 0, 0, time.UTC)
+	testSuite.deck = clients.FlashcardDeck{
+		ID:       1,
+		CourseID: "course",
+		Name:     "complexity",
+		Cards: []clients.Flashcard{
+			{ID: 1, Front: "binary search", Back: "O(log n)"},
+			{ID: 2, Front: "merge sort", Back: "O(n log n)"},
+			{ID: 3, Front: "hash lookup", Back: "O(1)"},
+		},
+	}
+}
+
+func (testSuite *FlashcardsTestSuite) TestGrade() {
+	review := clients.NewFlashcardReview(testSuite.deck, 1, "student1")
+
+	review = review.Grade(clients.FlashcardGood, testSuite.now)
+	testSuite.Equal(1, review.IntervalDays)
+	testSuite.Equal(clients.DefaultFlashcardEase, review.Ease)
+	testSuite.Equal(testSuite.now.AddDate(0, 0, 1), review.DueAt)
+
+	review = review.Grade(clients.FlashcardGood, review.DueAt)
+	testSuite.Equal(6, review.IntervalDays)
+	review = review.Grade(clients.FlashcardEasy, review.DueAt)
+	testSuite.InDelta(2.6, review.Ease, 0.001)
+	testSuite.Equal(16, review.IntervalDays)
+	testSuite.Equal(3, review.Repetitions)
+
+	// forgetting the card restarts it, showing it again in the same session
+	reviewedAt := review.DueAt
+	review = review.Grade(clients.FlashcardAgain, reviewedAt)
+	testSuite.Equal(0, review.Repetitions)
+	testSuite.Equal(0, review.IntervalDays)
+	testSuite.InDelta(2.06, review.Ease, 0.001)
+	testSuite.Equal(reviewedAt.Add(10*time.Minute), review.DueAt)
+}
+
+func (testSuite *FlashcardsTestSuite) TestNextFlashcard() {
+	reviews := []clients.FlashcardReview{
+		{DeckID: 1, CardID: 1, DueAt: testSuite.now.Add(-time.Hour)},
+		{DeckID: 1, CardID: 3, DueAt: testSuite.now.Add(-2 * time.Hour)},
+		{DeckID: 2, CardID: 2, DueAt: testSuite.now.Add(time.Hour)},
+	}
+
+	// due cards come first, most overdue first, then cards that have not been reviewed
+	card, remaining, exists := clients.NextFlashcard(testSuite.deck, reviews, testSuite.now)
+	testSuite.True(exists)
+	testSuite.Equal(3, card.ID)
+	testSuite.Equal(3, remaining)
+
+	reviews[1].DueAt = testSuite.now.AddDate(0, 0, 1)
+	reviews[0].DueAt = testSuite.now.AddDate(0, 0, 6)
+	reviews = append(reviews, clients.FlashcardReview{DeckID: 1, CardID: 2, DueAt: testSuite.now.Add(time.Hour)})
+	_, _, exists = clients.NextFlashcard(testSuite.deck, reviews, testSuite.now)
+	testSuite.False(exists)
+	// reminders count due cards in every deck of the course
+	testSuite.Equal(2, clients.DueFlashcards(reviews, testSuite.now.Add(time.Hour)))
+}
+
+func (testSuite *FlashcardsTestSuite) TestNewFlashcardReminderNotification() {
+	// every card due before the day's reminder time shares its reminder, and so its schedule entry
+	morning := clients.NewFlashcardReminderNotification("course", "student1", testSuite.now)
+	afternoon := clients.NewFlashcardReminderNotification("course", "student1", testSuite.now.Add(7*time.Hour))
+	testSuite.Equal(time.Date(2025, 2, 3, 16, 0, 0, 0, time.UTC), morning.RemindAt)
+	testSuite.Equal(morning, afternoon)
+
+	evening := clients.NewFlashcardReminderNotification("course", "student1", testSuite.now.Add(8*time.Hour))
+	testSuite.Equal(time.Date(2025, 2, 4, 16, 0, 0, 0, time.UTC), evening.RemindAt)
+	testSuite.NotEqual(morning.MessageID(), evening.MessageID())
+}
+
+func (testSuite *FlashcardsTestSuite) TestParseFlashcardLines() {
+	cards, err := clients.ParseFlashcardLines(locales.Default, "binary search | O(log n)\n\n merge sort|O(n log n) \n")
+	testSuite.NoError(err)
+	testSuite.Equal([]clients.Flashcard{{Front: "binary search", Back: "O(log n)"}, {Front: "merge sort", Back: "O(n log n)"}}, cards)
+
+	_, err = clients.ParseFlashcardLines(locales.Default, "binary search\nmerge sort | ")
+	testSuite.ErrorContains(err, "line 1 has no | between its front and back")
+	testSuite.ErrorContains(err, "line 2 is missing its front or back")
+
+	_, err = clients.ParseFlashcardLines(locales.Default, " \n")
+	testSuite.ErrorContains(err, "there are no cards")
+}
+
+func (testSuite *FlashcardsTestSuite) TestParseFlashcardCSV() {
+	cards, err := clients.ParseFlashcardCSV(locales.Default, []byte("Front,Back\nbinary search,O(log n)\n\"merge sort, top down\",\"O(n log n)\"\n"))
+	testSuite.NoError(err)
+	testSuite.Equal([]clients.Flashcard{{Front: "binary search", Back: "O(log n)"}, {Front: "merge sort, top down", Back: "O(n log n)"}}, cards)
+
+	_, err = clients.ParseFlashcardCSV(locales.Default, []byte("binary search\n"))
+	testSuite.ErrorContains(err, "line 1 has no back")
+
+	_, err = clients.ParseFlashcardCSV(locales.Default, []byte(strings.Repeat("front,back\n", clients.MaxFlashcards+2)))
+	testSuite.ErrorContains(err, "decks can have at most 500 cards")
+
+	_, err = clients.ParseFlashcardCSV(locales.Default, []byte("front,\"back\n"))
+	testSuite.ErrorContains(err, "not valid CSV, starting on line 1")
+}
+
+func (testSuite *FlashcardsTestSuite) TestParseFlashcardErrors() {
+	// card lengths are counted in characters, not bytes
+	cards, err := clients.ParseFlashcardLines(locales.Default, strings.Repeat("漢", 1000)+" | "+strings.Repeat("字", 1000))
+	testSuite.NoError(err)
+	testSuite.Len(cards, 1)
+
+	_, err = clients.ParseFlashcardLines(locales.Default, strings.Repeat("漢", 1001)+" | answer")
+	testSuite.ErrorContains(err, "line 1 is longer than 1000 characters")
+
+	_, err = clients.ParseFlashcardLines(locales.Locale("es"), "binary search")
+	testSuite.ErrorContains(err, "la línea 1 no tiene |")
+
+	// only the first errors are listed, so that they fit in a message
+	_, err = clients.ParseFlashcardLines(locales.Default, strings.Repeat("no separator\n", 500))
+	testSuite.Equal(11, strings.Count(err.Error(), "\n")+1)
+	testSuite.ErrorContains(err, "and 490 more problems")
+}
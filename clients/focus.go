@@ -0,0 +1,275 @@
+// Package clients provides focus timers, which are kept in a JetStream KV bucket so that they survive restarts.
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/palantir/stacktrace"
+)
+
+// focusTimerTTL is how long a focus timer is kept after it last changed, so that timers abandoned while paused are removed.
+const focusTimerTTL = 24 * time.Hour
+
+// The phases of a focus timer. Timers alternate between focus and break phases until their last focus phase,
+// and are done after it or stopped when stopped early.
+const (
+	FocusPhase   = "focus"
+	BreakPhase   = "break"
+	FocusDone    = "done"
+	FocusStopped = "stopped"
+)
+
+// FocusTimer is a pomodoro timer running in a voice channel or thread, with one timer per channel.
+type FocusTimer struct {
+	ChannelID    string `json:"channel_id"`
+	CourseID     string `json:"course_id"`
+	MessageID    string `json:"message_id"`
+	StartedBy    string `json:"started_by"`
+	FocusMinutes int    `json:"focus_minutes"`
+	BreakMinutes int    `json:"break_minutes"`
+	Cycles       int    `json:"cycles"`
+	// Cycle is the current cycle, starting from 1. Each cycle is a focus phase followed by a break phase, except the last.
+	Cycle       int       `json:"cycle"`
+	Phase       string    `json:"phase"`
+	PhaseEndsAt time.Time `json:"phase_ends_at"`
+	// PausedAt is zero while the timer is running. Resuming the timer pushes PhaseEndsAt back by how long it was paused.
+	PausedAt time.Time `json:"paused_at,omitzero"`
+	// Participants are pinged when the phase changes.
+	Participants []string `json:"participants"`
+	// revision is the KV revision the timer was read at, so that saving it does not overwrite changes made since.
+	revision uint64
+}
+
+// NewFocusTimer returns a focus timer starting its first focus phase at now, with the user who started it participating.
+func NewFocusTimer(courseID string, channelID string, userID string, focusMinutes int, breakMinutes int, cycles int, now time.Time) FocusTimer {
+	return FocusTimer{
+		ChannelID:    channelID,
+		CourseID:     courseID,
+		StartedBy:    userID,
+		FocusMinutes: focusMinutes,
+		BreakMinutes: breakMinutes,
+		Cycles:       cycles,
+		Cycle:        1,
+		Phase:        FocusPhase,
+		PhaseEndsAt:  now.Add(time.Duration(focusMinutes) * time.Minute).Truncate(time.Second),
+		Participants: []string{userID},
+	}
+}
+
+// Running reports whether the timer is neither done nor stopped.
+func (timer FocusTimer) Running() bool {
+	return timer.Phase == FocusPhase || timer.Phase == BreakPhase
+}
+
+// Paused reports whether the timer is paused.
+func (timer FocusTimer) Paused() bool {
+	return !timer.PausedAt.IsZero()
+}
+
+// Remaining returns how long is left in the current phase at now, which does not change while the timer is paused.
+func (timer FocusTimer) Remaining(now time.Time) time.Duration {
+	if timer.Paused() {
+		now = timer.PausedAt
+	}
+	return max(timer.PhaseEndsAt.Sub(now), 0)
+}
+
+// Due reports whether the current phase of a running, unpaused timer has ended at now.
+func (timer FocusTimer) Due(now time.Time) bool {
+	return timer.Running() && !timer.Paused() && !now.Before(timer.PhaseEndsAt)
+}
+
+// Advance moves the timer to its next phase starting at now: a break after a focus phase, or the next cycle's focus after a break.
+// The timer is done after the focus phase of its last cycle. Phases start at now rather than when the last phase ended,
+// so that a timer missed while the bot was restarting continues from its next phase instead of skipping phases.
+func (timer *FocusTimer) Advance(now time.Time) {
+	switch {
+	case timer.Phase == FocusPhase && timer.Cycle >= timer.Cycles:
+		timer.Phase = FocusDone
+	case timer.Phase == FocusPhase:
+		timer.Phase = BreakPhase
+		timer.PhaseEndsAt = now.Add(time.Duration(timer.BreakMinutes) * time.Minute).Truncate(time.Second)
+	case timer.Phase == BreakPhase:
+		timer.Cycle++
+		timer.Phase = FocusPhase
+		timer.PhaseEndsAt = now.Add(time.Duration(timer.FocusMinutes) * time.Minute).Truncate(time.Second)
+	}
+}
+
+// Pause pauses the timer at now.
+func (timer *FocusTimer) Pause(now time.Time) {
+	if !timer.Paused() {
+		timer.PausedAt = now.Truncate(time.Second)
+	}
+}
+
+// Resume resumes a paused timer at now, with the time that was left when it was paused.
+func (timer *FocusTimer) Resume(now time.Time) {
+	if timer.Paused() {
+		timer.PhaseEndsAt = timer.PhaseEndsAt.Add(now.Truncate(time.Second).Sub(timer.PausedAt))
+		timer.PausedAt = time.Time{}
+	}
+}
+
+// ToggleParticipant adds the user to the timer's participants, or removes them if they are participating, and reports whether they joined.
+func (timer *FocusTimer) ToggleParticipant(userID string) bool {
+	if slices.Contains(timer.Participants, userID) {
+		timer.Participants = slices.DeleteFunc(slices.Clone(timer.Participants), func(participant string) bool { return participant == userID })
+		return false
+	}
+	timer.Participants = append(slices.Clone(timer.Participants), userID)
+	return true
+}
+
+// focusBucket returns the focus timer KV bucket on the publisher connection, creating it on first use.
+func (mqClient *MQClient) focusBucket(ctx context.Context) (jetstream.KeyValue, error) {
+	js, err := mqClient.publisherStream()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error getting NATS publisher")
+	}
+
+	mqClient.publisherLock.Lock()
+	defer mqClient.publisherLock.Unlock()
+	if mqClient.focus != nil {
+		return mqClient.focus, nil
+	}
+
+	bucket := fmt.Sprintf("%s_focus", mqClient.StreamName)
+	kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      bucket,
+		Description: "running focus timers, by course and channel ID",
+		TTL:         focusTimerTTL,
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error creating key value bucket: %s", bucket)
+	}
+	mqClient.focus = kv
+	return kv, nil
+}
+
+// focusKey returns the KV key of the focus timer in a channel. Keys start with the course ID,
+// so that each shard can list the timers of only its own courses.
+func focusKey(courseID string, channelID string) string {
+	return fmt.Sprintf("%s.%s", courseID, channelID)
+}
+
+// ReadFocusTimer reads the focus timer running in a channel of a course, and reports whether there is one.
+func (mqClient *MQClient) ReadFocusTimer(ctx context.Context, courseID string, channelID string) (FocusTimer, bool, error) {
+	ctx, span := tracing.Start(ctx, "readFocusTimer")
+	defer span.End()
+
+	kv, err := mqClient.focusBucket(ctx)
+	if err != nil {
+		return FocusTimer{}, false, stacktrace.Propagate(err, "error getting focus bucket")
+	}
+	entry, err := kv.Get(ctx, focusKey(courseID, channelID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return FocusTimer{}, false, nil
+	}
+	if err != nil {
+		return FocusTimer{}, false, stacktrace.Propagate(err, "error reading focus timer: %s", channelID)
+	}
+	timer, err := decodeFocusTimer(entry)
+	return timer, err == nil, err
+}
+
+// ListFocusTimers lists the running focus timers of the given courses. The timers are read in a single pass over the
+// bucket, filtered by course, rather than reading every timer in every course one at a time.
+func (mqClient *MQClient) ListFocusTimers(ctx context.Context, courseIDs []string) ([]FocusTimer, error) {
+	ctx, span := tracing.Start(ctx, "listFocusTimers")
+	defer span.End()
+
+	if len(courseIDs) == 0 {
+		// an empty filter would watch every course
+		return []FocusTimer{}, nil
+	}
+	kv, err := mqClient.focusBucket(ctx)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error getting focus bucket")
+	}
+	filters := make([]string, 0, len(courseIDs))
+	for _, courseID := range courseIDs {
+		filters = append(filters, focusKey(courseID, "*"))
+	}
+	watcher, err := kv.WatchFiltered(ctx, filters, jetstream.IgnoreDeletes())
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error listing focus timers")
+	}
+	defer watcher.Stop()
+
+	timers := []FocusTimer{}
+	errs := []error{}
+	for entry := range watcher.Updates() {
+		if entry == nil {
+			// every current timer has been read
+			break
+		}
+		timer, err := decodeFocusTimer(entry)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		timers = append(timers, timer)
+	}
+	return timers, errors.Join(errs...)
+}
+
+// SaveFocusTimer saves a focus timer, returning it at its new revision. A new timer is only created if its channel has no timer,
+// and a timer read earlier is only saved if it has not changed since it was read, so that concurrent changes are not lost.
+func (mqClient *MQClient) SaveFocusTimer(ctx context.Context, timer FocusTimer) (FocusTimer, error) {
+	ctx, span := tracing.Start(ctx, "saveFocusTimer")
+	defer span.End()
+
+	kv, err := mqClient.focusBucket(ctx)
+	if err != nil {
+		return timer, stacktrace.Propagate(err, "error getting focus bucket")
+	}
+	value, err := json.Marshal(timer)
+	if err != nil {
+		return timer, stacktrace.Propagate(err, "error marshalling focus timer")
+	}
+
+	if timer.revision == 0 {
+		timer.revision, err = kv.Create(ctx, focusKey(timer.CourseID, timer.ChannelID), value)
+	} else {
+		timer.revision, err = kv.Update(ctx, focusKey(timer.CourseID, timer.ChannelID), value, timer.revision)
+	}
+	if err != nil {
+		return timer, stacktrace.Propagate(err, "error saving focus timer: %s", timer.ChannelID)
+	}
+	return timer, nil
+}
+
+// DeleteFocusTimer deletes a focus timer when it is done or stopped, unless it has changed since it was read.
+func (mqClient *MQClient) DeleteFocusTimer(ctx context.Context, timer FocusTimer) error {
+	ctx, span := tracing.Start(ctx, "deleteFocusTimer")
+	defer span.End()
+
+	kv, err := mqClient.focusBucket(ctx)
+	if err != nil {
+		return stacktrace.Propagate(err, "error getting focus bucket")
+	}
+	err = kv.Delete(ctx, focusKey(timer.CourseID, timer.ChannelID), jetstream.LastRevision(timer.revision))
+	if err != nil {
+		return stacktrace.Propagate(err, "error deleting focus timer: %s", timer.ChannelID)
+	}
+	return nil
+}
+
+// decodeFocusTimer decodes a focus timer from its KV entry, at the entry's revision.
+func decodeFocusTimer(entry jetstream.KeyValueEntry) (FocusTimer, error) {
+	timer := FocusTimer{}
+	err := json.Unmarshal(entry.Value(), &timer)
+	if err != nil {
+		return timer, stacktrace.Propagate(err, "error unmarshalling focus timer: %s", entry.Key())
+	}
+	timer.revision = entry.Revision()
+	return timer, nil
+}
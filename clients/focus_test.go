@@ -0,0 +1,69 @@
+package clients_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/stretchr/testify/suite"
+)
+
+type FocusTestSuite struct {
+	suite.Suite
+	startedAt time.Time
+	timer     clients.FocusTimer
+}
+
+func TestFocus(t *testing.T) {
+	suite.Run(t, new(FocusTestSuite))
+}
+
+func (testSuite *FocusTestSuite) SetupTest() {
+	testSuite.startedAt = time.Date(2025, 2, 3, 14, 0, 0, 0, time.UTC)
+	testSuite.timer = clients.NewFocusTimer("course", "channel", "student1", 25, 5, 2, testSuite.startedAt)
+}
+
+func (testSuite *FocusTestSuite) TestAdvance() {
+	testSuite.False(testSuite.timer.Due(testSuite.startedAt.Add(24 * time.Minute)))
+	testSuite.True(testSuite.timer.Due(testSuite.startedAt.Add(25 * time.Minute)))
+
+	// the bot was down for a minute, so the break starts when the timer is advanced
+	breakAt := testSuite.startedAt.Add(26 * time.Minute)
+	testSuite.timer.Advance(breakAt)
+	testSuite.Equal(clients.BreakPhase, testSuite.timer.Phase)
+	testSuite.Equal(breakAt.Add(5*time.Minute), testSuite.timer.PhaseEndsAt)
+
+	focusAt := breakAt.Add(5 * time.Minute)
+	testSuite.timer.Advance(focusAt)
+	testSuite.Equal(clients.FocusPhase, testSuite.timer.Phase)
+	testSuite.Equal(2, testSuite.timer.Cycle)
+	testSuite.Equal(focusAt.Add(25*time.Minute), testSuite.timer.PhaseEndsAt)
+
+	testSuite.timer.Advance(focusAt.Add(25 * time.Minute))
+	testSuite.Equal(clients.FocusDone, testSuite.timer.Phase)
+	testSuite.False(testSuite.timer.Running())
+	testSuite.False(testSuite.timer.Due(focusAt.Add(time.Hour)))
+}
+
+func (testSuite *FocusTestSuite) TestPause() {
+	pausedAt := testSuite.startedAt.Add(10 * time.Minute)
+	testSuite.timer.Pause(pausedAt)
+
+	testSuite.True(testSuite.timer.Paused())
+	testSuite.Equal(15*time.Minute, testSuite.timer.Remaining(pausedAt.Add(time.Hour)))
+	testSuite.False(testSuite.timer.Due(pausedAt.Add(time.Hour)))
+
+	resumedAt := pausedAt.Add(time.Hour)
+	testSuite.timer.Resume(resumedAt)
+	testSuite.False(testSuite.timer.Paused())
+	testSuite.Equal(resumedAt.Add(15*time.Minute), testSuite.timer.PhaseEndsAt)
+	testSuite.Equal(5*time.Minute, testSuite.timer.Remaining(resumedAt.Add(10*time.Minute)))
+}
+
+func (testSuite *FocusTestSuite) TestToggleParticipant() {
+	testSuite.True(testSuite.timer.ToggleParticipant("student2"))
+	testSuite.Equal([]string{"student1", "student2"}, testSuite.timer.Participants)
+
+	testSuite.False(testSuite.timer.ToggleParticipant("student1"))
+	testSuite.Equal([]string{"student2"}, testSuite.timer.Participants)
+}
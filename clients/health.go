@@ -0,0 +1,48 @@
+// Package clients provides health checks for the backend API and NATS consumer.
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/palantir/stacktrace"
+)
+
+// Ping checks that the backend is reachable. Any response other than a server error counts as reachable.
+func (backend *APIClient) Ping(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "ping")
+	defer span.End()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, backend.Url, nil)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to create API request")
+	}
+	request.Header.Add("authorization", fmt.Sprintf("Token %s", backend.APIKey))
+
+	response, err := backend.HttpClient.Do(request)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to execute API request")
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= http.StatusInternalServerError {
+		return stacktrace.NewError("backend server error: %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// ConsumerState returns the state of the supervised JetStream consumer.
+func (mqClient *MQClient) ConsumerState() ConsumerState {
+	return ConsumerState(mqClient.consumerState.Load())
+}
+
+// ConsumerReady returns an error unless the JetStream consumer is connected and consuming messages.
+func (mqClient *MQClient) ConsumerReady() error {
+	state := mqClient.ConsumerState()
+	if state != ConsumerConsuming {
+		return stacktrace.NewError("jetstream consumer for stream %s is %s", mqClient.StreamName, state)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Package clients provides functions for inspecting the NATS JetStream notifications stream and reminder schedule.
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/palantir/stacktrace"
+)
+
+// StreamStatus describes the notifications stream, its durable consumer, and the messages the consumer has not yet acknowledged.
+type StreamStatus struct {
+	Stream   *jetstream.StreamInfo
+	Consumer *jetstream.ConsumerInfo
+	Pending  []PendingMessage
+	// Scheduled are the reminders in the schedule bucket waiting for their fire time, earliest first.
+	Scheduled []ScheduledReminder
+}
+
+// PendingMessage is a stream message after the consumer's ack floor, such as a reminder waiting for its notification time.
+type PendingMessage struct {
+	Sequence     uint64
+	Subject      string
+	Published    time.Time
+	Data         []byte
+	Notification *AssignmentNotification
+}
+
+// InspectStream returns the status of the notifications stream, up to limit messages after the consumer's ack floor,
+// and up to limit scheduled reminders.
+func (mqClient *MQClient) InspectStream(ctx context.Context, limit int) (StreamStatus, error) {
+	ctx, span := tracing.Start(ctx, "inspectStream")
+	defer span.End()
+
+	status := StreamStatus{}
+
+	slog.Debug(fmt.Sprintf("opening NATS inspection connection to: %s", mqClient.NATSUrl))
+	connection, err := nats.Connect(mqClient.NATSUrl)
+	if err != nil {
+		return status, stacktrace.Propagate(err, "error connecting to NATS: %s", mqClient.NATSUrl)
+	}
+	defer connection.Close()
+
+	js, err := jetstream.New(connection)
+	if err != nil {
+		return status, stacktrace.Propagate(err, "error opening jetstream connection")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	stream, err := js.Stream(ctx, mqClient.StreamName)
+	if err != nil {
+		return status, stacktrace.Propagate(err, "error getting stream with name: %s", mqClient.StreamName)
+	}
+	status.Stream, err = stream.Info(ctx)
+	if err != nil {
+		return status, stacktrace.Propagate(err, "error getting info for stream: %s", mqClient.StreamName)
+	}
+
+	consumer, err := stream.Consumer(ctx, mqClient.consumerName())
+	if err != nil {
+		return status, stacktrace.Propagate(err, "error getting consumer %s for stream: %s", mqClient.consumerName(), mqClient.StreamName)
+	}
+	status.Consumer, err = consumer.Info(ctx)
+	if err != nil {
+		return status, stacktrace.Propagate(err, "error getting info for consumer: %s", mqClient.consumerName())
+	}
+
+	for sequence := status.Consumer.AckFloor.Stream + 1; sequence <= status.Stream.State.LastSeq && len(status.Pending) < limit; sequence++ {
+		message, err := stream.GetMsg(ctx, sequence)
+		if err != nil {
+			// messages may have been removed by stream limits
+			slog.Debug(stacktrace.Propagate(err, "error getting message with sequence: %d", sequence).Error())
+			continue
+		}
+		if !mqClient.consumesSubject(message.Subject) {
+			continue
+		}
+
+		pending := PendingMessage{
+			Sequence:  message.Sequence,
+			Subject:   message.Subject,
+			Published: message.Time,
+			Data:      message.Data,
+		}
+		envelope, err := DecodeEnvelope(messageKind(message.Subject), message.Data)
+		if err == nil && envelope.Type == AssignmentReminderMessage {
+			notification := AssignmentNotification{}
+			if envelope.Decode(&notification) == nil {
+				pending.Notification = &notification
+			}
+		}
+		status.Pending = append(status.Pending, pending)
+	}
+
+	schedule, err := js.KeyValue(ctx, fmt.Sprintf("%s_schedule", mqClient.StreamName))
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		return status, nil
+	}
+	if err != nil {
+		return status, stacktrace.Propagate(err, "error getting schedule bucket for stream: %s", mqClient.StreamName)
+	}
+	keys, err := schedule.Keys(ctx)
+	if err != nil && !errors.Is(err, jetstream.ErrNoKeysFound) {
+		return status, stacktrace.Propagate(err, "error listing scheduled reminders")
+	}
+	// keys are prefixed by fire time, so sorting them sorts reminders by fire time
+	slices.Sort(keys)
+	for _, key := range keys {
+		if len(status.Scheduled) >= limit {
+			break
+		}
+		entry, err := schedule.Get(ctx, key)
+		if err != nil {
+			slog.Debug(stacktrace.Propagate(err, "error getting scheduled reminder: %s", key).Error())
+			continue
+		}
+		reminder := ScheduledReminder{}
+		if json.Unmarshal(entry.Value(), &reminder) == nil {
+			status.Scheduled = append(status.Scheduled, reminder)
+		}
+	}
+
+	return status, nil
+}
@@ -0,0 +1,144 @@
+// Package clients provides leader election between bot instances using a JetStream KV bucket.
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/nats-io/nuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// leaderTTL is how long leadership lasts without being renewed, after which another instance can take over.
+	leaderTTL = 15 * time.Second
+	// leaderRenewInterval is how often the leader renews leadership, and followers campaign for it.
+	leaderRenewInterval = 5 * time.Second
+	// leaderKey is the key in the leader bucket holding the current leader's instance ID.
+	leaderKey = "leader"
+)
+
+// leaderElection holds this instance's leadership of singleton jobs, such as firing scheduled reminders.
+type leaderElection struct {
+	instanceID string
+	revision   uint64
+	leader     bool
+}
+
+// newInstanceID returns a unique ID for this bot instance, for identifying the leader in logs and the leader bucket.
+func (mqClient *MQClient) newInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-shard-%d-%s", hostname, mqClient.ShardID, nuid.Next())
+}
+
+// IsLeader reports whether this instance is the leader, which runs singleton jobs.
+func (mqClient *MQClient) IsLeader() bool {
+	return mqClient.leader.Load()
+}
+
+// RunLeaderElection campaigns for leadership and renews it until stopElection receives, then resigns.
+// Only one instance across all shards is the leader at a time. If the leader stops renewing, another instance takes over after leaderTTL.
+func (mqClient *MQClient) RunLeaderElection(stopElection chan bool) {
+	election := &leaderElection{instanceID: mqClient.newInstanceID()}
+	ticker := time.NewTicker(leaderRenewInterval)
+	defer ticker.Stop()
+
+	for {
+		err := mqClient.campaign(election)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "leader election failed").Error())
+		}
+
+		select {
+		case <-stopElection:
+			mqClient.resign(election)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// leaderBucket returns the leader KV bucket on the publisher connection.
+func (mqClient *MQClient) leaderBucket(ctx context.Context) (jetstream.KeyValue, error) {
+	js, err := mqClient.publisherStream()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error getting NATS publisher")
+	}
+
+	bucket := fmt.Sprintf("%s_leader", mqClient.StreamName)
+	kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      bucket,
+		Description: "the instance running singleton jobs",
+		TTL:         leaderTTL,
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error creating key value bucket: %s", bucket)
+	}
+	return kv, nil
+}
+
+// campaign renews leadership if this instance is the leader, and otherwise tries to become the leader.
+func (mqClient *MQClient) campaign(election *leaderElection) error {
+	ctx, cancel := context.WithTimeout(context.Background(), leaderRenewInterval)
+	defer cancel()
+
+	kv, err := mqClient.leaderBucket(ctx)
+	if err != nil {
+		mqClient.setLeader(election, false)
+		return stacktrace.Propagate(err, "error getting leader bucket")
+	}
+
+	if election.leader {
+		election.revision, err = kv.Update(ctx, leaderKey, []byte(election.instanceID), election.revision)
+		if err != nil {
+			mqClient.setLeader(election, false)
+			return stacktrace.Propagate(err, "lost leadership")
+		}
+		return nil
+	}
+
+	election.revision, err = kv.Create(ctx, leaderKey, []byte(election.instanceID))
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return nil
+	}
+	if err != nil {
+		return stacktrace.Propagate(err, "error campaigning for leadership")
+	}
+	mqClient.setLeader(election, true)
+	return nil
+}
+
+// resign gives up leadership so that another instance can take over without waiting for leaderTTL.
+func (mqClient *MQClient) resign(election *leaderElection) {
+	if !election.leader {
+		return
+	}
+	mqClient.setLeader(election, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), leaderRenewInterval)
+	defer cancel()
+	kv, err := mqClient.leaderBucket(ctx)
+	if err == nil {
+		err = kv.Delete(ctx, leaderKey, jetstream.LastRevision(election.revision))
+	}
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error resigning leadership").Error())
+	}
+}
+
+// setLeader records whether this instance is the leader, logging changes in leadership.
+func (mqClient *MQClient) setLeader(election *leaderElection, leader bool) {
+	if election.leader != leader {
+		slog.Info(fmt.Sprintf("instance %s leader: %t", election.instanceID, leader))
+	}
+	election.leader = leader
+	mqClient.leader.Store(leader)
+}
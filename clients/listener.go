@@ -3,128 +3,363 @@ package clients
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/getsentry/sentry-go"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/metrics"
+	"github.com/dragonejt/hakase-discord/tracing"
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/palantir/stacktrace"
 )
 
-// ListenToStream starts a JetStream consumer and listens for messages, dispatching them to handlers.
+const (
+	// listenerBackoffMin is the delay before the first restart of a failed consumer.
+	listenerBackoffMin = time.Second
+	// listenerBackoffMax caps the delay between consumer restarts. A consumer that ran for longer resets the backoff.
+	listenerBackoffMax = time.Minute
+)
+
+// ConsumerState is the state of the supervised JetStream consumer, reported by ConsumerReady.
+type ConsumerState int32
+
+// A consumer is starting while it connects and creates the stream and consumer, consuming once subscribed,
+// disconnected while NATS reconnects, and restarting while it waits to retry after a failure.
+const (
+	ConsumerStopped ConsumerState = iota
+	ConsumerStarting
+	ConsumerConsuming
+	ConsumerDisconnected
+	ConsumerRestarting
+)
+
+// String returns the lowercase name of the state.
+func (state ConsumerState) String() string {
+	switch state {
+	case ConsumerStopped:
+		return "stopped"
+	case ConsumerStarting:
+		return "starting"
+	case ConsumerConsuming:
+		return "consuming"
+	case ConsumerDisconnected:
+		return "disconnected"
+	case ConsumerRestarting:
+		return "restarting"
+	default:
+		return fmt.Sprintf("unknown (%d)", int32(state))
+	}
+}
+
+// listenerBackoff returns the delay before restarting the consumer after attempt consecutive failures.
+func listenerBackoff(attempt int) time.Duration {
+	delay := listenerBackoffMin
+	for range attempt {
+		delay *= 2
+		if delay >= listenerBackoffMax {
+			return listenerBackoffMax
+		}
+	}
+	return delay
+}
+
+// ListenToStream supervises the JetStream consumer until stopListener receives, dispatching messages to handlers.
+// If connecting, creating the stream or consumer, or consuming fails, the consumer is restarted with exponential backoff.
 func (mqClient *MQClient) ListenToStream(bot *discordgo.Session, hakaseClient BackendClient, stopListener chan bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopListener:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	defer mqClient.consumerState.Store(int32(ConsumerStopped))
+
+	attempt := 0
+	for {
+		mqClient.consumerState.Store(int32(ConsumerStarting))
+		started := time.Now()
+		err := mqClient.listen(ctx, bot, hakaseClient)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(started) > listenerBackoffMax {
+			attempt = 0
+		}
+		delay := listenerBackoff(attempt)
+		attempt++
+		mqClient.consumerState.Store(int32(ConsumerRestarting))
+		slog.Error(stacktrace.Propagate(err, "jetstream consumer for stream %s failed, restarting in %s", mqClient.StreamName, delay).Error())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// listen connects to NATS, creates the stream and durable consumer, and consumes messages until ctx is done,
+// returning nil, or until the connection closes or the consumer is lost, returning the error.
+// Temporary disconnects are handled by NATS reconnects without restarting the consumer.
+func (mqClient *MQClient) listen(ctx context.Context, bot *discordgo.Session, hakaseClient BackendClient) error {
+	failed := make(chan error, 1)
+	fail := func(err error) {
+		select {
+		case failed <- err:
+		default:
+		}
+	}
+
 	slog.Info(fmt.Sprintf("opening NATS consumer connection to: %s", mqClient.NATSUrl))
-	connection, err := nats.Connect(mqClient.NATSUrl)
+	connection, err := nats.Connect(mqClient.NATSUrl,
+		nats.Name("hakase-discord consumer"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			slog.Warn(fmt.Sprintf("NATS consumer connection disconnected: %v", err))
+			mqClient.consumerState.CompareAndSwap(int32(ConsumerConsuming), int32(ConsumerDisconnected))
+		}),
+		nats.ReconnectHandler(func(connection *nats.Conn) {
+			slog.Info(fmt.Sprintf("NATS consumer connection reconnected to: %s", connection.ConnectedUrlRedacted()))
+			mqClient.consumerState.CompareAndSwap(int32(ConsumerDisconnected), int32(ConsumerConsuming))
+		}),
+		nats.ClosedHandler(func(_ *nats.Conn) {
+			fail(stacktrace.NewError("NATS consumer connection closed"))
+		}),
+	)
 	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error connecting to NATS: %s", mqClient.NATSUrl).Error())
-		return
+		return stacktrace.Propagate(err, "error connecting to NATS: %s", mqClient.NATSUrl)
 	}
 	defer func() {
 		slog.Info("draining NATS consumer connection")
 		err := connection.Drain()
 		if err != nil {
 			slog.Error(stacktrace.Propagate(err, "error draining NATS connection").Error())
-			return
 		}
 	}()
 
 	slog.Debug("opening jetstream consumer connection")
 	js, err := jetstream.New(connection)
 	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error opening jetstream publisher connection").Error())
-		return
+		return stacktrace.Propagate(err, "error opening jetstream consumer connection")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	slog.Debug(fmt.Sprintf("creating stream with name: %s", mqClient.StreamName))
-	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
-		Name:     mqClient.StreamName,
-		Subjects: []string{fmt.Sprintf("%s.*", mqClient.StreamName)},
-	})
+	consumer, err := mqClient.createConsumer(ctx, js)
 	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error creating stream with name: %s", mqClient.StreamName).Error())
-		return
+		return stacktrace.Propagate(err, "error creating consumer for stream: %s", mqClient.StreamName)
 	}
-
-	consumer, err := js.CreateOrUpdateConsumer(ctx, mqClient.StreamName, jetstream.ConsumerConfig{
-		Name:      mqClient.StreamName,
-		Durable:   mqClient.StreamName,
-		AckPolicy: jetstream.AckExplicitPolicy,
-	})
+	deliveries, err := mqClient.createDeliveryLog(ctx, js)
 	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error creating consumer for stream: %s", mqClient.StreamName).Error())
-		return
+		return stacktrace.Propagate(err, "error creating delivery log for stream: %s", mqClient.StreamName)
 	}
 
 	subscription, err := consumer.Consume(func(message jetstream.Msg) {
-		consumeMessage(bot, hakaseClient, message)
-	})
+		mqClient.consumeMessage(bot, hakaseClient, deliveries, message)
+	}, jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+		slog.Warn(stacktrace.Propagate(err, "jetstream consumer error for stream: %s", mqClient.StreamName).Error())
+		// the stream or consumer was deleted, such as after NATS restarted without persistence, so it must be recreated
+		if errors.Is(err, jetstream.ErrConsumerDeleted) || errors.Is(err, jetstream.ErrConsumerNotFound) || errors.Is(err, jetstream.ErrStreamNotFound) {
+			fail(err)
+		}
+	}))
 	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error subscribing to stream: %s", mqClient.StreamName).Error())
-		return
+		return stacktrace.Propagate(err, "error subscribing to stream: %s", mqClient.StreamName)
 	}
 	defer subscription.Drain()
 
-	<-stopListener
+	mqClient.consumerState.Store(int32(ConsumerConsuming))
+	slog.Info(fmt.Sprintf("consuming messages from stream: %s", mqClient.StreamName))
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-failed:
+		return err
+	}
+}
+
+// createConsumer creates or updates the notifications stream and this shard's durable consumer.
+func (mqClient *MQClient) createConsumer(ctx context.Context, js jetstream.JetStream) (jetstream.Consumer, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	slog.Debug(fmt.Sprintf("creating stream with name: %s", mqClient.StreamName))
+	_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       mqClient.StreamName,
+		Subjects:   []string{fmt.Sprintf("%s.>", mqClient.StreamName)},
+		Duplicates: mqClient.DuplicateWindow,
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error creating stream with name: %s", mqClient.StreamName)
+	}
 
+	consumer, err := js.CreateOrUpdateConsumer(ctx, mqClient.StreamName, jetstream.ConsumerConfig{
+		Name:           mqClient.consumerName(),
+		Durable:        mqClient.consumerName(),
+		AckPolicy:      jetstream.AckExplicitPolicy,
+		FilterSubjects: mqClient.consumerSubjects(),
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error creating consumer %s for stream: %s", mqClient.consumerName(), mqClient.StreamName)
+	}
+	return consumer, nil
 }
 
 // consumeMessage dispatches messages based on their subject to the appropriate handler.
-func consumeMessage(bot *discordgo.Session, hakaseClient BackendClient, message jetstream.Msg) {
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), DiscordSession{}, bot), "consumeMessage")
-	defer transaction.Finish()
+func (mqClient *MQClient) consumeMessage(bot *discordgo.Session, hakaseClient BackendClient, deliveries *deliveryLog, message jetstream.Msg) {
+	// continue the trace of the interaction or job that published the message
+	ctx := extractMessageHeaders(WithSession(context.Background(), bot), natsHeaderCarrier(message.Headers()))
+	ctx, span := tracing.Start(ctx, "consumeMessage", OriginFrom(ctx).Attributes()...)
+	defer span.End()
 	slog.Info(fmt.Sprintf("received message: %s with subject: %s", string(message.Data()), message.Subject()))
 
-	if message.Subject() == "notifications" {
-		consumeNotification(transaction, hakaseClient, message)
-	} else if message.Subject() == "assignments" {
-		consumeAssignmentNotification(transaction, hakaseClient, message)
-	} else {
-		slog.Error(fmt.Sprintf("unknown message subject: %s", message.Subject()))
-		err := message.Ack()
+	metadata, err := message.Metadata()
+	if err == nil && metadata.NumDelivered > 1 {
+		metrics.Redeliveries.Inc()
+	}
+
+	envelope, err := DecodeEnvelope(messageKind(message.Subject()), message.Data())
+	if err != nil {
+		// invalid messages would never succeed, so they are terminated instead of redelivered
+		tracing.Error(ctx, err)
+		slog.Error(stacktrace.Propagate(err, "invalid message with subject: %s", message.Subject()).Error())
+		err = message.TermWithReason("invalid message")
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to terminate message with subject: %s", message.Subject()).Error())
+		}
+		return
+	}
+
+	switch envelope.Type {
+	case NotificationMessage:
+		consumeNotification(ctx, hakaseClient, envelope, message)
+	case AssignmentReminderMessage:
+		mqClient.consumeAssignmentNotification(ctx, hakaseClient, deliveries, envelope, message)
+	case AnnouncementMessage:
+		consumeAnnouncement(ctx, hakaseClient, deliveries, envelope, message)
+	case PollCloseMessage:
+		consumePollClose(ctx, hakaseClient, deliveries, envelope, message)
+	case FlashcardReminderMessage:
+		mqClient.consumeFlashcardReminder(ctx, hakaseClient, deliveries, envelope, message)
+	default:
+		slog.Error(fmt.Sprintf("no handler for message type: %s", envelope.Type))
+		err = message.Ack()
 		if err != nil {
 			slog.Error(stacktrace.Propagate(err, "failed to ACK message with subject: %s", message.Subject()).Error())
 		}
 	}
+}
 
+// consumerRetryDelay is how long a message is delayed before it is redelivered after a transient error, such as the backend being unavailable.
+const consumerRetryDelay = time.Minute
+
+// terminateMessage terminates a message that would never succeed, such as one whose payload cannot be decoded, instead of redelivering it.
+func terminateMessage(message jetstream.Msg, reason string) {
+	err := message.TermWithReason(reason)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to terminate message with subject: %s", message.Subject()).Error())
+	}
+}
+
+// retryMessage redelivers a message after delay, for errors that may not happen on a later attempt.
+func retryMessage(message jetstream.Msg, delay time.Duration) {
+	err := message.NakWithDelay(delay)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to NAK message with subject: %s", message.Subject()).Error())
+	}
 }
 
 // consumeNotification handles notification messages received from JetStream.
-func consumeNotification(span *sentry.Span, _ BackendClient, message jetstream.Msg) {
-	span = span.StartChild("consumeNotification")
-	defer span.Finish()
+func consumeNotification(ctx context.Context, _ BackendClient, envelope Envelope, message jetstream.Msg) {
+	ctx, span := tracing.Start(ctx, "consumeNotification")
+	defer span.End()
 
-	slog.Info(fmt.Sprintf("received notification with message: %s", string(message.Data())))
+	notification := Notification{}
+	err := envelope.Decode(&notification)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error decoding notification").Error())
+		terminateMessage(message, "invalid notification")
+		return
+	}
+	slog.Info(fmt.Sprintf("received notification with message: %s", notification.Message))
+	err = message.Ack()
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to ACK notification").Error())
+	}
 }
 
 // consumeAssignmentNotification handles assignment notification messages received from JetStream.
-func consumeAssignmentNotification(span *sentry.Span, hakaseClient BackendClient, message jetstream.Msg) {
-	span = span.StartChild("consumeAssignmentNotification")
-	defer span.Finish()
-	bot := span.GetTransaction().Context().Value(DiscordSession{}).(*discordgo.Session)
+// Delivered reminders are recorded before the message is acknowledged, so a redelivered reminder is acknowledged without posting it again.
+// Reminders that are not yet due, such as those published before the scheduler delayed them with NAKs, are moved to the schedule.
+// Reminders for a due date that has since changed or passed are dropped.
+func (mqClient *MQClient) consumeAssignmentNotification(ctx context.Context, hakaseClient BackendClient, deliveries *deliveryLog, envelope Envelope, message jetstream.Msg) {
+	ctx, span := tracing.Start(ctx, "consumeAssignmentNotification")
+	defer span.End()
+	bot := Session(ctx)
 
 	assignmentNotification := AssignmentNotification{}
-	err := json.Unmarshal(message.Data(), &assignmentNotification)
+	err := envelope.Decode(&assignmentNotification)
 	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error unmarshalling assignment notification").Error())
+		slog.Error(stacktrace.Propagate(err, "error decoding assignment notification").Error())
+		terminateMessage(message, "invalid assignment notification")
 		return
 	}
 
-	assignment, err := hakaseClient.ReadAssignment(span, fmt.Sprint(assignmentNotification.AssignmentID))
+	messageID := assignmentNotification.MessageID()
+	delivered, err := deliveries.Delivered(ctx, messageID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to check delivery of assignment notification: %s", messageID).Error())
+		retryMessage(message, consumerRetryDelay)
+		return
+	}
+	if delivered {
+		slog.Info(fmt.Sprintf("assignment notification already delivered: %s", messageID))
+		err = message.Ack()
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to ACK assignment notification: %s", messageID).Error())
+		}
+		return
+	}
+
+	assignment, err := hakaseClient.ReadAssignment(ctx, fmt.Sprint(assignmentNotification.AssignmentID))
+	if IsNotFound(err) {
+		// the assignment was deleted after its reminders were scheduled
+		terminateMessage(message, "assignment not found")
+		return
+	}
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "failed to get assignment with ID: %d", assignmentNotification.AssignmentID).Error())
+		retryMessage(message, consumerRetryDelay)
+		return
+	}
+
+	// reminders for an earlier due date are replaced by those scheduled when the due date changed,
+	// and reminders are not posted once the assignment is due
+	stale := !assignmentNotification.Due.IsZero() && !assignmentNotification.Due.Equal(assignment.Due)
+	if stale || time.Now().After(assignment.Due) {
+		slog.Info(fmt.Sprintf("dropping assignment notification for a past due date: %s", messageID))
+		err = message.Ack()
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to ACK assignment notification: %s", messageID).Error())
+		}
 		return
 	}
 
-	course, err := hakaseClient.ReadCourse(span, assignmentNotification.CourseID)
+	course, err := hakaseClient.ReadCourse(ctx, assignmentNotification.CourseID)
+	if IsNotFound(err) {
+		terminateMessage(message, "course not found")
+		return
+	}
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "failed to get course with courseID: %s", assignmentNotification.CourseID).Error())
+		retryMessage(message, consumerRetryDelay)
 		return
 	}
 
@@ -133,6 +368,7 @@ func consumeAssignmentNotification(span *sentry.Span, hakaseClient BackendClient
 		guild, err := bot.Guild(course.CourseID)
 		if err != nil {
 			slog.Error(stacktrace.Propagate(err, "unable to get guild system channel for notifications").Error())
+			retryMessage(message, consumerRetryDelay)
 			return
 		}
 		notificationsChannel = guild.SystemChannelID
@@ -140,17 +376,228 @@ func consumeAssignmentNotification(span *sentry.Span, hakaseClient BackendClient
 
 	notificationTime := assignment.Due.Add(-1 * assignmentNotification.Before)
 	if time.Now().After(notificationTime) {
-		_, err := bot.ChannelMessageSend(notificationsChannel, fmt.Sprintf("**[assignment notification]** assignment: %s is due in %s hours!", assignment.Name, assignmentNotification.Before/time.Hour))
+		locale := locales.Resolve(course.Locale)
+		content := locale.T("reminder.assignment", assignment.Name, locale.Duration(assignmentNotification.Before))
+		if assignment.IsExam() {
+			content = locale.T("reminder.exam", assignment.Name, locale.Duration(assignmentNotification.Before))
+		}
+		_, err := bot.ChannelMessageSend(notificationsChannel, content)
 		if err != nil {
+			metrics.RemindersFailed.Inc()
 			slog.Error(stacktrace.Propagate(err, "failed to send assignment notification for %d", assignment.ID).Error())
 			// retry sending assignment notification in 15 minutes
 			_ = message.NakWithDelay(15 * time.Minute)
+		} else {
+			metrics.RemindersSent.Inc()
+			err = deliveries.Record(ctx, messageID)
+			if err != nil {
+				slog.Error(stacktrace.Propagate(err, "failed to record delivery of assignment notification: %s", messageID).Error())
+			}
+			err = message.Ack()
+			if err != nil {
+				slog.Error(stacktrace.Propagate(err, "failed to ACK assignment notification: %s", messageID).Error())
+			}
 		}
 	} else {
-		err := message.NakWithDelay(time.Until(notificationTime))
+		assignmentNotification.Due = assignment.Due
+		err := mqClient.ScheduleAssignmentNotifications(ctx, []AssignmentNotification{assignmentNotification})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to schedule assignment notification for %d", assignment.ID).Error())
+			// retry scheduling assignment notification in 15 minutes
+			_ = message.NakWithDelay(15 * time.Minute)
+			return
+		}
+		err = message.Ack()
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to ACK assignment notification: %s", messageID).Error())
+		}
+	}
+}
+
+// consumeAnnouncement posts scheduled announcements received from JetStream when they are due.
+// Posted announcements are recorded before the message is acknowledged, so a redelivered announcement is acknowledged without posting it again.
+func consumeAnnouncement(ctx context.Context, hakaseClient BackendClient, deliveries *deliveryLog, envelope Envelope, message jetstream.Msg) {
+	ctx, span := tracing.Start(ctx, "consumeAnnouncement")
+	defer span.End()
+
+	notification := AnnouncementNotification{}
+	err := envelope.Decode(&notification)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error decoding announcement").Error())
+		terminateMessage(message, "invalid announcement")
+		return
+	}
+
+	messageID := notification.MessageID()
+	delivered, err := deliveries.Delivered(ctx, messageID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to check delivery of announcement: %s", messageID).Error())
+		retryMessage(message, consumerRetryDelay)
+		return
+	}
+
+	announcement := Announcement{}
+	if !delivered {
+		announcement, err = hakaseClient.ReadAnnouncement(ctx, fmt.Sprint(notification.AnnouncementID))
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to get announcement with ID: %d", notification.AnnouncementID).Error())
+			retryMessage(message, consumerRetryDelay)
+			return
+		}
+	}
+	// announcements posted immediately have a message ID, as do those posted before a failure to record their delivery
+	if delivered || announcement.MessageID != "" {
+		slog.Info(fmt.Sprintf("announcement already posted: %s", messageID))
+		err = message.Ack()
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to ACK announcement: %s", messageID).Error())
+		}
+		return
+	}
+
+	_, err = PostAnnouncement(ctx, hakaseClient, announcement)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to post announcement: %d", announcement.ID).Error())
+		// retry posting the announcement in 15 minutes
+		retryMessage(message, 15*time.Minute)
+		return
+	}
+	err = deliveries.Record(ctx, messageID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to record delivery of announcement: %s", messageID).Error())
+	}
+	err = message.Ack()
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to ACK announcement: %s", messageID).Error())
+	}
+}
+
+// consumePollClose closes polls received from JetStream when they are due to close.
+// Polls closed early by staff are acknowledged without closing them again.
+func consumePollClose(ctx context.Context, hakaseClient BackendClient, deliveries *deliveryLog, envelope Envelope, message jetstream.Msg) {
+	ctx, span := tracing.Start(ctx, "consumePollClose")
+	defer span.End()
+
+	notification := PollCloseNotification{}
+	err := envelope.Decode(&notification)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error decoding poll closing").Error())
+		terminateMessage(message, "invalid poll closing")
+		return
+	}
+
+	messageID := notification.MessageID()
+	delivered, err := deliveries.Delivered(ctx, messageID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to check delivery of poll closing: %s", messageID).Error())
+		retryMessage(message, consumerRetryDelay)
+		return
+	}
+
+	poll := Poll{}
+	if !delivered {
+		poll, err = hakaseClient.ReadPoll(ctx, fmt.Sprint(notification.PollID))
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to get poll with ID: %d", notification.PollID).Error())
+			retryMessage(message, consumerRetryDelay)
+			return
+		}
+	}
+	if delivered || poll.Closed() {
+		slog.Info(fmt.Sprintf("poll already closed: %s", messageID))
+		err = message.Ack()
 		if err != nil {
-			_, _ = bot.ChannelMessageSend(notificationsChannel, fmt.Sprintf("**[assignment notification error]** failed to schedule assignment notifications for assignment: %s", assignment.Name))
-			slog.Error(stacktrace.Propagate(err, "failed to schedule assignment notifications for %d", assignment.ID).Error())
+			slog.Error(stacktrace.Propagate(err, "failed to ACK poll closing: %s", messageID).Error())
 		}
+		return
+	}
+
+	_, err = ClosePoll(ctx, hakaseClient, poll)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to close poll: %d", poll.ID).Error())
+		// retry closing the poll in a minute, since it no longer accepts votes
+		retryMessage(message, consumerRetryDelay)
+		return
+	}
+	err = deliveries.Record(ctx, messageID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to record delivery of poll closing: %s", messageID).Error())
+	}
+	err = message.Ack()
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to ACK poll closing: %s", messageID).Error())
+	}
+}
+
+// consumeFlashcardReminder DMs a student how many of their flashcards are due in a course, and reminds them again the next day
+// while cards are still due. Students who already reviewed their due cards are not reminded, and their next review schedules their next reminder.
+func (mqClient *MQClient) consumeFlashcardReminder(ctx context.Context, hakaseClient BackendClient, deliveries *deliveryLog, envelope Envelope, message jetstream.Msg) {
+	ctx, span := tracing.Start(ctx, "consumeFlashcardReminder")
+	defer span.End()
+	bot := Session(ctx)
+
+	notification := FlashcardReminderNotification{}
+	err := envelope.Decode(&notification)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error decoding flashcard reminder").Error())
+		terminateMessage(message, "invalid flashcard reminder")
+		return
+	}
+
+	messageID := notification.MessageID()
+	delivered, err := deliveries.Delivered(ctx, messageID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to check delivery of flashcard reminder: %s", messageID).Error())
+		retryMessage(message, consumerRetryDelay)
+		return
+	}
+
+	due := 0
+	if !delivered {
+		reviews, err := hakaseClient.ListFlashcardReviews(ctx, notification.CourseID, notification.UserID)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to list flashcard reviews of %s in course: %s", notification.UserID, notification.CourseID).Error())
+			retryMessage(message, consumerRetryDelay)
+			return
+		}
+		due = DueFlashcards(reviews, time.Now())
+	}
+	if delivered || due == 0 {
+		slog.Info(fmt.Sprintf("no flashcard reminder needed: %s", messageID))
+		err = message.Ack()
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to ACK flashcard reminder: %s", messageID).Error())
+		}
+		return
+	}
+
+	courseName := notification.CourseID
+	guild, err := bot.State.Guild(notification.CourseID)
+	if err == nil {
+		courseName = guild.Name
+	}
+	dmChannel, err := bot.UserChannelCreate(notification.UserID)
+	if err == nil {
+		_, err = bot.ChannelMessageSend(dmChannel.ID, courseDefaultLocale(ctx, hakaseClient, notification.CourseID).T("flashcards.reminder", due, courseName))
+	}
+	if err != nil {
+		// students who do not accept DMs would never be reminded, so the reminder is not retried
+		slog.Warn(stacktrace.Propagate(err, "failed to DM flashcard reminder to: %s", notification.UserID).Error())
+	}
+
+	next := notification
+	next.RemindAt = notification.RemindAt.AddDate(0, 0, 1)
+	err = mqClient.ScheduleFlashcardReminder(ctx, next)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to schedule next flashcard reminder: %s", next.MessageID()).Error())
+	}
+
+	err = deliveries.Record(ctx, messageID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to record delivery of flashcard reminder: %s", messageID).Error())
+	}
+	err = message.Ack()
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to ACK flashcard reminder: %s", messageID).Error())
 	}
 }
@@ -0,0 +1,123 @@
+// Package clients provides the NATS message headers that carry trace context and the interaction that caused a message.
+package clients
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	schemaVersionHeader = "hakase-schema-version"
+	guildIDHeader       = "hakase-guild-id"
+	userIDHeader        = "hakase-user-id"
+	interactionIDHeader = "hakase-interaction-id"
+)
+
+// Origin identifies the guild, user and interaction that caused a message to be published, such as adding an assignment.
+type Origin struct {
+	GuildID       string
+	UserID        string
+	InteractionID string
+}
+
+// originKey is the context key for the Origin.
+type originKey struct{}
+
+// WithOrigin returns a copy of ctx carrying interaction as the origin of messages published with it.
+func WithOrigin(ctx context.Context, interaction *discordgo.Interaction) context.Context {
+	origin := Origin{GuildID: interaction.GuildID, InteractionID: interaction.ID}
+	if interaction.Member != nil && interaction.Member.User != nil {
+		origin.UserID = interaction.Member.User.ID
+	} else if interaction.User != nil {
+		origin.UserID = interaction.User.ID
+	}
+	return context.WithValue(ctx, originKey{}, origin)
+}
+
+// OriginFrom returns the Origin carried by ctx, which is empty if messages were not caused by an interaction.
+func OriginFrom(ctx context.Context) Origin {
+	origin, _ := ctx.Value(originKey{}).(Origin)
+	return origin
+}
+
+// Attributes returns the origin as span attributes, omitting unknown IDs.
+func (origin Origin) Attributes() []attribute.KeyValue {
+	attributes := []attribute.KeyValue{}
+	if origin.GuildID != "" {
+		attributes = append(attributes, attribute.String("discord.guild_id", origin.GuildID))
+	}
+	if origin.UserID != "" {
+		attributes = append(attributes, attribute.String("discord.user_id", origin.UserID))
+	}
+	if origin.InteractionID != "" {
+		attributes = append(attributes, attribute.String("discord.interaction_id", origin.InteractionID))
+	}
+	return attributes
+}
+
+// natsHeaderCarrier adapts NATS message headers for trace propagation.
+// NATS headers are case sensitive, so keys are matched case insensitively to accept headers from any producer.
+type natsHeaderCarrier nats.Header
+
+// Get returns the first value of the header key.
+func (carrier natsHeaderCarrier) Get(key string) string {
+	if values := carrier[key]; len(values) > 0 {
+		return values[0]
+	}
+	for name, values := range carrier {
+		if strings.EqualFold(name, key) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// Set replaces the values of the header key with value.
+func (carrier natsHeaderCarrier) Set(key string, value string) {
+	carrier[key] = []string{value}
+}
+
+// Keys returns the header names.
+func (carrier natsHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(carrier))
+	for key := range carrier {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// injectMessageHeaders writes the schema version, the trace context in ctx, and its origin to the headers in carrier.
+func injectMessageHeaders(ctx context.Context, carrier propagation.TextMapCarrier) {
+	carrier.Set(schemaVersionHeader, strconv.Itoa(MessageVersion))
+	tracing.Inject(ctx, carrier)
+
+	origin := OriginFrom(ctx)
+	headers := map[string]string{guildIDHeader: origin.GuildID, userIDHeader: origin.UserID, interactionIDHeader: origin.InteractionID}
+	for header, value := range headers {
+		if value != "" {
+			carrier.Set(header, value)
+		}
+	}
+}
+
+// extractMessageHeaders returns ctx continuing the trace in the headers in carrier, and carrying their origin if any.
+func extractMessageHeaders(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	ctx = tracing.Extract(ctx, carrier)
+
+	origin := Origin{
+		GuildID:       carrier.Get(guildIDHeader),
+		UserID:        carrier.Get(userIDHeader),
+		InteractionID: carrier.Get(interactionIDHeader),
+	}
+	if origin == (Origin{}) {
+		return ctx
+	}
+	return context.WithValue(ctx, originKey{}, origin)
+}
@@ -0,0 +1,217 @@
+// Package clients implements backend API operations for office hours, and their queue statistics.
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/tracing"
+)
+
+// DefaultHelpTime is the estimated time staff spend helping each student, until a session has helped enough students to measure it.
+const DefaultHelpTime = 10 * time.Minute
+
+// OfficeHours is an office hours session, with a queue of students waiting for staff in a voice channel.
+type OfficeHours struct {
+	ID       int    `json:"id,omitempty"`
+	CourseID string `json:"course_id"`
+	HostID   string `json:"host_id"`
+	// ChannelID and MessageID locate the queue message, which is edited as the queue changes.
+	ChannelID      string    `json:"channel_id"`
+	MessageID      string    `json:"message_id,omitempty"`
+	VoiceChannelID string    `json:"voice_channel_id"`
+	OpenedAt       time.Time `json:"opened_at"`
+	// ClosedAt is zero while the session is open.
+	ClosedAt time.Time `json:"closed_at,omitzero"`
+	// Queue is every student who joined the queue, in the order they joined, including those already helped or who left.
+	Queue []QueueEntry `json:"queue,omitempty"`
+}
+
+// QueueEntry is a student in an office hours queue.
+type QueueEntry struct {
+	UserID   string    `json:"user_id"`
+	Topic    string    `json:"topic"`
+	JoinedAt time.Time `json:"joined_at"`
+	// HelpedAt and HelpedBy are set when staff pull the student from the queue.
+	HelpedAt time.Time `json:"helped_at,omitzero"`
+	HelpedBy string    `json:"helped_by,omitempty"`
+	// LeftAt is set when the student leaves the queue without being helped.
+	LeftAt time.Time `json:"left_at,omitzero"`
+}
+
+// Waiting reports whether the student is still waiting in the queue.
+func (entry QueueEntry) Waiting() bool {
+	return entry.HelpedAt.IsZero() && entry.LeftAt.IsZero()
+}
+
+// Open reports whether the session is open.
+func (officeHours OfficeHours) Open() bool {
+	return officeHours.ClosedAt.IsZero()
+}
+
+// OpenOfficeHours returns the open session among sessions, and whether there is one.
+func OpenOfficeHours(sessions []OfficeHours) (OfficeHours, bool) {
+	for _, session := range sessions {
+		if session.Open() {
+			return session, true
+		}
+	}
+	return OfficeHours{}, false
+}
+
+// Waiting returns the students waiting in the queue, first in line first.
+func (officeHours OfficeHours) Waiting() []QueueEntry {
+	waiting := []QueueEntry{}
+	for _, entry := range officeHours.Queue {
+		if entry.Waiting() {
+			waiting = append(waiting, entry)
+		}
+	}
+	return waiting
+}
+
+// Position returns the 1-based position of a user waiting in the queue, or 0 if they are not waiting.
+func (officeHours OfficeHours) Position(userID string) int {
+	return slices.IndexFunc(officeHours.Waiting(), func(entry QueueEntry) bool { return entry.UserID == userID }) + 1
+}
+
+// HelpTime returns the average time between staff pulling consecutive students from the queue,
+// or DefaultHelpTime if fewer than two students have been helped.
+func (officeHours OfficeHours) HelpTime() time.Duration {
+	helped := []time.Time{}
+	for _, entry := range officeHours.Queue {
+		if !entry.HelpedAt.IsZero() {
+			helped = append(helped, entry.HelpedAt)
+		}
+	}
+	if len(helped) < 2 {
+		return DefaultHelpTime
+	}
+	slices.SortFunc(helped, func(a time.Time, b time.Time) int { return a.Compare(b) })
+	return helped[len(helped)-1].Sub(helped[0]) / time.Duration(len(helped)-1)
+}
+
+// EstimatedWait returns the estimated wait for the student at a 1-based position in the queue.
+// Staff are assumed to be helping a student, so the first student in line waits for one help time.
+func (officeHours OfficeHours) EstimatedWait(position int) time.Duration {
+	return time.Duration(position) * officeHours.HelpTime()
+}
+
+// OfficeHoursStatistics summarizes the office hours sessions of a course for staff.
+type OfficeHoursStatistics struct {
+	Sessions int
+	// Duration is the total time sessions were open. Open sessions count until now.
+	Duration time.Duration
+	Helped   int
+	// Unhelped counts students who left the queue or were still waiting when their session closed.
+	Unhelped    int
+	AverageWait time.Duration
+	// HelpedBy counts the students each staff member helped, by user ID.
+	HelpedBy map[string]int
+}
+
+// SummarizeOfficeHours summarizes office hours sessions, with waits measured from joining the queue to being helped.
+func SummarizeOfficeHours(sessions []OfficeHours, now time.Time) OfficeHoursStatistics {
+	statistics := OfficeHoursStatistics{Sessions: len(sessions), HelpedBy: map[string]int{}}
+	totalWait := time.Duration(0)
+	for _, session := range sessions {
+		closedAt := session.ClosedAt
+		if session.Open() {
+			closedAt = now
+		}
+		statistics.Duration += closedAt.Sub(session.OpenedAt)
+
+		for _, entry := range session.Queue {
+			if entry.HelpedAt.IsZero() {
+				if !session.Open() || !entry.LeftAt.IsZero() {
+					statistics.Unhelped++
+				}
+				continue
+			}
+			statistics.Helped++
+			statistics.HelpedBy[entry.HelpedBy]++
+			totalWait += entry.HelpedAt.Sub(entry.JoinedAt)
+		}
+	}
+	if statistics.Helped > 0 {
+		statistics.AverageWait = totalWait / time.Duration(statistics.Helped)
+	}
+	return statistics
+}
+
+// officeHoursQueueRequest is the request body joining an office hours queue or pulling the next student from it.
+type officeHoursQueueRequest struct {
+	OfficeHoursID string `json:"office_hours_id"`
+	UserID        string `json:"user_id,omitempty"`
+	Topic         string `json:"topic,omitempty"`
+	HelperID      string `json:"helper_id,omitempty"`
+}
+
+// ReadOfficeHours retrieves an office hours session by its ID from the backend, with its queue.
+func (backend *APIClient) ReadOfficeHours(ctx context.Context, officeHoursID string) (OfficeHours, error) {
+	ctx, span := tracing.Start(ctx, "readOfficeHours")
+	defer span.End()
+
+	officeHours := OfficeHours{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("officehours?id=%s", officeHoursID), nil, http.StatusOK, &officeHours)
+	return officeHours, err
+}
+
+// ListOfficeHours lists all office hours sessions for a course, with their queues.
+func (backend *APIClient) ListOfficeHours(ctx context.Context, courseID string) ([]OfficeHours, error) {
+	ctx, span := tracing.Start(ctx, "listOfficeHours")
+	defer span.End()
+
+	sessions := []OfficeHours{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("officehours?course_id=%s", courseID), nil, http.StatusOK, &sessions)
+	return sessions, err
+}
+
+// CreateOfficeHours creates a new office hours session in the backend.
+func (backend *APIClient) CreateOfficeHours(ctx context.Context, officeHours OfficeHours) (OfficeHours, error) {
+	ctx, span := tracing.Start(ctx, "createOfficeHours")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPost, "officehours", officeHours, http.StatusCreated, &officeHours)
+	return officeHours, err
+}
+
+// UpdateOfficeHours updates an existing office hours session in the backend, such as to close it. The queue is not updated.
+func (backend *APIClient) UpdateOfficeHours(ctx context.Context, officeHours OfficeHours) (OfficeHours, error) {
+	ctx, span := tracing.Start(ctx, "updateOfficeHours")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPut, "officehours", officeHours, http.StatusAccepted, &officeHours)
+	return officeHours, err
+}
+
+// JoinOfficeHoursQueue adds a student to the end of an office hours queue with their topic.
+func (backend *APIClient) JoinOfficeHoursQueue(ctx context.Context, officeHoursID string, userID string, topic string) error {
+	ctx, span := tracing.Start(ctx, "joinOfficeHoursQueue")
+	defer span.End()
+
+	body := officeHoursQueueRequest{OfficeHoursID: officeHoursID, UserID: userID, Topic: topic}
+	return backend.jsonRequest(ctx, http.MethodPost, "officehours/queue", body, http.StatusCreated, nil)
+}
+
+// LeaveOfficeHoursQueue removes a waiting student from an office hours queue.
+func (backend *APIClient) LeaveOfficeHoursQueue(ctx context.Context, officeHoursID string, userID string) error {
+	ctx, span := tracing.Start(ctx, "leaveOfficeHoursQueue")
+	defer span.End()
+
+	return backend.jsonRequest(ctx, http.MethodDelete, fmt.Sprintf("officehours/queue?office_hours_id=%s&user_id=%s", officeHoursID, userID), nil, http.StatusNoContent, nil)
+}
+
+// NextInOfficeHoursQueue pulls the first waiting student from an office hours queue for the helper, returning their queue entry.
+func (backend *APIClient) NextInOfficeHoursQueue(ctx context.Context, officeHoursID string, helperID string) (QueueEntry, error) {
+	ctx, span := tracing.Start(ctx, "nextInOfficeHoursQueue")
+	defer span.End()
+
+	entry := QueueEntry{}
+	body := officeHoursQueueRequest{OfficeHoursID: officeHoursID, HelperID: helperID}
+	err := backend.jsonRequest(ctx, http.MethodPost, "officehours/next", body, http.StatusOK, &entry)
+	return entry, err
+}
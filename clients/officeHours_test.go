@@ -0,0 +1,88 @@
+package clients_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/stretchr/testify/suite"
+)
+
+type OfficeHoursTestSuite struct {
+	suite.Suite
+	openedAt    time.Time
+	officeHours clients.OfficeHours
+}
+
+func TestOfficeHours(t *testing.T) {
+	suite.Run(t, new(OfficeHoursTestSuite))
+}
+
+func (testSuite *OfficeHoursTestSuite) SetupTest() {
+	testSuite.openedAt = time.Date(2025, 1, 30, 14, 0, 0, 0, time.UTC)
+	testSuite.officeHours = clients.OfficeHours{
+		ID:       1,
+		CourseID: "course",
+		HostID:   "host",
+		OpenedAt: testSuite.openedAt,
+		Queue: []clients.QueueEntry{
+			{UserID: "helped1", JoinedAt: testSuite.openedAt, HelpedAt: testSuite.openedAt.Add(5 * time.Minute), HelpedBy: "ta1"},
+			{UserID: "helped2", JoinedAt: testSuite.openedAt, HelpedAt: testSuite.openedAt.Add(25 * time.Minute), HelpedBy: "ta2"},
+			{UserID: "left", JoinedAt: testSuite.openedAt, LeftAt: testSuite.openedAt.Add(10 * time.Minute)},
+			{UserID: "waiting1", JoinedAt: testSuite.openedAt.Add(20 * time.Minute)},
+			{UserID: "waiting2", JoinedAt: testSuite.openedAt.Add(30 * time.Minute)},
+		},
+	}
+}
+
+func (testSuite *OfficeHoursTestSuite) TestWaiting() {
+	waiting := testSuite.officeHours.Waiting()
+	testSuite.Len(waiting, 2)
+	testSuite.Equal("waiting1", waiting[0].UserID)
+	testSuite.Equal("waiting2", waiting[1].UserID)
+}
+
+func (testSuite *OfficeHoursTestSuite) TestPosition() {
+	testSuite.Equal(1, testSuite.officeHours.Position("waiting1"))
+	testSuite.Equal(2, testSuite.officeHours.Position("waiting2"))
+	testSuite.Equal(0, testSuite.officeHours.Position("helped1"))
+	testSuite.Equal(0, testSuite.officeHours.Position("left"))
+	testSuite.Equal(0, testSuite.officeHours.Position("unknown"))
+}
+
+func (testSuite *OfficeHoursTestSuite) TestHelpTime() {
+	testSuite.Equal(20*time.Minute, testSuite.officeHours.HelpTime())
+	testSuite.Equal(40*time.Minute, testSuite.officeHours.EstimatedWait(2))
+
+	testSuite.officeHours.Queue = testSuite.officeHours.Queue[1:]
+	testSuite.Equal(clients.DefaultHelpTime, testSuite.officeHours.HelpTime())
+}
+
+func (testSuite *OfficeHoursTestSuite) TestOpenOfficeHours() {
+	closed := testSuite.officeHours
+	closed.ID = 2
+	closed.ClosedAt = testSuite.openedAt.Add(time.Hour)
+
+	open, exists := clients.OpenOfficeHours([]clients.OfficeHours{closed, testSuite.officeHours})
+	testSuite.True(exists)
+	testSuite.Equal(1, open.ID)
+
+	_, exists = clients.OpenOfficeHours([]clients.OfficeHours{closed})
+	testSuite.False(exists)
+}
+
+func (testSuite *OfficeHoursTestSuite) TestSummarizeOfficeHours() {
+	now := testSuite.openedAt.Add(time.Hour)
+	statistics := clients.SummarizeOfficeHours([]clients.OfficeHours{testSuite.officeHours}, now)
+	testSuite.Equal(1, statistics.Sessions)
+	testSuite.Equal(time.Hour, statistics.Duration)
+	testSuite.Equal(2, statistics.Helped)
+	testSuite.Equal(1, statistics.Unhelped)
+	testSuite.Equal(15*time.Minute, statistics.AverageWait)
+	testSuite.Equal(map[string]int{"ta1": 1, "ta2": 1}, statistics.HelpedBy)
+
+	testSuite.officeHours.ClosedAt = testSuite.openedAt.Add(30 * time.Minute)
+	statistics = clients.SummarizeOfficeHours([]clients.OfficeHours{testSuite.officeHours}, now)
+	testSuite.Equal(30*time.Minute, statistics.Duration)
+	testSuite.Equal(3, statistics.Unhelped)
+}
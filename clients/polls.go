@@ -0,0 +1,326 @@
+// Package clients implements backend API operations for polls, and posting and closing polls in Discord.
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/palantir/stacktrace"
+)
+
+// MaxPollOptions is the most options a poll can have.
+const MaxPollOptions = 10
+
+// pollBarWidth is the number of blocks in a poll option's result bar.
+const pollBarWidth = 10
+
+// Poll is a poll posted by staff, with single or multiple choice options, that closes automatically if ClosesAt is set.
+type Poll struct {
+	ID        int    `json:"id,omitempty"`
+	CourseID  string `json:"course_id"`
+	AuthorID  string `json:"author_id"`
+	ChannelID string `json:"channel_id"`
+	// MessageID is the ID of the Discord message the poll was posted as, empty until it is posted.
+	MessageID      string   `json:"message_id,omitempty"`
+	Question       string   `json:"question"`
+	Options        []string `json:"options"`
+	MultipleChoice bool     `json:"multiple_choice"`
+	// Anonymous polls never show who voted, even to staff. Voters are still stored, so that each member votes once.
+	Anonymous bool       `json:"anonymous"`
+	CreatedAt time.Time  `json:"created_at"`
+	ClosesAt  time.Time  `json:"closes_at,omitzero"`
+	ClosedAt  time.Time  `json:"closed_at,omitzero"`
+	Votes     []PollVote `json:"votes,omitempty"`
+}
+
+// PollVote is a member's vote in a poll, with the indices of the options they chose.
+type PollVote struct {
+	UserID  string    `json:"user_id"`
+	Options []int     `json:"options"`
+	VotedAt time.Time `json:"voted_at"`
+}
+
+// pollVoteRequest is the request body voting in a poll. The backend replaces any earlier vote by the member.
+type pollVoteRequest struct {
+	PollID  string `json:"poll_id"`
+	UserID  string `json:"user_id"`
+	Options []int  `json:"options"`
+}
+
+// Closed reports whether the poll was closed.
+func (poll Poll) Closed() bool {
+	return !poll.ClosedAt.IsZero()
+}
+
+// Accepting reports whether the poll accepts votes at now. A poll past its closing time stops accepting votes before it is closed.
+func (poll Poll) Accepting(now time.Time) bool {
+	return !poll.Closed() && (poll.ClosesAt.IsZero() || now.Before(poll.ClosesAt))
+}
+
+// Results returns the number of votes for each option. Votes for options the poll does not have are ignored.
+func (poll Poll) Results() []int {
+	results := make([]int, len(poll.Options))
+	for _, vote := range poll.Votes {
+		for _, option := range vote.Options {
+			if option >= 0 && option < len(results) {
+				results[option]++
+			}
+		}
+	}
+	return results
+}
+
+// Percent returns the percentage of voters who chose an option with count votes, rounded to the nearest percent.
+func (poll Poll) Percent(count int) int {
+	if len(poll.Votes) == 0 {
+		return 0
+	}
+	return int(math.Round(float64(count) * 100 / float64(len(poll.Votes))))
+}
+
+// ResultsCSV returns the poll's results as CSV, with a row for each option.
+// The voters for each option are listed by user ID unless the poll is anonymous.
+func (poll Poll) ResultsCSV() ([]byte, error) {
+	voters := make([][]string, len(poll.Options))
+	for _, vote := range poll.Votes {
+		for _, option := range vote.Options {
+			if option >= 0 && option < len(voters) {
+				voters[option] = append(voters[option], vote.UserID)
+			}
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+	_ = writer.Write([]string{"option", "votes", "percent", "voters"})
+	for index, count := range poll.Results() {
+		optionVoters := ""
+		if !poll.Anonymous {
+			optionVoters = strings.Join(voters[index], " ")
+		}
+		_ = writer.Write([]string{poll.Options[index], strconv.Itoa(count), strconv.Itoa(poll.Percent(count)), optionVoters})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, stacktrace.Propagate(err, "failed to write poll results: %d", poll.ID)
+	}
+	return buffer.Bytes(), nil
+}
+
+// ReadPoll retrieves a poll by its ID from the backend, with its votes.
+func (backend *APIClient) ReadPoll(ctx context.Context, pollID string) (Poll, error) {
+	ctx, span := tracing.Start(ctx, "readPoll")
+	defer span.End()
+
+	poll := Poll{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("polls?id=%s", pollID), nil, http.StatusOK, &poll)
+	return poll, err
+}
+
+// CreatePoll creates a new poll in the backend.
+func (backend *APIClient) CreatePoll(ctx context.Context, poll Poll) (Poll, error) {
+	ctx, span := tracing.Start(ctx, "createPoll")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPost, "polls", poll, http.StatusCreated, &poll)
+	return poll, err
+}
+
+// UpdatePoll updates an existing poll in the backend, such as to close it. Its votes are not updated.
+func (backend *APIClient) UpdatePoll(ctx context.Context, poll Poll) (Poll, error) {
+	ctx, span := tracing.Start(ctx, "updatePoll")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPut, "polls", poll, http.StatusAccepted, &poll)
+	return poll, err
+}
+
+// VotePoll records a member's vote in a poll, replacing their earlier vote.
+func (backend *APIClient) VotePoll(ctx context.Context, pollID string, userID string, options []int) error {
+	ctx, span := tracing.Start(ctx, "votePoll")
+	defer span.End()
+
+	body := pollVoteRequest{PollID: pollID, UserID: userID, Options: options}
+	return backend.jsonRequest(ctx, http.MethodPost, "polls/votes", body, http.StatusCreated, nil)
+}
+
+// pollBar returns a bar showing the share of voters who chose an option.
+func pollBar(percent int) string {
+	filled := (percent*pollBarWidth + 50) / 100
+	return strings.Repeat("█", filled) + strings.Repeat("░", pollBarWidth-filled)
+}
+
+// pollMessage returns the embed and components of a poll's message: a result bar for each option,
+// a menu to vote while the poll is open, and buttons for staff to close it and export its results.
+func pollMessage(locale locales.Locale, poll Poll) ([]*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	lines := make([]string, len(poll.Options))
+	for index, count := range poll.Results() {
+		percent := poll.Percent(count)
+		lines[index] = locale.T("poll.option", index+1, poll.Options[index], pollBar(percent), percent, count)
+	}
+
+	choice, mode := locale.T("poll.single"), locale.T("poll.named")
+	if poll.MultipleChoice {
+		choice = locale.T("poll.multiple")
+	}
+	if poll.Anonymous {
+		mode = locale.T("poll.anonymous")
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       poll.Question,
+		Description: strings.Join(lines, "\n\n"),
+		Fields:      []*discordgo.MessageEmbedField{},
+		Footer:      &discordgo.MessageEmbedFooter{Text: locale.T("poll.footer", len(poll.Votes), choice, mode)},
+		Timestamp:   poll.CreatedAt.Format(time.RFC3339),
+	}
+	if poll.Closed() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: locale.T("poll.closed"), Value: fmt.Sprintf("<t:%d:f>", poll.ClosedAt.Unix())})
+	} else if !poll.ClosesAt.IsZero() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: locale.T("poll.closes"), Value: fmt.Sprintf("<t:%d:R>", poll.ClosesAt.Unix())})
+	}
+
+	exportButton := discordgo.Button{
+		Emoji:    &discordgo.ComponentEmoji{Name: "📄"},
+		Label:    locale.T("button.export_poll"),
+		Style:    discordgo.SecondaryButton,
+		CustomID: fmt.Sprintf("exportPoll_%d", poll.ID),
+	}
+	if poll.Closed() {
+		return []*discordgo.MessageEmbed{embed}, []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{exportButton}},
+		}
+	}
+
+	options := make([]discordgo.SelectMenuOption, len(poll.Options))
+	for index, option := range poll.Options {
+		options[index] = discordgo.SelectMenuOption{Label: option, Value: strconv.Itoa(index)}
+	}
+	placeholder, maxValues := locale.T("poll.vote_single"), 1
+	if poll.MultipleChoice {
+		placeholder, maxValues = locale.T("poll.vote_multiple"), len(options)
+	}
+	minValues := 1
+
+	return []*discordgo.MessageEmbed{embed}, []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					MenuType:    discordgo.StringSelectMenu,
+					CustomID:    fmt.Sprintf("votePoll_%d", poll.ID),
+					Placeholder: placeholder,
+					MinValues:   &minValues,
+					MaxValues:   maxValues,
+					Options:     options,
+				},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Emoji:    &discordgo.ComponentEmoji{Name: "🔒"},
+					Label:    locale.T("button.close_poll"),
+					Style:    discordgo.DangerButton,
+					CustomID: fmt.Sprintf("closePoll_%d", poll.ID),
+				},
+				exportButton,
+			},
+		},
+	}
+}
+
+// courseDefaultLocale returns the course's default locale, which polls and reminders are sent in since they are not replies to a member.
+func courseDefaultLocale(ctx context.Context, backend BackendClient, courseID string) locales.Locale {
+	course, err := backend.ReadCourse(ctx, courseID)
+	if err != nil {
+		slog.Warn(stacktrace.Propagate(err, "error reading course locale: %s", courseID).Error())
+		return locales.Default
+	}
+	return locales.Resolve(course.Locale)
+}
+
+// PostPoll posts a poll to its channel in the course's default locale, and records the message it was posted as.
+// An error is returned only if the poll was not posted.
+func PostPoll(ctx context.Context, backend BackendClient, poll Poll) (Poll, error) {
+	ctx, span := tracing.Start(ctx, "postPoll")
+	defer span.End()
+
+	embeds, components := pollMessage(courseDefaultLocale(ctx, backend, poll.CourseID), poll)
+	message, err := Session(ctx).ChannelMessageSendComplex(poll.ChannelID, &discordgo.MessageSend{
+		Embeds:          embeds,
+		Components:      components,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return poll, stacktrace.Propagate(err, "failed to post poll %d to channel: %s", poll.ID, poll.ChannelID)
+	}
+
+	poll.MessageID = message.ID
+	_, err = backend.UpdatePoll(ctx, poll)
+	if err != nil {
+		// the poll works without its message ID, but its results are not updated as members vote
+		slog.Error(stacktrace.Propagate(err, "failed to record message of poll: %d", poll.ID).Error())
+	}
+	return poll, nil
+}
+
+// RefreshPoll reads a poll and edits its message to show its current results.
+// The poll is returned even if editing its message fails.
+func RefreshPoll(ctx context.Context, backend BackendClient, pollID string) (Poll, error) {
+	ctx, span := tracing.Start(ctx, "refreshPoll")
+	defer span.End()
+
+	poll, err := backend.ReadPoll(ctx, pollID)
+	if err != nil {
+		return poll, stacktrace.Propagate(err, "error reading poll: %s", pollID)
+	}
+	if poll.MessageID == "" {
+		return poll, nil
+	}
+
+	embeds, components := pollMessage(courseDefaultLocale(ctx, backend, poll.CourseID), poll)
+	_, err = Session(ctx).ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:              poll.MessageID,
+		Channel:         poll.ChannelID,
+		Embeds:          &embeds,
+		Components:      &components,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return poll, stacktrace.Propagate(err, "error editing poll message: %s", poll.MessageID)
+	}
+	return poll, nil
+}
+
+// ClosePoll closes a poll and edits its message to show its final results.
+// An error is returned only if the poll was not closed.
+func ClosePoll(ctx context.Context, backend BackendClient, poll Poll) (Poll, error) {
+	ctx, span := tracing.Start(ctx, "closePoll")
+	defer span.End()
+
+	poll.ClosedAt = time.Now().Truncate(time.Second)
+	_, err := backend.UpdatePoll(ctx, poll)
+	if err != nil {
+		return poll, stacktrace.Propagate(err, "failed to close poll: %d", poll.ID)
+	}
+
+	refreshed, err := RefreshPoll(ctx, backend, fmt.Sprint(poll.ID))
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to show final results of poll: %d", poll.ID).Error())
+	}
+	if refreshed.ID != 0 {
+		poll = refreshed
+	}
+	return poll, nil
+}
@@ -0,0 +1,66 @@
+package clients_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/stretchr/testify/suite"
+)
+
+type PollTestSuite struct {
+	suite.Suite
+	createdAt time.Time
+	poll      clients.Poll
+}
+
+func TestPoll(t *testing.T) {
+	suite.Run(t, new(PollTestSuite))
+}
+
+func (testSuite *PollTestSuite) SetupTest() {
+	testSuite.createdAt = time.Date(2025, 1, 30, 14, 0, 0, 0, time.UTC)
+	testSuite.poll = clients.Poll{
+		ID:             1,
+		CourseID:       "course",
+		Question:       "which topics should we review?",
+		Options:        []string{"recursion", "pointers", "graphs, trees"},
+		MultipleChoice: true,
+		CreatedAt:      testSuite.createdAt,
+		ClosesAt:       testSuite.createdAt.Add(time.Hour),
+		Votes: []clients.PollVote{
+			{UserID: "student1", Options: []int{0, 2}},
+			{UserID: "student2", Options: []int{2}},
+			{UserID: "student3", Options: []int{2, 5}},
+		},
+	}
+}
+
+func (testSuite *PollTestSuite) TestResults() {
+	testSuite.Equal([]int{1, 0, 3}, testSuite.poll.Results())
+	testSuite.Equal(33, testSuite.poll.Percent(1))
+	testSuite.Equal(100, testSuite.poll.Percent(3))
+	testSuite.Equal(0, clients.Poll{Options: []string{"yes", "no"}}.Percent(0))
+}
+
+func (testSuite *PollTestSuite) TestAccepting() {
+	testSuite.True(testSuite.poll.Accepting(testSuite.createdAt.Add(30 * time.Minute)))
+	// polls past their closing time stop accepting votes before the scheduler closes them
+	testSuite.False(testSuite.poll.Accepting(testSuite.createdAt.Add(time.Hour)))
+
+	testSuite.poll.ClosedAt = testSuite.createdAt.Add(10 * time.Minute)
+	testSuite.False(testSuite.poll.Accepting(testSuite.createdAt.Add(30 * time.Minute)))
+
+	testSuite.True(clients.Poll{}.Accepting(testSuite.createdAt))
+}
+
+func (testSuite *PollTestSuite) TestResultsCSV() {
+	results, err := testSuite.poll.ResultsCSV()
+	testSuite.Require().NoError(err)
+	testSuite.Equal("option,votes,percent,voters\nrecursion,1,33,student1\npointers,0,0,\n\"graphs, trees\",3,100,student1 student2 student3\n", string(results))
+
+	testSuite.poll.Anonymous = true
+	results, err = testSuite.poll.ResultsCSV()
+	testSuite.Require().NoError(err)
+	testSuite.Equal("option,votes,percent,voters\nrecursion,1,33,\npointers,0,0,\n\"graphs, trees\",3,100,\n", string(results))
+}
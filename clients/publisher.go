@@ -3,93 +3,154 @@ package clients
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
-	"github.com/getsentry/sentry-go"
+	"github.com/dragonejt/hakase-discord/tracing"
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
+	"github.com/nats-io/nuid"
 	"github.com/palantir/stacktrace"
 )
 
-func CreateStreamConnection(NATS_URL string) jetstream.JetStream {
-	slog.Info(fmt.Sprintf("opening NATS publisher connection to: %s", NATS_URL))
-	connection, err := nats.Connect(NATS_URL)
+// outgoingMessage is a message to publish to a stream subject, deduplicated by the stream using its ID.
+type outgoingMessage struct {
+	subject string
+	id      string
+	data    []byte
+}
+
+// publisherStream returns the long-lived publisher connection, connecting it on first use.
+// The connection reconnects indefinitely, buffering publishes while disconnected.
+func (mqClient *MQClient) publisherStream() (jetstream.JetStream, error) {
+	mqClient.publisherLock.Lock()
+	defer mqClient.publisherLock.Unlock()
+	if mqClient.publisher != nil {
+		return mqClient.publisher, nil
+	}
+
+	slog.Info(fmt.Sprintf("opening NATS publisher connection to: %s", mqClient.NATSUrl))
+	connection, err := nats.Connect(mqClient.NATSUrl,
+		nats.Name("hakase-discord publisher"),
+		nats.MaxReconnects(-1),
+		nats.RetryOnFailedConnect(true),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			slog.Warn(fmt.Sprintf("NATS publisher connection disconnected: %v", err))
+		}),
+		nats.ReconnectHandler(func(connection *nats.Conn) {
+			slog.Info(fmt.Sprintf("NATS publisher connection reconnected to: %s", connection.ConnectedUrlRedacted()))
+		}),
+	)
 	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error connecting to NATS: %s", NATS_URL).Error())
-		return nil
+		return nil, stacktrace.Propagate(err, "error connecting to NATS: %s", mqClient.NATSUrl)
 	}
 
 	slog.Debug("opening jetstream publisher connection")
 	js, err := jetstream.New(connection)
 	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error opening jetstream publisher connection").Error())
-		return nil
+		connection.Close()
+		return nil, stacktrace.Propagate(err, "error opening jetstream publisher connection")
 	}
-	return js
+	mqClient.publisher = js
+	return js, nil
 }
 
-func (mqClient *MQClient) publishMessage(span *sentry.Span, subject string, message []byte) error {
-	js := mqClient.PublisherPool.Get().(jetstream.JetStream)
-	defer mqClient.PublisherPool.Put(js)
+// DrainPublisher drains the publisher connection, flushing buffered messages, and waits until it is closed or ctx is done.
+func (mqClient *MQClient) DrainPublisher(ctx context.Context) error {
+	mqClient.publisherLock.Lock()
+	defer mqClient.publisherLock.Unlock()
+	if mqClient.publisher == nil {
+		return nil
+	}
 
-	ctx, cancel := context.WithTimeout(span.Context(), 10*time.Second)
-	defer cancel()
+	connection := mqClient.publisher.Conn()
+	mqClient.publisher = nil
+	slog.Info("draining NATS publisher connection")
+	err := connection.Drain()
+	if err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+		return stacktrace.Propagate(err, "error draining NATS publisher connection")
+	}
+	for !connection.IsClosed() {
+		select {
+		case <-ctx.Done():
+			connection.Close()
+			return stacktrace.Propagate(ctx.Err(), "timed out draining NATS publisher connection")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return nil
+}
 
-	slog.Debug(fmt.Sprintf("publishing message to subject: %s.%s", mqClient.StreamName, subject))
-	_, err := js.Publish(ctx, fmt.Sprintf("%s.%s", mqClient.StreamName, subject), message)
+// publishMessages publishes messages asynchronously and waits for the stream to acknowledge each of them,
+// returning an error joining every message that failed to publish.
+func (mqClient *MQClient) publishMessages(ctx context.Context, messages []outgoingMessage) error {
+	js, err := mqClient.publisherStream()
 	if err != nil {
-		return stacktrace.Propagate(err, "error publishing message to subject: %s.%s", mqClient.StreamName, subject)
+		return stacktrace.Propagate(err, "error getting NATS publisher")
 	}
 
-	return nil
-}
+	ctx, cancel := context.WithTimeout(ctx, mqClient.PublishTimeout)
+	defer cancel()
 
-// PublishNotification publishes a notification message to the notifications subject in JetStream.
-func (mqClient *MQClient) PublishNotification(span *sentry.Span, notification string) {
-	span = span.StartChild("publishNotification")
-	defer span.Finish()
+	errs := []error{}
+	futures := make([]jetstream.PubAckFuture, 0, len(messages))
+	for _, message := range messages {
+		subject := message.subject
+		slog.Debug(fmt.Sprintf("publishing message with ID: %s to subject: %s", message.id, subject))
+		natsMessage := &nats.Msg{Subject: subject, Data: message.data, Header: nats.Header{}}
+		injectMessageHeaders(ctx, natsHeaderCarrier(natsMessage.Header))
+		future, err := js.PublishMsgAsync(natsMessage, jetstream.WithMsgID(message.id))
+		if err != nil {
+			errs = append(errs, stacktrace.Propagate(err, "error publishing message to subject: %s", subject))
+			continue
+		}
+		futures = append(futures, future)
+	}
 
-	err := mqClient.publishMessage(span, "notifications", []byte(notification))
-	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error publishing notification").Error())
-		return
+	for _, future := range futures {
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			errs = append(errs, stacktrace.Propagate(err, "error publishing message to subject: %s", future.Msg().Subject))
+		case <-ctx.Done():
+			errs = append(errs, stacktrace.Propagate(ctx.Err(), "timed out publishing message to subject: %s", future.Msg().Subject))
+		}
 	}
+
+	return errors.Join(errs...)
 }
 
-// PublishAssignmentNotification publishes an assignment notification to the assignments subject in JetStream.
-func (mqClient *MQClient) PublishAssignmentNotification(span *sentry.Span, notification AssignmentNotification) {
-	span = span.StartChild("publishAssignmentNotification")
-	defer span.Finish()
+// PublishNotification publishes a notification message to the notifications subject in JetStream.
+func (mqClient *MQClient) PublishNotification(ctx context.Context, notification string) error {
+	ctx, span := tracing.Start(ctx, "publishNotification")
+	defer span.End()
 
-	message, err := json.Marshal(notification)
+	message, err := newOutgoingMessage(mqClient.shardSubject("notifications", mqClient.ShardID), NotificationMessage, nuid.Next(), Notification{Message: notification})
 	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error marshalling assignment notification").Error())
-		return
+		return stacktrace.Propagate(err, "error creating notification message")
 	}
-	err = mqClient.publishMessage(span, "assignments", message)
+	err = mqClient.publishMessages(ctx, []outgoingMessage{message})
 	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error publishing assignment notification").Error())
-		return
+		return stacktrace.Propagate(err, "error publishing notification")
 	}
+	return nil
 }
 
 // PublishStudySessionNotification publishes a study session notification to the study_sessions subject in JetStream.
-func (mqClient *MQClient) PublishStudySessionNotification(span *sentry.Span, notification StudySessionNotification) {
-	span = span.StartChild("publishStudySessionNotification")
-	defer span.Finish()
+func (mqClient *MQClient) PublishStudySessionNotification(ctx context.Context, notification StudySessionNotification) error {
+	ctx, span := tracing.Start(ctx, "publishStudySessionNotification")
+	defer span.End()
 
-	message, err := json.Marshal(notification)
+	message, err := newOutgoingMessage(mqClient.guildSubject("study_sessions", notification.CourseID), StudySessionMessage, nuid.Next(), notification)
 	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error marshalling study session notification").Error())
-		return
+		return stacktrace.Propagate(err, "error creating study session notification message")
 	}
 
-	err = mqClient.publishMessage(span, "study_sessions", message)
+	err = mqClient.publishMessages(ctx, []outgoingMessage{message})
 	if err != nil {
-		slog.Error(stacktrace.Propagate(err, "error publishing study session notification").Error())
-		return
+		return stacktrace.Propagate(err, "error publishing study session notification")
 	}
+	return nil
 }
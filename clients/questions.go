@@ -0,0 +1,96 @@
+// Package clients implements backend API operations for anonymous questions, and the audit record of who asked them.
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/tracing"
+)
+
+// Question statuses. Questions in courses without review are approved when they are asked.
+const (
+	QuestionPending  = "pending"
+	QuestionApproved = "approved"
+	QuestionRejected = "rejected"
+)
+
+// ErrQuestionNotInCourse is returned when reading who asked a question from another course than the one it was asked in.
+var ErrQuestionNotInCourse = errors.New("question was not asked in this course")
+
+// Question is a question asked anonymously with /ask. It has no asker, which is only kept in its QuestionAudit.
+type Question struct {
+	ID       int    `json:"id,omitempty"`
+	CourseID string `json:"course_id"`
+	Text     string `json:"text"`
+	Status   string `json:"status"`
+	// ReviewedBy is the staff member who approved or rejected the question, empty if it was not reviewed.
+	ReviewedBy string `json:"reviewed_by,omitempty"`
+	// ChannelID, MessageID and ThreadID locate the posted question and the thread answering it, empty until it is posted.
+	ChannelID string    `json:"channel_id,omitempty"`
+	MessageID string    `json:"message_id,omitempty"`
+	ThreadID  string    `json:"thread_id,omitempty"`
+	AskedAt   time.Time `json:"asked_at"`
+}
+
+// QuestionAudit records who asked a question, for staff handling abuse.
+type QuestionAudit struct {
+	QuestionID int       `json:"question_id"`
+	AskerID    string    `json:"asker_id"`
+	AskedAt    time.Time `json:"asked_at"`
+}
+
+// askedQuestion is the request body creating a question. The backend stores the asker in the question's audit record.
+type askedQuestion struct {
+	Question
+	AskerID string `json:"asker_id"`
+}
+
+// ReadQuestion retrieves a question by its ID from the backend.
+func (backend *APIClient) ReadQuestion(ctx context.Context, questionID string) (Question, error) {
+	ctx, span := tracing.Start(ctx, "readQuestion")
+	defer span.End()
+
+	question := Question{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("questions?id=%s", questionID), nil, http.StatusOK, &question)
+	return question, err
+}
+
+// CreateQuestion creates a new question in the backend, recording its asker in the audit store.
+func (backend *APIClient) CreateQuestion(ctx context.Context, question Question, askerID string) (Question, error) {
+	ctx, span := tracing.Start(ctx, "createQuestion")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPost, "questions", askedQuestion{Question: question, AskerID: askerID}, http.StatusCreated, &question)
+	return question, err
+}
+
+// UpdateQuestion updates an existing question in the backend, such as to approve it or record where it was posted.
+func (backend *APIClient) UpdateQuestion(ctx context.Context, question Question) (Question, error) {
+	ctx, span := tracing.Start(ctx, "updateQuestion")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPut, "questions", question, http.StatusAccepted, &question)
+	return question, err
+}
+
+// ReadQuestionAudit retrieves who asked a question in a course from the audit store. The backend logs that the viewer accessed it.
+// Questions asked in other courses return ErrQuestionNotInCourse, so that staff of one course cannot unmask askers in another.
+func (backend *APIClient) ReadQuestionAudit(ctx context.Context, courseID string, questionID string, viewerID string) (QuestionAudit, error) {
+	ctx, span := tracing.Start(ctx, "readQuestionAudit")
+	defer span.End()
+
+	audit := QuestionAudit{}
+	question, err := backend.ReadQuestion(ctx, questionID)
+	if err != nil {
+		return audit, err
+	}
+	if question.CourseID != courseID {
+		return audit, ErrQuestionNotInCourse
+	}
+	err = backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("questions/audit?question_id=%s&course_id=%s&viewer_id=%s", questionID, courseID, viewerID), nil, http.StatusOK, &audit)
+	return audit, err
+}
@@ -0,0 +1,58 @@
+package clients_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/stretchr/testify/suite"
+)
+
+type QuestionsTestSuite struct {
+	suite.Suite
+	server       *httptest.Server
+	backend      *clients.APIClient
+	auditQueries []string
+}
+
+func TestQuestions(t *testing.T) {
+	suite.Run(t, new(QuestionsTestSuite))
+}
+
+func (testSuite *QuestionsTestSuite) SetupTest() {
+	testSuite.auditQueries = []string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /questions", func(writer http.ResponseWriter, request *http.Request) {
+		_ = json.NewEncoder(writer).Encode(clients.Question{ID: 1, CourseID: "course1", Status: clients.QuestionApproved})
+	})
+	mux.HandleFunc("GET /questions/audit", func(writer http.ResponseWriter, request *http.Request) {
+		testSuite.auditQueries = append(testSuite.auditQueries, request.URL.RawQuery)
+		_ = json.NewEncoder(writer).Encode(clients.QuestionAudit{QuestionID: 1, AskerID: "student1"})
+	})
+	testSuite.server = httptest.NewServer(mux)
+	testSuite.backend = &clients.APIClient{Url: testSuite.server.URL, HttpClient: testSuite.server.Client()}
+}
+
+func (testSuite *QuestionsTestSuite) TearDownTest() {
+	testSuite.server.Close()
+}
+
+func (testSuite *QuestionsTestSuite) TestReadQuestionAudit() {
+	audit, err := testSuite.backend.ReadQuestionAudit(context.Background(), "course1", "1", "staff1")
+
+	testSuite.Require().NoError(err)
+	testSuite.Equal("student1", audit.AskerID)
+	testSuite.Equal([]string{"question_id=1&course_id=course1&viewer_id=staff1"}, testSuite.auditQueries)
+}
+
+func (testSuite *QuestionsTestSuite) TestReadQuestionAuditOtherCourse() {
+	audit, err := testSuite.backend.ReadQuestionAudit(context.Background(), "course2", "1", "staff2")
+
+	testSuite.ErrorIs(err, clients.ErrQuestionNotInCourse)
+	testSuite.Empty(audit.AskerID)
+	// the audit store is never asked, so the lookup is not logged against the question
+	testSuite.Empty(testSuite.auditQueries)
+}
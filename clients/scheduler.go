@@ -0,0 +1,359 @@
+// Package clients provides the scheduler, which holds reminders in a JetStream KV bucket until they are due.
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	// SchedulerInterval is how often the scheduler fires due reminders.
+	SchedulerInterval = 30 * time.Second
+	// schedulerLease is how long a claimed reminder is reserved for the instance firing it.
+	// If that instance fails before firing the reminder, another tick claims it once the lease expires.
+	schedulerLease = 2 * time.Minute
+)
+
+// ScheduledReminder is a message stored in the schedule bucket until its fire time: an assignment reminder, announcement,
+// poll closing or flashcard reminder. It holds the message's type, ID and payload as they are published in its envelope,
+// so that scheduling a new kind of message needs only a message type. CourseID routes the message to its guild's shard.
+// Headers holds the trace context and origin of the interaction that scheduled the reminder, so that firing it continues that trace.
+type ScheduledReminder struct {
+	Type       string            `json:"type"`
+	ID         string            `json:"id"`
+	CourseID   string            `json:"course_id"`
+	Payload    json.RawMessage   `json:"payload"`
+	FireAt     time.Time         `json:"fire_at"`
+	LeaseUntil time.Time         `json:"lease_until,omitzero"`
+	Headers    map[string]string `json:"headers,omitempty"`
+}
+
+// scheduledPayload is the payload of a scheduled message, which has a deterministic message ID.
+type scheduledPayload interface {
+	payload
+	MessageID() string
+}
+
+// newScheduledReminder returns a reminder firing notification as a message of messageType to courseID's shard at fireAt.
+func newScheduledReminder(messageType string, courseID string, fireAt time.Time, notification scheduledPayload) (ScheduledReminder, error) {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return ScheduledReminder{}, stacktrace.Propagate(err, "error marshalling %s payload", messageType)
+	}
+	return ScheduledReminder{Type: messageType, ID: notification.MessageID(), CourseID: courseID, Payload: data, FireAt: fireAt}, nil
+}
+
+// legacyScheduledReminder is the legacy JSON encoding of a ScheduledReminder, with Go field names and a field for each kind of message.
+type legacyScheduledReminder struct {
+	Notification      AssignmentNotification
+	Announcement      *AnnouncementNotification
+	PollClose         *PollCloseNotification
+	FlashcardReminder *FlashcardReminderNotification
+	FireAt            time.Time
+	LeaseUntil        time.Time
+	Headers           map[string]string
+}
+
+// UnmarshalJSON decodes the reminder from its JSON encoding or its legacy encoding, so that reminders scheduled by older versions still fire.
+func (reminder *ScheduledReminder) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+
+	if _, legacy := fields["Notification"]; !legacy {
+		// the alias has the same fields and tags without this method, so decoding it does not recurse
+		type scheduledReminder ScheduledReminder
+		return json.Unmarshal(data, (*scheduledReminder)(reminder))
+	}
+
+	decoded := legacyScheduledReminder{}
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+	switch {
+	case decoded.Announcement != nil:
+		*reminder, err = newScheduledReminder(AnnouncementMessage, decoded.Announcement.CourseID, decoded.FireAt, decoded.Announcement)
+	case decoded.PollClose != nil:
+		*reminder, err = newScheduledReminder(PollCloseMessage, decoded.PollClose.CourseID, decoded.FireAt, decoded.PollClose)
+	case decoded.FlashcardReminder != nil:
+		*reminder, err = newScheduledReminder(FlashcardReminderMessage, decoded.FlashcardReminder.CourseID, decoded.FireAt, decoded.FlashcardReminder)
+	default:
+		*reminder, err = newScheduledReminder(AssignmentReminderMessage, decoded.Notification.CourseID, decoded.FireAt, decoded.Notification)
+	}
+	reminder.LeaseUntil = decoded.LeaseUntil
+	reminder.Headers = decoded.Headers
+	return err
+}
+
+// scheduleKey returns the schedule bucket key for a reminder, prefixed by its zero padded fire time so that keys sort by fire time.
+func scheduleKey(reminder ScheduledReminder) string {
+	return fmt.Sprintf("%012d.%s", reminder.FireAt.Unix(), reminder.ID)
+}
+
+// scheduleKeyTime returns the fire time encoded in a schedule bucket key.
+func scheduleKeyTime(key string) (time.Time, error) {
+	prefix, _, _ := strings.Cut(key, ".")
+	seconds, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil {
+		return time.Time{}, stacktrace.Propagate(err, "invalid schedule key: %s", key)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// scheduleBucket returns the schedule KV bucket on the publisher connection, creating it on first use.
+func (mqClient *MQClient) scheduleBucket(ctx context.Context) (jetstream.KeyValue, error) {
+	js, err := mqClient.publisherStream()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error getting NATS publisher")
+	}
+
+	mqClient.publisherLock.Lock()
+	defer mqClient.publisherLock.Unlock()
+	if mqClient.schedule != nil {
+		return mqClient.schedule, nil
+	}
+
+	bucket := fmt.Sprintf("%s_schedule", mqClient.StreamName)
+	kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      bucket,
+		Description: "reminders waiting for their fire time, keyed by fire time",
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error creating key value bucket: %s", bucket)
+	}
+	mqClient.schedule = kv
+	return kv, nil
+}
+
+// ScheduleAssignmentNotifications stores assignment notifications in the schedule bucket, to be published when they are due.
+// Scheduling a reminder that is already scheduled has no effect.
+func (mqClient *MQClient) ScheduleAssignmentNotifications(ctx context.Context, notifications []AssignmentNotification) error {
+	ctx, span := tracing.Start(ctx, "scheduleAssignmentNotifications")
+	defer span.End()
+
+	reminders := make([]ScheduledReminder, len(notifications))
+	for index, notification := range notifications {
+		reminder, err := newScheduledReminder(AssignmentReminderMessage, notification.CourseID, notification.Due.Add(-1*notification.Before), notification)
+		if err != nil {
+			return stacktrace.Propagate(err, "error scheduling assignment reminder")
+		}
+		reminders[index] = reminder
+	}
+	return mqClient.scheduleReminders(ctx, reminders)
+}
+
+// ScheduleAnnouncement stores an announcement in the schedule bucket, to be published when it is scheduled for.
+// Scheduling an announcement that is already scheduled has no effect.
+func (mqClient *MQClient) ScheduleAnnouncement(ctx context.Context, notification AnnouncementNotification) error {
+	ctx, span := tracing.Start(ctx, "scheduleAnnouncement")
+	defer span.End()
+
+	reminder, err := newScheduledReminder(AnnouncementMessage, notification.CourseID, notification.ScheduledFor, notification)
+	if err != nil {
+		return stacktrace.Propagate(err, "error scheduling AnnouncementMessage")
+	}
+	return mqClient.scheduleReminders(ctx, []ScheduledReminder{reminder})
+}
+
+// SchedulePollClose stores a poll's closing time in the schedule bucket, to be published when the poll is due to close.
+// Scheduling a poll closing that is already scheduled has no effect.
+func (mqClient *MQClient) SchedulePollClose(ctx context.Context, notification PollCloseNotification) error {
+	ctx, span := tracing.Start(ctx, "schedulePollClose")
+	defer span.End()
+
+	reminder, err := newScheduledReminder(PollCloseMessage, notification.CourseID, notification.ClosesAt, notification)
+	if err != nil {
+		return stacktrace.Propagate(err, "error scheduling PollCloseMessage")
+	}
+	return mqClient.scheduleReminders(ctx, []ScheduledReminder{reminder})
+}
+
+// ScheduleFlashcardReminder stores a student's flashcard reminder in the schedule bucket, to be published when it is due.
+// Only the first reminder scheduled for a student on a day is kept.
+func (mqClient *MQClient) ScheduleFlashcardReminder(ctx context.Context, notification FlashcardReminderNotification) error {
+	ctx, span := tracing.Start(ctx, "scheduleFlashcardReminder")
+	defer span.End()
+
+	reminder, err := newScheduledReminder(FlashcardReminderMessage, notification.CourseID, notification.RemindAt, notification)
+	if err != nil {
+		return stacktrace.Propagate(err, "error scheduling FlashcardReminderMessage")
+	}
+	return mqClient.scheduleReminders(ctx, []ScheduledReminder{reminder})
+}
+
+// scheduleReminders stores reminders in the schedule bucket with the trace context and origin of ctx.
+func (mqClient *MQClient) scheduleReminders(ctx context.Context, reminders []ScheduledReminder) error {
+	ctx, cancel := context.WithTimeout(ctx, mqClient.PublishTimeout)
+	defer cancel()
+
+	kv, err := mqClient.scheduleBucket(ctx)
+	if err != nil {
+		return stacktrace.Propagate(err, "error getting schedule bucket")
+	}
+
+	errs := []error{}
+	for _, reminder := range reminders {
+		reminder.Headers = map[string]string{}
+		injectMessageHeaders(ctx, propagation.MapCarrier(reminder.Headers))
+		value, err := json.Marshal(reminder)
+		if err != nil {
+			return stacktrace.Propagate(err, "error marshalling scheduled reminder")
+		}
+
+		key := scheduleKey(reminder)
+		_, err = kv.Create(ctx, key, value)
+		if err != nil && !errors.Is(err, jetstream.ErrKeyExists) {
+			errs = append(errs, stacktrace.Propagate(err, "error scheduling reminder: %s", key))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RunScheduler fires due reminders every SchedulerInterval while this instance is the leader, until stopScheduler receives.
+func (mqClient *MQClient) RunScheduler(stopScheduler chan bool) {
+	ticker := time.NewTicker(SchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopScheduler:
+			return
+		case <-ticker.C:
+			if !mqClient.IsLeader() {
+				continue
+			}
+			ctx, span := tracing.Start(context.Background(), "scheduler")
+			err := mqClient.FireDueReminders(ctx, time.Now())
+			if err != nil {
+				slog.Error(stacktrace.Propagate(err, "failed to fire due reminders").Error())
+			}
+			span.End()
+		}
+	}
+}
+
+// FireDueReminders claims every scheduled reminder due at now, publishes it to the assignments subject, and removes it from the schedule.
+// Reminders are claimed with a lease so that concurrent schedulers do not fire the same reminder.
+func (mqClient *MQClient) FireDueReminders(ctx context.Context, now time.Time) error {
+	ctx, span := tracing.Start(ctx, "fireDueReminders")
+	defer span.End()
+
+	kv, err := mqClient.scheduleBucket(ctx)
+	if err != nil {
+		return stacktrace.Propagate(err, "error getting schedule bucket")
+	}
+
+	keys, err := kv.Keys(ctx)
+	if errors.Is(err, jetstream.ErrNoKeysFound) {
+		return nil
+	}
+	if err != nil {
+		return stacktrace.Propagate(err, "error listing scheduled reminders")
+	}
+
+	errs := []error{}
+	for _, key := range keys {
+		fireAt, err := scheduleKeyTime(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if fireAt.After(now) {
+			continue
+		}
+
+		err = mqClient.fireReminder(ctx, kv, key, now)
+		if err != nil {
+			errs = append(errs, stacktrace.Propagate(err, "error firing reminder: %s", key))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// fireReminder claims the scheduled reminder at key, publishes it, and deletes it. A reminder leased by another scheduler is skipped.
+func (mqClient *MQClient) fireReminder(ctx context.Context, kv jetstream.KeyValue, key string, now time.Time) error {
+	entry, err := kv.Get(ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		// fired by another scheduler
+		return nil
+	}
+	if err != nil {
+		return stacktrace.Propagate(err, "error reading scheduled reminder")
+	}
+
+	reminder := ScheduledReminder{}
+	err = json.Unmarshal(entry.Value(), &reminder)
+	if err != nil {
+		return stacktrace.Propagate(err, "error unmarshalling scheduled reminder")
+	}
+	if reminder.LeaseUntil.After(now) {
+		return nil
+	}
+
+	reminder.LeaseUntil = now.Add(schedulerLease)
+	value, err := json.Marshal(reminder)
+	if err != nil {
+		return stacktrace.Propagate(err, "error marshalling scheduled reminder")
+	}
+	revision, err := kv.Update(ctx, key, value, entry.Revision())
+	if err != nil {
+		// claimed by another scheduler since it was read
+		slog.Debug(stacktrace.Propagate(err, "failed to claim scheduled reminder: %s", key).Error())
+		return nil
+	}
+
+	// continue the trace of the interaction that scheduled the reminder
+	ctx = extractMessageHeaders(ctx, propagation.MapCarrier(reminder.Headers))
+	ctx, span := tracing.Start(ctx, "fireReminder", OriginFrom(ctx).Attributes()...)
+	defer span.End()
+
+	message, err := mqClient.scheduledMessage(reminder)
+	if err != nil {
+		return stacktrace.Propagate(err, "error creating scheduled message: %s", reminder.ID)
+	}
+	err = mqClient.publishMessages(ctx, []outgoingMessage{message})
+	if err != nil {
+		return stacktrace.Propagate(err, "error publishing scheduled message: %s", reminder.ID)
+	}
+
+	err = kv.Delete(ctx, key, jetstream.LastRevision(revision))
+	if err != nil {
+		return stacktrace.Propagate(err, "error deleting fired reminder")
+	}
+	return nil
+}
+
+// scheduledMessage returns the message to publish for a scheduled reminder when it fires, in an envelope of the reminder's type
+// on the subject of that type for its course's shard.
+func (mqClient *MQClient) scheduledMessage(reminder ScheduledReminder) (outgoingMessage, error) {
+	kind, exists := messageTypeKind(reminder.Type)
+	if !exists {
+		return outgoingMessage{}, stacktrace.NewError("unknown scheduled message type: %s", reminder.Type)
+	}
+	envelope := Envelope{Type: reminder.Type, Version: MessageVersion, ID: reminder.ID, CreatedAt: time.Now().UTC(), Payload: reminder.Payload}
+	err := envelope.Validate()
+	if err != nil {
+		return outgoingMessage{}, stacktrace.Propagate(err, "invalid scheduled message")
+	}
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return outgoingMessage{}, stacktrace.Propagate(err, "error marshalling %s envelope", reminder.Type)
+	}
+	return outgoingMessage{subject: mqClient.guildSubject(kind, reminder.CourseID), id: reminder.ID, data: data}, nil
+}
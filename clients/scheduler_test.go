@@ -0,0 +1,46 @@
+package clients_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/stretchr/testify/suite"
+)
+
+type SchedulerTestSuite struct {
+	suite.Suite
+}
+
+func TestScheduler(t *testing.T) {
+	suite.Run(t, new(SchedulerTestSuite))
+}
+
+func (testSuite *SchedulerTestSuite) TestDecodeScheduledReminder() {
+	data := []byte(`{"type":"poll_close","id":"poll-7-1738573200","course_id":"1234567890","payload":{"poll_id":7,"course_id":"1234567890","closes_at":"2025-02-03T09:00:00Z"},"fire_at":"2025-02-03T09:00:00Z"}`)
+
+	reminder := clients.ScheduledReminder{}
+	testSuite.Require().NoError(json.Unmarshal(data, &reminder))
+	testSuite.Equal(clients.PollCloseMessage, reminder.Type)
+	testSuite.Equal("poll-7-1738573200", reminder.ID)
+	testSuite.True(reminder.LeaseUntil.IsZero())
+
+	notification := clients.PollCloseNotification{}
+	testSuite.Require().NoError(json.Unmarshal(reminder.Payload, &notification))
+	testSuite.Equal(7, notification.PollID)
+}
+
+func (testSuite *SchedulerTestSuite) TestDecodeLegacyScheduledReminder() {
+	// reminders scheduled by older versions have a field for each kind of message, and an empty assignment reminder
+	data := []byte(`{"Notification":{"assignment_id":0,"course_id":"","before_seconds":0},"Announcement":{"announcement_id":3,"course_id":"1234567890","scheduled_for":"2025-02-03T09:00:00Z"},"FireAt":"2025-02-03T09:00:00Z","LeaseUntil":"0001-01-01T00:00:00Z","Headers":{"traceparent":"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}}`)
+
+	reminder := clients.ScheduledReminder{}
+	testSuite.Require().NoError(json.Unmarshal(data, &reminder))
+	testSuite.Equal(clients.AnnouncementMessage, reminder.Type)
+	testSuite.Equal("announcement-3-1738573200", reminder.ID)
+	testSuite.Equal("1234567890", reminder.CourseID)
+	testSuite.Equal(time.Date(2025, 2, 3, 9, 0, 0, 0, time.UTC), reminder.FireAt)
+	testSuite.Contains(reminder.Headers, "traceparent")
+	testSuite.JSONEq(`{"announcement_id":3,"course_id":"1234567890","scheduled_for":"2025-02-03T09:00:00Z"}`, string(reminder.Payload))
+}
@@ -0,0 +1,62 @@
+// Package clients provides the mapping of guilds to Discord gateway shards and their stream subjects.
+package clients
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ShardForGuild returns the Discord gateway shard that receives events for a guild, (guild_id >> 22) % shard_count.
+func ShardForGuild(guildID string, shardCount int) int {
+	id, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil || shardCount <= 1 {
+		return 0
+	}
+	return int((id >> 22) % uint64(shardCount))
+}
+
+// shardSubject returns the stream subject for messages of the kind consumed by shard, <stream name>.<subject>.<shard>.
+func (mqClient *MQClient) shardSubject(subject string, shard int) string {
+	return fmt.Sprintf("%s.%s.%d", mqClient.StreamName, subject, shard)
+}
+
+// guildSubject returns the stream subject for messages of the kind consumed by the shard owning the guild.
+func (mqClient *MQClient) guildSubject(subject string, guildID string) string {
+	return mqClient.shardSubject(subject, ShardForGuild(guildID, mqClient.ShardCount))
+}
+
+// messageKind returns the kind of a stream message from its subject, such as assignments for <stream name>.assignments.<shard>.
+func messageKind(subject string) string {
+	tokens := strings.Split(subject, ".")
+	if len(tokens) < 2 {
+		return ""
+	}
+	return tokens[1]
+}
+
+// consumerName returns the name of this shard's durable consumer. Shard 0 keeps the unsharded consumer's name so that it keeps its position in the stream.
+func (mqClient *MQClient) consumerName() string {
+	if mqClient.ShardID == 0 {
+		return mqClient.StreamName
+	}
+	return fmt.Sprintf("%s_shard_%d", mqClient.StreamName, mqClient.ShardID)
+}
+
+// consumerSubjects returns the subjects consumed by this shard. Shard 0 also consumes messages published before sharding, which have no shard token.
+func (mqClient *MQClient) consumerSubjects() []string {
+	subjects := []string{fmt.Sprintf("%s.*.%d", mqClient.StreamName, mqClient.ShardID)}
+	if mqClient.ShardID == 0 {
+		subjects = append(subjects, fmt.Sprintf("%s.*", mqClient.StreamName))
+	}
+	return subjects
+}
+
+// consumesSubject reports whether this shard's consumer consumes messages published to subject.
+func (mqClient *MQClient) consumesSubject(subject string) bool {
+	tokens := strings.Split(subject, ".")
+	if len(tokens) == 2 {
+		return mqClient.ShardID == 0
+	}
+	return len(tokens) == 3 && tokens[2] == strconv.Itoa(mqClient.ShardID)
+}
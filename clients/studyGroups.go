@@ -0,0 +1,252 @@
+// Package clients implements backend API operations for study groups, and matching students into them.
+package clients
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/tracing"
+)
+
+const (
+	// DefaultStudyGroupSize is the size of study groups in courses that have not chosen one.
+	DefaultStudyGroupSize = 4
+	MinStudyGroupSize     = 2
+	MaxStudyGroupSize     = 8
+	// maxStudyGroupTopics is the most topics kept from a student's request, so that one student cannot match on everything.
+	maxStudyGroupTopics = 5
+)
+
+// The ways a course's study groups meet: in a private text and voice channel each, or in a private thread each.
+const (
+	StudyGroupChannels = "channels"
+	StudyGroupThreads  = "threads"
+)
+
+// StudyGroupSlots are the times students can be available to study, in the order they are shown.
+var StudyGroupSlots = []string{"weekday_mornings", "weekday_afternoons", "weekday_evenings", "weekends"}
+
+// StudyGroupRequest is a student waiting to be matched into a study group.
+// The backend removes requests when their students are matched, so a course's requests are the students still waiting.
+type StudyGroupRequest struct {
+	ID       int      `json:"id,omitempty"`
+	CourseID string   `json:"course_id"`
+	UserID   string   `json:"user_id"`
+	Topics   []string `json:"topics"`
+	// Availability is the slots of StudyGroupSlots the student can study in. Students are only matched with others sharing a slot.
+	Availability []string  `json:"availability"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// StudyGroupMember is a student in a study group, with the topics and availability they were matched with, so that they can be matched again.
+type StudyGroupMember struct {
+	UserID       string   `json:"user_id"`
+	Topics       []string `json:"topics"`
+	Availability []string `json:"availability"`
+}
+
+// StudyGroup is a group of students matched to study together, meeting in their own channels or thread.
+type StudyGroup struct {
+	ID       int                `json:"id,omitempty"`
+	CourseID string             `json:"course_id"`
+	Name     string             `json:"name"`
+	Members  []StudyGroupMember `json:"members"`
+	// Topics are the topics shared by at least two members, and Availability the slots shared by every member.
+	Topics       []string `json:"topics"`
+	Availability []string `json:"availability"`
+	// ChannelID is the group's text channel or thread, and VoiceChannelID is empty for groups meeting in a thread.
+	ChannelID      string    `json:"channel_id"`
+	VoiceChannelID string    `json:"voice_channel_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	// DisbandedAt is zero while the group has members.
+	DisbandedAt time.Time `json:"disbanded_at,omitzero"`
+}
+
+// ParseTopics splits comma separated topics, lowercasing them and dropping duplicates, and keeps at most maxStudyGroupTopics.
+func ParseTopics(value string) []string {
+	topics := []string{}
+	for topic := range strings.SplitSeq(value, ",") {
+		topic = strings.ToLower(strings.TrimSpace(topic))
+		if topic != "" && !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
+		}
+	}
+	return topics[:min(len(topics), maxStudyGroupTopics)]
+}
+
+// Active reports whether the group has not been disbanded.
+func (group StudyGroup) Active() bool {
+	return group.DisbandedAt.IsZero()
+}
+
+// Member returns a member of the group by their user ID, and whether they are in the group.
+func (group StudyGroup) Member(userID string) (StudyGroupMember, bool) {
+	index := slices.IndexFunc(group.Members, func(member StudyGroupMember) bool { return member.UserID == userID })
+	if index < 0 {
+		return StudyGroupMember{}, false
+	}
+	return group.Members[index], true
+}
+
+// Without returns the group without a member.
+func (group StudyGroup) Without(userID string) StudyGroup {
+	group.Members = slices.DeleteFunc(slices.Clone(group.Members), func(member StudyGroupMember) bool { return member.UserID == userID })
+	return group
+}
+
+// StudyGroupOf returns the active group among groups that a student is in, and whether they are in one.
+func StudyGroupOf(groups []StudyGroup, userID string) (StudyGroup, bool) {
+	for _, group := range groups {
+		if _, exists := group.Member(userID); exists && group.Active() {
+			return group, true
+		}
+	}
+	return StudyGroup{}, false
+}
+
+// StudyGroupRequestOf returns a student's request among requests, and whether they are waiting to be matched.
+func StudyGroupRequestOf(requests []StudyGroupRequest, userID string) (StudyGroupRequest, bool) {
+	index := slices.IndexFunc(requests, func(request StudyGroupRequest) bool { return request.UserID == userID })
+	if index < 0 {
+		return StudyGroupRequest{}, false
+	}
+	return requests[index], true
+}
+
+// MatchStudyGroups matches waiting students into groups of size. Students who waited longest are matched first.
+// Each group is built from its longest waiting student by adding the student sharing the most topics with the group,
+// among those sharing an available slot with every member. Students who cannot fill a group keep waiting for the next match.
+func MatchStudyGroups(requests []StudyGroupRequest, size int) []StudyGroup {
+	waiting := slices.Clone(requests)
+	slices.SortStableFunc(waiting, func(a StudyGroupRequest, b StudyGroupRequest) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	matched := make([]bool, len(waiting))
+
+	groups := []StudyGroup{}
+	for seed := range waiting {
+		if matched[seed] || len(waiting[seed].Availability) == 0 {
+			continue
+		}
+		members := []int{seed}
+		availability := waiting[seed].Availability
+		for len(members) < size {
+			best, bestScore := -1, -1
+			for candidate := range waiting {
+				if matched[candidate] || slices.Contains(members, candidate) || len(sharedSlots(availability, waiting[candidate].Availability)) == 0 {
+					continue
+				}
+				score := 0
+				for _, topic := range waiting[candidate].Topics {
+					if slices.ContainsFunc(members, func(member int) bool { return slices.Contains(waiting[member].Topics, topic) }) {
+						score++
+					}
+				}
+				// ties go to the candidate who waited longest, since waiting is sorted by when students asked
+				if score > bestScore {
+					best, bestScore = candidate, score
+				}
+			}
+			if best < 0 {
+				break
+			}
+			members = append(members, best)
+			availability = sharedSlots(availability, waiting[best].Availability)
+		}
+		if len(members) < size {
+			continue
+		}
+
+		group := StudyGroup{CourseID: waiting[seed].CourseID, Topics: []string{}, Availability: availability}
+		topicCounts := map[string]int{}
+		for _, member := range members {
+			matched[member] = true
+			group.Members = append(group.Members, StudyGroupMember{UserID: waiting[member].UserID, Topics: waiting[member].Topics, Availability: waiting[member].Availability})
+			for _, topic := range waiting[member].Topics {
+				topicCounts[topic]++
+			}
+		}
+		for topic, count := range topicCounts {
+			if count >= 2 {
+				group.Topics = append(group.Topics, topic)
+			}
+		}
+		slices.SortFunc(group.Topics, func(a string, b string) int {
+			return cmp.Or(cmp.Compare(topicCounts[b], topicCounts[a]), cmp.Compare(a, b))
+		})
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// sharedSlots returns the slots in both a and b, in the order of StudyGroupSlots.
+func sharedSlots(a []string, b []string) []string {
+	return slices.DeleteFunc(slices.Clone(StudyGroupSlots), func(slot string) bool { return !slices.Contains(a, slot) || !slices.Contains(b, slot) })
+}
+
+// ListStudyGroupRequests lists the students waiting to be matched into study groups in a course.
+func (backend *APIClient) ListStudyGroupRequests(ctx context.Context, courseID string) ([]StudyGroupRequest, error) {
+	ctx, span := tracing.Start(ctx, "listStudyGroupRequests")
+	defer span.End()
+
+	requests := []StudyGroupRequest{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("studygroups/requests?course_id=%s", courseID), nil, http.StatusOK, &requests)
+	return requests, err
+}
+
+// CreateStudyGroupRequest adds a student to the students waiting to be matched, replacing their earlier request.
+func (backend *APIClient) CreateStudyGroupRequest(ctx context.Context, request StudyGroupRequest) (StudyGroupRequest, error) {
+	ctx, span := tracing.Start(ctx, "createStudyGroupRequest")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPost, "studygroups/requests", request, http.StatusCreated, &request)
+	return request, err
+}
+
+// UpdateStudyGroupRequest updates a waiting student's request, such as to change their availability.
+func (backend *APIClient) UpdateStudyGroupRequest(ctx context.Context, request StudyGroupRequest) (StudyGroupRequest, error) {
+	ctx, span := tracing.Start(ctx, "updateStudyGroupRequest")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPut, "studygroups/requests", request, http.StatusAccepted, &request)
+	return request, err
+}
+
+// DeleteStudyGroupRequest removes a student from the students waiting to be matched.
+func (backend *APIClient) DeleteStudyGroupRequest(ctx context.Context, requestID string) error {
+	ctx, span := tracing.Start(ctx, "deleteStudyGroupRequest")
+	defer span.End()
+
+	return backend.jsonRequest(ctx, http.MethodDelete, fmt.Sprintf("studygroups/requests?id=%s", requestID), nil, http.StatusNoContent, nil)
+}
+
+// ListStudyGroups lists all study groups in a course, including disbanded groups.
+func (backend *APIClient) ListStudyGroups(ctx context.Context, courseID string) ([]StudyGroup, error) {
+	ctx, span := tracing.Start(ctx, "listStudyGroups")
+	defer span.End()
+
+	groups := []StudyGroup{}
+	err := backend.jsonRequest(ctx, http.MethodGet, fmt.Sprintf("studygroups?course_id=%s", courseID), nil, http.StatusOK, &groups)
+	return groups, err
+}
+
+// CreateStudyGroup creates a new study group in the backend, which removes its members' requests.
+func (backend *APIClient) CreateStudyGroup(ctx context.Context, group StudyGroup) (StudyGroup, error) {
+	ctx, span := tracing.Start(ctx, "createStudyGroup")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPost, "studygroups", group, http.StatusCreated, &group)
+	return group, err
+}
+
+// UpdateStudyGroup updates an existing study group in the backend, such as when a member leaves or it is disbanded.
+func (backend *APIClient) UpdateStudyGroup(ctx context.Context, group StudyGroup) (StudyGroup, error) {
+	ctx, span := tracing.Start(ctx, "updateStudyGroup")
+	defer span.End()
+
+	err := backend.jsonRequest(ctx, http.MethodPut, "studygroups", group, http.StatusAccepted, &group)
+	return group, err
+}
@@ -0,0 +1,88 @@
+package clients_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/stretchr/testify/suite"
+)
+
+type StudyGroupsTestSuite struct {
+	suite.Suite
+	createdAt time.Time
+}
+
+func TestStudyGroups(t *testing.T) {
+	suite.Run(t, new(StudyGroupsTestSuite))
+}
+
+func (testSuite *StudyGroupsTestSuite) SetupTest() {
+	testSuite.createdAt = time.Date(2025, 2, 3, 9, 0, 0, 0, time.UTC)
+}
+
+func (testSuite *StudyGroupsTestSuite) request(userID string, waited int, topics []string, availability []string) clients.StudyGroupRequest {
+	return clients.StudyGroupRequest{
+		CourseID:     "course",
+		UserID:       userID,
+		Topics:       topics,
+		Availability: availability,
+		CreatedAt:    testSuite.createdAt.Add(-time.Duration(waited) * time.Minute),
+	}
+}
+
+func (testSuite *StudyGroupsTestSuite) TestParseTopics() {
+	testSuite.Equal([]string{"recursion", "graphs"}, clients.ParseTopics(" Recursion, graphs,, recursion "))
+	testSuite.Empty(clients.ParseTopics(" , "))
+	testSuite.Len(clients.ParseTopics("a, b, c, d, e, f, g"), 5)
+}
+
+func (testSuite *StudyGroupsTestSuite) TestMatchStudyGroups() {
+	requests := []clients.StudyGroupRequest{
+		testSuite.request("student1", 40, []string{"graphs"}, clients.StudyGroupSlots),
+		testSuite.request("student2", 30, []string{"recursion"}, clients.StudyGroupSlots),
+		testSuite.request("student3", 20, []string{"graphs", "recursion"}, []string{"weekends"}),
+		testSuite.request("student4", 10, []string{"sorting"}, []string{"weekday_mornings"}),
+		testSuite.request("student5", 0, []string{"sorting"}, clients.StudyGroupSlots),
+	}
+
+	groups := clients.MatchStudyGroups(requests, 2)
+
+	testSuite.Len(groups, 2)
+	// student3 shares a topic with student1, so is chosen over student2, who waited longer
+	testSuite.Equal([]string{"student1", "student3"}, []string{groups[0].Members[0].UserID, groups[0].Members[1].UserID})
+	testSuite.Equal([]string{"graphs"}, groups[0].Topics)
+	testSuite.Equal([]string{"weekends"}, groups[0].Availability)
+	testSuite.Equal([]string{"student2", "student4"}, []string{groups[1].Members[0].UserID, groups[1].Members[1].UserID})
+	testSuite.Empty(groups[1].Topics)
+	testSuite.Equal([]string{"weekday_mornings"}, groups[1].Availability)
+}
+
+func (testSuite *StudyGroupsTestSuite) TestMatchStudyGroupsAvailability() {
+	requests := []clients.StudyGroupRequest{
+		testSuite.request("student1", 20, []string{"graphs"}, []string{"weekday_mornings"}),
+		testSuite.request("student2", 10, []string{"graphs"}, []string{"weekends"}),
+		testSuite.request("student3", 0, []string{"graphs"}, []string{"weekday_mornings", "weekends"}),
+	}
+
+	// no three students share a slot, so everyone keeps waiting
+	testSuite.Empty(clients.MatchStudyGroups(requests, 3))
+}
+
+func (testSuite *StudyGroupsTestSuite) TestStudyGroupOf() {
+	member := clients.StudyGroupMember{UserID: "student1"}
+	groups := []clients.StudyGroup{
+		{ID: 1, Members: []clients.StudyGroupMember{member}, DisbandedAt: testSuite.createdAt},
+		{ID: 2, Members: []clients.StudyGroupMember{member, {UserID: "student2"}}},
+	}
+
+	group, exists := clients.StudyGroupOf(groups, "student1")
+	testSuite.True(exists)
+	testSuite.Equal(2, group.ID)
+	_, exists = clients.StudyGroupOf(groups, "student3")
+	testSuite.False(exists)
+
+	remaining := group.Without("student1")
+	testSuite.Equal([]clients.StudyGroupMember{{UserID: "student2"}}, remaining.Members)
+	testSuite.Len(group.Members, 2)
+}
@@ -0,0 +1,159 @@
+// Package commands provides declarative registration of the bot's application commands.
+// The desired commands are diffed against the commands registered with Discord, and changes are applied with a single bulk overwrite.
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/interactions"
+	"github.com/palantir/stacktrace"
+)
+
+// Commands is the full set of application commands registered by hakase.
+var Commands = []*discordgo.ApplicationCommand{&interactions.AssignmentsCommand, &interactions.HakaseCommand, &interactions.AnnounceCommand, &interactions.OfficeHoursCommand, &interactions.AskCommand, &interactions.QuestionsCommand, &interactions.QuestionCommand, &interactions.AcceptAnswerCommand, &interactions.FAQCommand, &interactions.PollCommand, &interactions.AttendanceCommand, &interactions.StudyGroupCommand, &interactions.FocusCommand, &interactions.FlashcardsCommand}
+
+// Diff is the set of changes needed to make the registered commands match the desired commands.
+type Diff struct {
+	Create []*discordgo.ApplicationCommand
+	Update []*discordgo.ApplicationCommand
+	Delete []*discordgo.ApplicationCommand
+}
+
+// Empty reports whether the diff has no changes.
+func (diff Diff) Empty() bool {
+	return len(diff.Create) == 0 && len(diff.Update) == 0 && len(diff.Delete) == 0
+}
+
+// commandKey identifies a command by type and name, since a chat command and a context menu command may share a name.
+func commandKey(command *discordgo.ApplicationCommand) string {
+	commandType := command.Type
+	if commandType == 0 {
+		commandType = discordgo.ChatApplicationCommand
+	}
+	return fmt.Sprintf("%d:%s", commandType, command.Name)
+}
+
+// commandSpec returns the user-defined fields of a command, ignoring fields that Discord assigns such as IDs and versions.
+func commandSpec(command *discordgo.ApplicationCommand) string {
+	spec := struct {
+		Type                     discordgo.ApplicationCommandType      `json:"type"`
+		Name                     string                                `json:"name"`
+		NameLocalizations        *map[discordgo.Locale]string          `json:"name_localizations,omitempty"`
+		Description              string                                `json:"description,omitempty"`
+		DescriptionLocalizations *map[discordgo.Locale]string          `json:"description_localizations,omitempty"`
+		DefaultMemberPermissions *int64                                `json:"default_member_permissions,omitempty"`
+		Options                  []*discordgo.ApplicationCommandOption `json:"options"`
+	}{
+		Type:                     command.Type,
+		Name:                     command.Name,
+		NameLocalizations:        command.NameLocalizations,
+		Description:              command.Description,
+		DescriptionLocalizations: command.DescriptionLocalizations,
+		DefaultMemberPermissions: command.DefaultMemberPermissions,
+		Options:                  command.Options,
+	}
+	if spec.Type == 0 {
+		spec.Type = discordgo.ChatApplicationCommand
+	}
+	if spec.Options == nil {
+		spec.Options = []*discordgo.ApplicationCommandOption{}
+	}
+	if spec.NameLocalizations != nil && len(*spec.NameLocalizations) == 0 {
+		spec.NameLocalizations = nil
+	}
+	if spec.DescriptionLocalizations != nil && len(*spec.DescriptionLocalizations) == 0 {
+		spec.DescriptionLocalizations = nil
+	}
+
+	specJSON, _ := json.Marshal(spec)
+	return string(specJSON)
+}
+
+// ComputeDiff compares the registered commands against the desired commands.
+func ComputeDiff(registered []*discordgo.ApplicationCommand, desired []*discordgo.ApplicationCommand) Diff {
+	diff := Diff{}
+
+	registeredMap := make(map[string]*discordgo.ApplicationCommand, len(registered))
+	for _, command := range registered {
+		registeredMap[commandKey(command)] = command
+	}
+
+	desiredKeys := make(map[string]bool, len(desired))
+	for _, command := range desired {
+		key := commandKey(command)
+		desiredKeys[key] = true
+
+		existing, exists := registeredMap[key]
+		if !exists {
+			diff.Create = append(diff.Create, command)
+		} else if commandSpec(existing) != commandSpec(command) {
+			diff.Update = append(diff.Update, command)
+		}
+	}
+
+	for _, command := range registered {
+		if !desiredKeys[commandKey(command)] {
+			diff.Delete = append(diff.Delete, command)
+		}
+	}
+	sort.Slice(diff.Delete, func(i, j int) bool {
+		return diff.Delete[i].Name < diff.Delete[j].Name
+	})
+
+	return diff
+}
+
+// Sync registers the desired commands for the application, removing stale commands.
+// If guildID is not empty, commands are registered to that guild only, which takes effect immediately and is intended for development.
+// Discord is only called to overwrite commands when the diff is not empty.
+func Sync(bot *discordgo.Session, appID string, guildID string, desired []*discordgo.ApplicationCommand) (Diff, error) {
+	registered, err := bot.ApplicationCommands(appID, guildID)
+	if err != nil {
+		return Diff{}, stacktrace.Propagate(err, "failed to list registered commands")
+	}
+
+	diff := ComputeDiff(registered, desired)
+	if diff.Empty() {
+		slog.Info("registered commands are up to date")
+		return diff, nil
+	}
+
+	for _, command := range diff.Create {
+		slog.Info(fmt.Sprintf("creating command: %s", command.Name))
+	}
+	for _, command := range diff.Update {
+		slog.Info(fmt.Sprintf("updating command: %s", command.Name))
+	}
+	for _, command := range diff.Delete {
+		slog.Info(fmt.Sprintf("deleting command: %s", command.Name))
+	}
+
+	_, err = bot.ApplicationCommandBulkOverwrite(appID, guildID, desired)
+	if err != nil {
+		return diff, stacktrace.Propagate(err, "failed to overwrite commands")
+	}
+
+	return diff, nil
+}
+
+// List returns the commands registered for the application, globally if guildID is empty.
+func List(bot *discordgo.Session, appID string, guildID string) ([]*discordgo.ApplicationCommand, error) {
+	registered, err := bot.ApplicationCommands(appID, guildID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to list registered commands")
+	}
+	return registered, nil
+}
+
+// Purge removes all commands registered for the application, globally if guildID is empty.
+func Purge(bot *discordgo.Session, appID string, guildID string) error {
+	_, err := bot.ApplicationCommandBulkOverwrite(appID, guildID, []*discordgo.ApplicationCommand{})
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to purge commands")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package commands_test
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/commands"
+	"github.com/stretchr/testify/suite"
+)
+
+type RegistryTestSuite struct {
+	suite.Suite
+	desired []*discordgo.ApplicationCommand
+}
+
+func TestRegistry(t *testing.T) {
+	suite.Run(t, new(RegistryTestSuite))
+}
+
+func (testSuite *RegistryTestSuite) SetupTest() {
+	testSuite.desired = []*discordgo.ApplicationCommand{
+		{
+			Name:        "assignments",
+			Description: "configure assignments",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Name: "list", Description: "list assignments", Type: discordgo.ApplicationCommandOptionSubCommand},
+			},
+		},
+		{
+			Name:        "hakase",
+			Description: "course configuration",
+		},
+	}
+}
+
+func (testSuite *RegistryTestSuite) TestComputeDiffUpToDate() {
+	registered := []*discordgo.ApplicationCommand{
+		{
+			ID:            "1",
+			ApplicationID: "100",
+			Version:       "1",
+			Type:          discordgo.ChatApplicationCommand,
+			Name:          "assignments",
+			Description:   "configure assignments",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Name: "list", Description: "list assignments", Type: discordgo.ApplicationCommandOptionSubCommand},
+			},
+		},
+		{
+			ID:          "2",
+			Type:        discordgo.ChatApplicationCommand,
+			Name:        "hakase",
+			Description: "course configuration",
+		},
+	}
+	testSuite.True(commands.ComputeDiff(registered, testSuite.desired).Empty())
+}
+
+func (testSuite *RegistryTestSuite) TestComputeDiffChanges() {
+	registered := []*discordgo.ApplicationCommand{
+		{ID: "1", Type: discordgo.ChatApplicationCommand, Name: "assignments", Description: "old description"},
+		{ID: "3", Type: discordgo.ChatApplicationCommand, Name: "stale", Description: "no longer used"},
+	}
+
+	diff := commands.ComputeDiff(registered, testSuite.desired)
+	testSuite.False(diff.Empty())
+	testSuite.Len(diff.Create, 1)
+	testSuite.Equal("hakase", diff.Create[0].Name)
+	testSuite.Len(diff.Update, 1)
+	testSuite.Equal("assignments", diff.Update[0].Name)
+	testSuite.Len(diff.Delete, 1)
+	testSuite.Equal("stale", diff.Delete[0].Name)
+}
+
+func (testSuite *RegistryTestSuite) TestComputeDiffDistinguishesCommandTypes() {
+	registered := []*discordgo.ApplicationCommand{
+		{ID: "1", Type: discordgo.MessageApplicationCommand, Name: "hakase"},
+	}
+
+	diff := commands.ComputeDiff(registered, testSuite.desired[1:])
+	testSuite.Len(diff.Create, 1)
+	testSuite.Len(diff.Delete, 1)
+}
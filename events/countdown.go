@@ -0,0 +1,91 @@
+// Package events provides the periodic exam countdown job.
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// CountdownInterval is how often exam countdowns are refreshed.
+// Discord rate limits channel renames to twice every 10 minutes.
+const CountdownInterval = 10 * time.Minute
+
+// Countdown refreshes exam countdowns every CountdownInterval until stopCountdown receives.
+func Countdown(bot *discordgo.Session, hakaseClient clients.HakaseClient, stopCountdown chan bool) {
+	ticker := time.NewTicker(CountdownInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			UpdateCountdowns(bot, hakaseClient)
+		case <-stopCountdown:
+			return
+		}
+	}
+}
+
+// UpdateCountdowns renames the countdown voice channel and edits the pinned countdown embed for every course the bot is in.
+func UpdateCountdowns(bot *discordgo.Session, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "updateCountdowns")
+	defer span.End()
+
+	for _, guildID := range guildIDs(bot) {
+		course, err := hakaseClient.Backend.ReadCourse(ctx, guildID)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to read course: %s", guildID).Error())
+			continue
+		}
+		if course.CountdownChannel == "" && course.CountdownPinMessage == "" {
+			continue
+		}
+		updateCountdown(ctx, hakaseClient, course)
+	}
+}
+
+// updateCountdown refreshes the exam countdown voice channel and pinned embed for a single course.
+func updateCountdown(ctx context.Context, hakaseClient clients.HakaseClient, course clients.Course) {
+	ctx, span := tracing.Start(ctx, "updateCountdown")
+	defer span.End()
+	bot := clients.Session(ctx)
+
+	assignments, err := hakaseClient.Backend.ListAssignments(ctx, course.CourseID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to list assignments for course: %s", course.CourseID).Error())
+		return
+	}
+	now := time.Now()
+	locale := locales.Resolve(course.Locale)
+
+	if course.CountdownChannel != "" {
+		channelName := views.CountdownChannelName(locale, assignments, now)
+		channel, err := bot.Channel(course.CountdownChannel)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to get countdown channel: %s", course.CountdownChannel).Error())
+		} else if channel.Name != channelName {
+			// only rename when the name changes to stay within the channel rename rate limit
+			_, err = bot.ChannelEdit(course.CountdownChannel, &discordgo.ChannelEdit{Name: channelName})
+			if err != nil {
+				slog.Error(stacktrace.Propagate(err, "failed to rename countdown channel: %s", course.CountdownChannel).Error())
+			}
+		}
+	}
+
+	if course.CountdownPinMessage != "" {
+		_, err = bot.ChannelMessageEditEmbed(course.CountdownPinChannel, course.CountdownPinMessage, views.CountdownView(locale, assignments, now))
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to update countdown message: %s", course.CountdownPinMessage).Error())
+		}
+	}
+
+	slog.Debug(fmt.Sprintf("updated exam countdown for course: %s", course.CourseID))
+}
@@ -0,0 +1,59 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/events"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type CountdownEventsTestSuite struct {
+	suite.Suite
+	bot          *discordgo.Session
+	hakaseClient clients.HakaseClient
+	backend      *MockBackendClient
+}
+
+func TestCountdownEvents(t *testing.T) {
+	suite.Run(t, new(CountdownEventsTestSuite))
+}
+
+func (hakaseClient *MockBackendClient) ReadCourse(ctx context.Context, courseID string) (clients.Course, error) {
+	args := hakaseClient.Called(ctx, courseID)
+	return args.Get(0).(clients.Course), args.Error(1)
+}
+
+func (hakaseClient *MockBackendClient) ListAssignments(ctx context.Context, courseID string) ([]clients.Assignment, error) {
+	args := hakaseClient.Called(ctx, courseID)
+	return args.Get(0).([]clients.Assignment), args.Error(1)
+}
+
+func (testSuite *CountdownEventsTestSuite) SetupTest() {
+	testSuite.bot = &discordgo.Session{}
+	testSuite.bot.State = &discordgo.State{}
+	testSuite.bot.State.Guilds = []*discordgo.Guild{{ID: "1234567890", Name: "Test Guild"}}
+	testSuite.backend = new(MockBackendClient)
+	testSuite.hakaseClient = clients.HakaseClient{
+		Backend:       testSuite.backend,
+		Notifications: nil,
+	}
+}
+
+func (testSuite *CountdownEventsTestSuite) TestUpdateCountdownsSkipsUnconfiguredCourse() {
+	testSuite.backend.On("ReadCourse", mock.Anything, "1234567890").Return(clients.Course{CourseID: "1234567890"}, nil)
+	events.UpdateCountdowns(testSuite.bot, testSuite.hakaseClient)
+	testSuite.backend.AssertExpectations(testSuite.T())
+	testSuite.backend.AssertNotCalled(testSuite.T(), "ListAssignments", mock.Anything, mock.Anything)
+}
+
+func (testSuite *CountdownEventsTestSuite) TestUpdateCountdownsListsAssignments() {
+	testSuite.backend.On("ReadCourse", mock.Anything, "1234567890").Return(clients.Course{CourseID: "1234567890", CountdownChannel: "0987654321"}, nil)
+	testSuite.backend.On("ListAssignments", mock.Anything, "1234567890").Return([]clients.Assignment{}, errors.New("backend unavailable"))
+	events.UpdateCountdowns(testSuite.bot, testSuite.hakaseClient)
+	testSuite.backend.AssertExpectations(testSuite.T())
+}
@@ -0,0 +1,117 @@
+// Package events provides the periodic focus timer job.
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// FocusInterval is how often focus timers are checked for phases that have ended.
+// Timer embeds count down on their own, so this only delays the ping at the end of a phase.
+const FocusInterval = 15 * time.Second
+
+// FocusTimers advances focus timers every FocusInterval until stopFocusTimers receives.
+// Timers are kept in JetStream, so timers running when the bot restarts continue once it is back.
+func FocusTimers(bot *discordgo.Session, hakaseClient clients.HakaseClient, stopFocusTimers chan bool) {
+	ticker := time.NewTicker(FocusInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			AdvanceFocusTimers(bot, hakaseClient)
+		case <-stopFocusTimers:
+			return
+		}
+	}
+}
+
+// AdvanceFocusTimers moves every focus timer whose phase has ended to its next phase, for the courses on this shard.
+func AdvanceFocusTimers(bot *discordgo.Session, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "advanceFocusTimers")
+	defer span.End()
+
+	timers, err := hakaseClient.Notifications.ListFocusTimers(ctx, guildIDs(bot))
+	if err != nil {
+		// timers that could be read are still advanced
+		slog.Error(stacktrace.Propagate(err, "failed to list focus timers").Error())
+	}
+	now := time.Now()
+	for _, timer := range timers {
+		if !timer.Due(now) {
+			continue
+		}
+		advanceFocusTimer(ctx, hakaseClient, timer, now)
+	}
+}
+
+// advanceFocusTimer moves a focus timer to its next phase, pings its participants and edits its embed.
+// A timer changed since it was listed, such as by being paused, is left for the next tick.
+func advanceFocusTimer(ctx context.Context, hakaseClient clients.HakaseClient, timer clients.FocusTimer, now time.Time) {
+	ctx, span := tracing.Start(ctx, "advanceFocusTimer")
+	defer span.End()
+	bot := clients.Session(ctx)
+
+	timer.Advance(now)
+	var err error
+	if timer.Running() {
+		timer, err = hakaseClient.Notifications.SaveFocusTimer(ctx, timer)
+	} else {
+		err = hakaseClient.Notifications.DeleteFocusTimer(ctx, timer)
+	}
+	if err != nil {
+		slog.Warn(stacktrace.Propagate(err, "failed to advance focus timer: %s", timer.ChannelID).Error())
+		return
+	}
+
+	locale := locales.Default
+	course, err := hakaseClient.Backend.ReadCourse(ctx, timer.CourseID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to read course: %s", timer.CourseID).Error())
+	} else {
+		locale = locales.Resolve(course.Locale)
+	}
+
+	embeds := []*discordgo.MessageEmbed{views.FocusTimerView(locale, timer, now)}
+	components := views.FocusTimerActions(locale, timer)
+	_, err = bot.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:              timer.MessageID,
+		Channel:         timer.ChannelID,
+		Embeds:          &embeds,
+		Components:      &components,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to edit focus timer message: %s", timer.MessageID).Error())
+	}
+
+	mentions := make([]string, len(timer.Participants))
+	for index, participant := range timer.Participants {
+		mentions[index] = fmt.Sprintf("<@%s>", participant)
+	}
+	content := locale.T("focus.ping.done", strings.Join(mentions, " "), timer.Cycles)
+	switch timer.Phase {
+	case clients.FocusPhase:
+		content = locale.T("focus.ping.focus", strings.Join(mentions, " "), timer.FocusMinutes, timer.Cycle, timer.Cycles)
+	case clients.BreakPhase:
+		content = locale.T("focus.ping.break", strings.Join(mentions, " "), timer.BreakMinutes)
+	}
+	_, err = bot.ChannelMessageSendComplex(timer.ChannelID, &discordgo.MessageSend{
+		Content:         content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: timer.Participants},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to ping focus timer participants: %s", timer.ChannelID).Error())
+	}
+	slog.Info(fmt.Sprintf("focus timer in %s moved to %s, cycle %d of %d", timer.ChannelID, timer.Phase, timer.Cycle, timer.Cycles))
+}
@@ -8,20 +8,20 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
-	"github.com/getsentry/sentry-go"
+	"github.com/dragonejt/hakase-discord/tracing"
 	"github.com/palantir/stacktrace"
 )
 
 // GuildCreate handles the event when the bot is added to a guild and creates a course.
 func GuildCreate(bot *discordgo.Session, guildCreate *discordgo.GuildCreate, hakaseClient clients.HakaseClient) {
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), clients.DiscordSession{}, bot), "guildCreate")
-	defer transaction.Finish()
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "guildCreate")
+	defer span.End()
 	slog.Info(fmt.Sprintf("added to guild: %s (%s)", guildCreate.Name, guildCreate.ID))
 
 	course := clients.Course{
 		CourseID: guildCreate.ID,
 	}
-	err := hakaseClient.Backend.CreateCourse(transaction, course)
+	err := hakaseClient.Backend.CreateCourse(ctx, course)
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "failed to create course").Error())
 	}
@@ -34,11 +34,11 @@ func GuildCreate(bot *discordgo.Session, guildCreate *discordgo.GuildCreate, hak
 
 // GuildDelete handles the event when the bot is removed from a guild and deletes the course.
 func GuildDelete(bot *discordgo.Session, guildDelete *discordgo.GuildDelete, hakaseClient clients.HakaseClient) {
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), clients.DiscordSession{}, bot), "guildDelete")
-	defer transaction.Finish()
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "guildDelete")
+	defer span.End()
 	slog.Info(fmt.Sprintf("removed from guild: %s (%s)", guildDelete.Name, guildDelete.ID))
 
-	err := hakaseClient.Backend.DeleteCourse(transaction, guildDelete.ID)
+	err := hakaseClient.Backend.DeleteCourse(ctx, guildDelete.ID)
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "failed to delete course").Error())
 	}
@@ -48,3 +48,16 @@ func GuildDelete(bot *discordgo.Session, guildDelete *discordgo.GuildDelete, hak
 		slog.Error(stacktrace.Propagate(err, "failed to update status").Error())
 	}
 }
+
+// guildIDs returns the IDs of the guilds on this shard. They are copied under the state lock,
+// since jobs running on a ticker would otherwise race with gateway events adding and removing guilds.
+func guildIDs(bot *discordgo.Session) []string {
+	bot.State.RLock()
+	defer bot.State.RUnlock()
+
+	ids := make([]string, 0, len(bot.State.Guilds))
+	for _, guild := range bot.State.Guilds {
+		ids = append(ids, guild.ID)
+	}
+	return ids
+}
@@ -1,13 +1,13 @@
 package events_test
 
 import (
+	"context"
 	"log/slog"
 	"testing"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
 	"github.com/dragonejt/hakase-discord/events"
-	"github.com/getsentry/sentry-go"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -30,13 +30,13 @@ type MockBackendClient struct {
 	mock.Mock
 }
 
-func (hakaseClient *MockBackendClient) CreateCourse(span *sentry.Span, course clients.Course) error {
-	hakaseClient.Called(span, course)
+func (hakaseClient *MockBackendClient) CreateCourse(ctx context.Context, course clients.Course) error {
+	hakaseClient.Called(ctx, course)
 	return nil
 }
 
-func (hakaseClient *MockBackendClient) DeleteCourse(span *sentry.Span, courseID string) error {
-	hakaseClient.Called(span, courseID)
+func (hakaseClient *MockBackendClient) DeleteCourse(ctx context.Context, courseID string) error {
+	hakaseClient.Called(ctx, courseID)
 	return nil
 }
 
@@ -5,14 +5,43 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
 	"github.com/dragonejt/hakase-discord/interactions"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/metrics"
+	"github.com/palantir/stacktrace"
 )
 
+// interactionLabels returns the metric labels for an interaction: its type, and the command name or custom ID prefix.
+func interactionLabels(interactionCreate *discordgo.InteractionCreate) (string, string) {
+	switch interactionCreate.Type {
+	case discordgo.InteractionApplicationCommand:
+		return "command", interactionCreate.ApplicationCommandData().Name
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		return "autocomplete", interactionCreate.ApplicationCommandData().Name
+	case discordgo.InteractionMessageComponent:
+		name, _, _ := strings.Cut(interactionCreate.MessageComponentData().CustomID, "_")
+		return "component", name
+	case discordgo.InteractionModalSubmit:
+		name, _, _ := strings.Cut(interactionCreate.ModalSubmitData().CustomID, "_")
+		return "modal", name
+	default:
+		return "unknown", ""
+	}
+}
+
 // InteractionCreate dispatches Discord interactions to the appropriate handler based on type and command.
 func InteractionCreate(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	interactionType, name := interactionLabels(interactionCreate)
+	start := time.Now()
+	defer func() {
+		metrics.InteractionsHandled.Inc(interactionType, name)
+		metrics.HandlerLatency.Observe(time.Since(start).Seconds(), interactionType)
+	}()
+
 	switch interactionCreate.Type {
 	case discordgo.InteractionApplicationCommand:
 		switch interactionCreate.ApplicationCommandData().Name {
@@ -20,13 +49,48 @@ func InteractionCreate(bot *discordgo.Session, interactionCreate *discordgo.Inte
 			interactions.SlashAssignments(bot, interactionCreate, hakaseClient)
 		case "hakase":
 			interactions.SlashHakase(bot, interactionCreate, hakaseClient)
+		case "announce":
+			interactions.SlashAnnounce(bot, interactionCreate, hakaseClient)
+		case "officehours":
+			interactions.SlashOfficeHours(bot, interactionCreate, hakaseClient)
+		case "ask":
+			interactions.SlashAsk(bot, interactionCreate, hakaseClient)
+		case "questions":
+			interactions.SlashQuestions(bot, interactionCreate, hakaseClient)
+		case "question":
+			interactions.SlashQuestion(bot, interactionCreate, hakaseClient)
+		case "faq":
+			interactions.SlashFAQ(bot, interactionCreate, hakaseClient)
+		case "Accept answer":
+			interactions.AcceptAnswer(bot, interactionCreate, hakaseClient)
+		case "poll":
+			interactions.SlashPoll(bot, interactionCreate, hakaseClient)
+		case "attendance":
+			interactions.SlashAttendance(bot, interactionCreate, hakaseClient)
+		case "studygroup":
+			interactions.SlashStudyGroup(bot, interactionCreate, hakaseClient)
+		case "focus":
+			interactions.SlashFocus(bot, interactionCreate, hakaseClient)
+		case "flashcards":
+			interactions.SlashFlashcards(bot, interactionCreate, hakaseClient)
 		default:
 			slog.Error(fmt.Sprintf("unknown command: %s", interactionCreate.ApplicationCommandData().Name))
 		}
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		switch interactionCreate.ApplicationCommandData().Name {
+		case "assignments":
+			interactions.AssignmentsAutocomplete(bot, interactionCreate, hakaseClient)
+		case "faq":
+			interactions.FAQAutocomplete(bot, interactionCreate, hakaseClient)
+		case "flashcards":
+			interactions.FlashcardsAutocomplete(bot, interactionCreate, hakaseClient)
+		default:
+			slog.Error(fmt.Sprintf("unknown autocomplete command: %s", interactionCreate.ApplicationCommandData().Name))
+		}
 	case discordgo.InteractionMessageComponent:
 		customID := interactionCreate.MessageComponentData().CustomID
 		if strings.HasPrefix(customID, "addAssignmentAction") {
-			interactions.AddAssignment(bot, interactionCreate)
+			interactions.AddAssignment(bot, interactionCreate, hakaseClient)
 		} else if strings.HasPrefix(customID, "updateAssignmentAction") {
 			interactions.UpdateAssignment(bot, interactionCreate, hakaseClient)
 		} else if strings.HasPrefix(customID, "deleteAssignmentAction") {
@@ -35,6 +99,50 @@ func InteractionCreate(bot *discordgo.Session, interactionCreate *discordgo.Inte
 			interactions.UpdateNotifyChannel(bot, interactionCreate, hakaseClient)
 		} else if strings.HasPrefix(customID, "updateNotifyRole") {
 			interactions.UpdateNotifyRole(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "updateCountdownChannel") {
+			interactions.UpdateCountdownChannel(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "updateCourseLocale") {
+			interactions.UpdateCourseLocale(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "pinCountdownAction") {
+			interactions.PinCountdown(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "acknowledgeAnnouncement") {
+			interactions.AcknowledgeAnnouncement(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "announcementAcknowledgements") {
+			interactions.AnnouncementAcknowledgements(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "joinOfficeHours") {
+			interactions.JoinOfficeHours(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "leaveOfficeHours") {
+			interactions.LeaveOfficeHours(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "nextOfficeHours") {
+			interactions.NextOfficeHours(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "closeOfficeHours") {
+			interactions.CloseOfficeHours(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "approveQuestion") {
+			interactions.ApproveQuestion(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "rejectQuestion") {
+			interactions.RejectQuestion(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "votePoll") {
+			interactions.VotePoll(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "closePoll") {
+			interactions.ClosePoll(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "exportPoll") {
+			interactions.ExportPoll(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "checkInAttendance") {
+			interactions.CheckInAttendance(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "endAttendance") {
+			interactions.EndAttendance(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "studyGroupAvailability") {
+			interactions.StudyGroupAvailability(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "joinFocus") {
+			interactions.JoinFocus(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "pauseFocus") {
+			interactions.PauseFocus(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "stopFocus") {
+			interactions.StopFocus(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "flipFlashcard") {
+			interactions.FlipFlashcard(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "gradeFlashcard") {
+			interactions.GradeFlashcard(bot, interactionCreate, hakaseClient)
 		} else {
 			slog.Error(fmt.Sprintf("unknown message component action: %s", customID))
 		}
@@ -44,6 +152,16 @@ func InteractionCreate(bot *discordgo.Session, interactionCreate *discordgo.Inte
 			interactions.AddAssignmentSubmit(bot, interactionCreate, hakaseClient)
 		} else if strings.HasPrefix(customID, "updateAssignment") {
 			interactions.UpdateAssignmentSubmit(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "announce") {
+			interactions.AnnounceSubmit(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "joinOfficeHoursQueue") {
+			interactions.JoinOfficeHoursSubmit(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "askQuestion") {
+			interactions.AskSubmit(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "createQAThread") {
+			interactions.QAThreadSubmit(bot, interactionCreate, hakaseClient)
+		} else if strings.HasPrefix(customID, "createFlashcardDeck") {
+			interactions.FlashcardDeckSubmit(bot, interactionCreate, hakaseClient)
 		} else {
 			slog.Error(fmt.Sprintf("unknown modal submit: %s", interactionCreate.ModalSubmitData().CustomID))
 		}
@@ -52,3 +170,27 @@ func InteractionCreate(bot *discordgo.Session, interactionCreate *discordgo.Inte
 
 	}
 }
+
+// InteractionUnavailable responds to an interaction received while the bot is shutting down, asking the user to try again.
+// The course's default locale is not read, since the backend may be unavailable while shutting down.
+func InteractionUnavailable(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate) {
+	locale := locales.Resolve(string(interactionCreate.Locale))
+	response := &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: locale.T("restarting"),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}
+	if interactionCreate.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		response = &discordgo.InteractionResponse{
+			Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+			Data: &discordgo.InteractionResponseData{Choices: []*discordgo.ApplicationCommandOptionChoice{}},
+		}
+	}
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, response)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
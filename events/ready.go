@@ -8,19 +8,22 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
-	"github.com/getsentry/sentry-go"
+	"github.com/dragonejt/hakase-discord/tracing"
 	"github.com/palantir/stacktrace"
 )
 
 // Ready handles the Discord ready event and updates bot status and notifications.
 func Ready(bot *discordgo.Session, ready *discordgo.Ready, hakaseClient clients.HakaseClient) {
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), clients.DiscordSession{}, bot), "ready")
-	defer transaction.Finish()
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "ready")
+	defer span.End()
 	slog.Info(fmt.Sprintf("logged in as %s", ready.User.String()))
 
-	hakaseClient.Notifications.PublishNotification(transaction, fmt.Sprintf("logged in as %s", ready.User.String()))
+	err := hakaseClient.Notifications.PublishNotification(ctx, fmt.Sprintf("logged in as %s", ready.User.String()))
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to publish login notification").Error())
+	}
 
-	err := bot.UpdateCustomStatus(fmt.Sprintf("assisting %d classes", len(bot.State.Guilds)))
+	err = bot.UpdateCustomStatus(fmt.Sprintf("assisting %d classes", len(bot.State.Guilds)))
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "failed to update status").Error())
 	}
@@ -1,6 +1,7 @@
 package events_test
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"testing"
@@ -8,7 +9,6 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
 	"github.com/dragonejt/hakase-discord/events"
-	"github.com/getsentry/sentry-go"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -30,8 +30,9 @@ type MockNotificationsClient struct {
 	mock.Mock
 }
 
-func (notifications *MockNotificationsClient) PublishNotification(span *sentry.Span, notification string) {
-	notifications.Called(span, notification)
+func (notifications *MockNotificationsClient) PublishNotification(ctx context.Context, notification string) error {
+	notifications.Called(ctx, notification)
+	return nil
 }
 
 func (testSuite *ReadyEventsTestSuite) SetupTest() {
@@ -0,0 +1,192 @@
+// Package events provides the periodic study group matching job.
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// StudyGroupInterval is how often waiting students are matched into study groups.
+const StudyGroupInterval = time.Hour
+
+// studyGroupThreadArchive is how long, in minutes, a study group's thread stays open without new messages.
+const studyGroupThreadArchive = 10080
+
+// studyGroupPermissions are the permissions members have in their study group's channels, which no one else can see.
+const studyGroupPermissions = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionReadMessageHistory |
+	discordgo.PermissionVoiceConnect | discordgo.PermissionVoiceSpeak
+
+// StudyGroups matches waiting students into study groups every StudyGroupInterval until stopStudyGroups receives.
+func StudyGroups(bot *discordgo.Session, hakaseClient clients.HakaseClient, stopStudyGroups chan bool) {
+	ticker := time.NewTicker(StudyGroupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			MatchStudyGroups(bot, hakaseClient)
+		case <-stopStudyGroups:
+			return
+		}
+	}
+}
+
+// MatchStudyGroups matches waiting students into study groups for every course the bot is in that has study groups configured.
+func MatchStudyGroups(bot *discordgo.Session, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "matchStudyGroups")
+	defer span.End()
+
+	for _, guildID := range guildIDs(bot) {
+		course, err := hakaseClient.Backend.ReadCourse(ctx, guildID)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to read course: %s", guildID).Error())
+			continue
+		}
+		if course.StudyGroupChannel == "" {
+			continue
+		}
+		matchCourseStudyGroups(ctx, hakaseClient, course)
+	}
+}
+
+// matchCourseStudyGroups matches a course's waiting students into study groups, and gives each group its own channels or thread.
+func matchCourseStudyGroups(ctx context.Context, hakaseClient clients.HakaseClient, course clients.Course) {
+	ctx, span := tracing.Start(ctx, "matchCourseStudyGroups")
+	defer span.End()
+	bot := clients.Session(ctx)
+
+	requests, err := hakaseClient.Backend.ListStudyGroupRequests(ctx, course.CourseID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to list study group requests for course: %s", course.CourseID).Error())
+		return
+	}
+	size := course.StudyGroupSize
+	if size == 0 {
+		size = clients.DefaultStudyGroupSize
+	}
+	matches := clients.MatchStudyGroups(requests, size)
+	if len(matches) == 0 {
+		return
+	}
+
+	existing, err := hakaseClient.Backend.ListStudyGroups(ctx, course.CourseID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to list study groups for course: %s", course.CourseID).Error())
+		return
+	}
+	locale := locales.Resolve(course.Locale)
+
+	for index, group := range matches {
+		group.Name = locale.T("studygroup.name", len(existing)+index+1)
+		group.CreatedAt = time.Now().Truncate(time.Second)
+		group, err = createStudyGroupSpace(ctx, course, group)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to create channels for study group in course: %s", course.CourseID).Error())
+			continue
+		}
+
+		created, err := hakaseClient.Backend.CreateStudyGroup(ctx, group)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to create study group in course: %s", course.CourseID).Error())
+			// the members are still waiting, so remove the space to be created again when they are next matched
+			deleteStudyGroupSpace(ctx, group)
+			continue
+		}
+
+		members := make([]string, len(created.Members))
+		for memberIndex, member := range created.Members {
+			members[memberIndex] = member.UserID
+		}
+		_, err = bot.ChannelMessageSendComplex(created.ChannelID, &discordgo.MessageSend{
+			Embeds:          []*discordgo.MessageEmbed{views.StudyGroupView(locale, created)},
+			AllowedMentions: &discordgo.MessageAllowedMentions{Users: members},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to welcome study group: %d", created.ID).Error())
+		}
+		slog.Info(fmt.Sprintf("matched study group %d of %d students in course: %s", created.ID, len(created.Members), course.CourseID))
+	}
+}
+
+// createStudyGroupSpace creates a study group's private text and voice channels in the course's study group category,
+// or its private thread in the course's study group channel, returning the group with the channels it meets in.
+func createStudyGroupSpace(ctx context.Context, course clients.Course, group clients.StudyGroup) (clients.StudyGroup, error) {
+	bot := clients.Session(ctx)
+
+	if course.StudyGroupMode == clients.StudyGroupThreads {
+		thread, err := bot.ThreadStartComplex(course.StudyGroupChannel, &discordgo.ThreadStart{
+			Name:                group.Name,
+			AutoArchiveDuration: studyGroupThreadArchive,
+			Type:                discordgo.ChannelTypeGuildPrivateThread,
+			Invitable:           false,
+		})
+		if err != nil {
+			return group, stacktrace.Propagate(err, "failed to start study group thread in channel: %s", course.StudyGroupChannel)
+		}
+		group.ChannelID = thread.ID
+		for _, member := range group.Members {
+			err = bot.ThreadMemberAdd(thread.ID, member.UserID)
+			if err != nil {
+				slog.Error(stacktrace.Propagate(err, "failed to add %s to study group thread: %s", member.UserID, thread.ID).Error())
+			}
+		}
+		return group, nil
+	}
+
+	overwrites := []*discordgo.PermissionOverwrite{
+		// the @everyone role has the ID of the guild
+		{ID: course.CourseID, Type: discordgo.PermissionOverwriteTypeRole, Deny: discordgo.PermissionViewChannel},
+		{ID: bot.State.User.ID, Type: discordgo.PermissionOverwriteTypeMember, Allow: studyGroupPermissions},
+	}
+	for _, member := range group.Members {
+		overwrites = append(overwrites, &discordgo.PermissionOverwrite{ID: member.UserID, Type: discordgo.PermissionOverwriteTypeMember, Allow: studyGroupPermissions})
+	}
+
+	text, err := bot.GuildChannelCreateComplex(course.CourseID, discordgo.GuildChannelCreateData{
+		Name:                 group.Name,
+		Type:                 discordgo.ChannelTypeGuildText,
+		ParentID:             course.StudyGroupChannel,
+		PermissionOverwrites: overwrites,
+	})
+	if err != nil {
+		return group, stacktrace.Propagate(err, "failed to create study group text channel in category: %s", course.StudyGroupChannel)
+	}
+	group.ChannelID = text.ID
+
+	voice, err := bot.GuildChannelCreateComplex(course.CourseID, discordgo.GuildChannelCreateData{
+		Name:                 group.Name,
+		Type:                 discordgo.ChannelTypeGuildVoice,
+		ParentID:             course.StudyGroupChannel,
+		PermissionOverwrites: overwrites,
+	})
+	if err != nil {
+		// the group can still meet in its text channel
+		slog.Error(stacktrace.Propagate(err, "failed to create study group voice channel in category: %s", course.StudyGroupChannel).Error())
+		return group, nil
+	}
+	group.VoiceChannelID = voice.ID
+	return group, nil
+}
+
+// deleteStudyGroupSpace deletes a study group's channels or thread.
+func deleteStudyGroupSpace(ctx context.Context, group clients.StudyGroup) {
+	bot := clients.Session(ctx)
+	for _, channelID := range []string{group.ChannelID, group.VoiceChannelID} {
+		if channelID == "" {
+			continue
+		}
+		_, err := bot.ChannelDelete(channelID)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to delete study group channel: %s", channelID).Error())
+		}
+	}
+}
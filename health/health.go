@@ -0,0 +1,108 @@
+// Package health provides the HTTP server for liveness, readiness and metrics endpoints.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/metrics"
+	"github.com/palantir/stacktrace"
+)
+
+// CheckTimeout is how long each readiness check may run before it is considered failed.
+const CheckTimeout = 5 * time.Second
+
+// Check is a named readiness check, such as whether the Discord gateway is connected.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// checkResult is the JSON result of a readiness check.
+type checkResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Handler returns the HTTP handler serving /healthz, /readyz and /metrics.
+// /healthz reports that the process is running, /readyz runs every check, and /metrics writes metrics in the Prometheus text format.
+func Handler(checks []Check) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = writer.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("GET /readyz", func(writer http.ResponseWriter, request *http.Request) {
+		results, ready := runChecks(request.Context(), checks)
+		writer.Header().Set("Content-Type", "application/json")
+		if !ready {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(writer).Encode(results)
+	})
+	mux.HandleFunc("GET /metrics", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		err := metrics.WriteTo(writer)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to write metrics").Error())
+		}
+	})
+	return mux
+}
+
+// runChecks runs every check concurrently, returning each check's result and whether all of them passed.
+func runChecks(ctx context.Context, checks []Check) (map[string]checkResult, bool) {
+	results := make(map[string]checkResult, len(checks))
+	ready := true
+	lock := sync.Mutex{}
+	wait := sync.WaitGroup{}
+
+	for _, check := range checks {
+		wait.Go(func() {
+			checkCtx, cancel := context.WithTimeout(ctx, CheckTimeout)
+			defer cancel()
+
+			err := check.Check(checkCtx)
+			result := checkResult{Status: "ok"}
+			if err != nil {
+				result = checkResult{Status: "failed", Error: err.Error()}
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			results[check.Name] = result
+			ready = ready && err == nil
+		})
+	}
+	wait.Wait()
+
+	return results, ready
+}
+
+// Serve runs the health server on addr until ctx is cancelled, then shuts it down.
+func Serve(ctx context.Context, addr string, checks []Check) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           Handler(checks),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("starting health server on " + addr)
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return stacktrace.Propagate(err, "health server failed on %s", addr)
+	}
+	return nil
+}
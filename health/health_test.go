@@ -0,0 +1,78 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dragonejt/hakase-discord/health"
+	"github.com/dragonejt/hakase-discord/metrics"
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthTestSuite struct {
+	suite.Suite
+	ready  bool
+	server *httptest.Server
+}
+
+func TestHealth(t *testing.T) {
+	suite.Run(t, new(HealthTestSuite))
+}
+
+func (testSuite *HealthTestSuite) SetupTest() {
+	testSuite.ready = true
+	testSuite.server = httptest.NewServer(health.Handler([]health.Check{
+		{Name: "discord", Check: func(ctx context.Context) error { return nil }},
+		{Name: "nats", Check: func(ctx context.Context) error {
+			if !testSuite.ready {
+				return errors.New("consumer not active")
+			}
+			return nil
+		}},
+	}))
+}
+
+func (testSuite *HealthTestSuite) TearDownTest() {
+	testSuite.server.Close()
+}
+
+func (testSuite *HealthTestSuite) TestHealthz() {
+	response, err := http.Get(testSuite.server.URL + "/healthz")
+	testSuite.Require().NoError(err)
+	defer response.Body.Close()
+	testSuite.Equal(http.StatusOK, response.StatusCode)
+}
+
+func (testSuite *HealthTestSuite) TestReadyz() {
+	response, err := http.Get(testSuite.server.URL + "/readyz")
+	testSuite.Require().NoError(err)
+	defer response.Body.Close()
+	testSuite.Equal(http.StatusOK, response.StatusCode)
+}
+
+func (testSuite *HealthTestSuite) TestReadyzFailedCheck() {
+	testSuite.ready = false
+	response, err := http.Get(testSuite.server.URL + "/readyz")
+	testSuite.Require().NoError(err)
+	defer response.Body.Close()
+	testSuite.Equal(http.StatusServiceUnavailable, response.StatusCode)
+
+	results := map[string]map[string]string{}
+	testSuite.Require().NoError(json.NewDecoder(response.Body).Decode(&results))
+	testSuite.Equal("ok", results["discord"]["status"])
+	testSuite.Equal("failed", results["nats"]["status"])
+	testSuite.Equal("consumer not active", results["nats"]["error"])
+}
+
+func (testSuite *HealthTestSuite) TestMetrics() {
+	metrics.RemindersSent.Inc()
+	response, err := http.Get(testSuite.server.URL + "/metrics")
+	testSuite.Require().NoError(err)
+	defer response.Body.Close()
+	testSuite.Equal(http.StatusOK, response.StatusCode)
+	testSuite.Contains(response.Header.Get("Content-Type"), "version=0.0.4")
+}
@@ -0,0 +1,65 @@
+// Package interactions provides handlers for announcement actions (acknowledge, acknowledgements summary).
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// AcknowledgeAnnouncement records that the member read an announcement, when they click its "got it" button.
+func AcknowledgeAnnouncement(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "acknowledgeAnnouncementAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("acknowledgeAnnouncement executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	announcementID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	err := hakaseClient.Backend.AcknowledgeAnnouncement(ctx, announcementID, interactionCreate.Member.User.ID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error acknowledging announcement: %s", announcementID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.acknowledge_announcement", err.Error()))
+		return
+	}
+	respondEphemeral(ctx, interactionCreate, locale.T("announcement.acknowledged"))
+}
+
+// AnnouncementAcknowledgements responds to staff with a summary of who acknowledged an announcement, visible only to them.
+func AnnouncementAcknowledgements(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "announcementAcknowledgementsAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("announcementAcknowledgements executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return
+	}
+
+	announcementID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	announcement, err := hakaseClient.Backend.ReadAnnouncement(ctx, announcementID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading announcement: %s", announcementID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.read_announcement", err.Error()))
+		return
+	}
+
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{views.AnnouncementAcknowledgementsView(locale, announcement)},
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
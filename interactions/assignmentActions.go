@@ -11,21 +11,33 @@ import (
 	"github.com/araddon/dateparse"
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/tracing"
 	"github.com/dragonejt/hakase-discord/views"
-	"github.com/getsentry/sentry-go"
 	"github.com/palantir/stacktrace"
 )
 
 // UpdateAssignment opens a modal for updating an assignment via Discord interaction.
 func UpdateAssignment(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), clients.DiscordSession{}, bot), "updateAssignmentAction")
-	defer transaction.Finish()
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "updateAssignmentAction")
+	defer span.End()
 	slog.Debug(fmt.Sprintf("updateAssignment executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+
+	assignmentID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	openUpdateAssignmentModal(ctx, interactionCreate, hakaseClient, assignmentID)
+}
+
+// openUpdateAssignmentModal responds with the update assignment modal for assignmentID if the member is an admin.
+func openUpdateAssignmentModal(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, assignmentID string) {
+	ctx, span := tracing.Start(ctx, "openUpdateAssignmentModal")
+	defer span.End()
+	bot := clients.Session(ctx)
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
 	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
 		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "admin permissions needed!",
+				Content: locale.T("admin_required"),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -35,8 +47,7 @@ func UpdateAssignment(bot *discordgo.Session, interactionCreate *discordgo.Inter
 		return
 	}
 
-	assignmentID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
-	assignment, err := hakaseClient.Backend.ReadAssignment(transaction, assignmentID)
+	assignment, err := hakaseClient.Backend.ReadAssignment(ctx, assignmentID)
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "error reading assignment").Error())
 		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
@@ -49,13 +60,14 @@ func UpdateAssignment(bot *discordgo.Session, interactionCreate *discordgo.Inter
 		if err != nil {
 			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
 		}
+		return
 	}
 	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseModal,
 		Data: &discordgo.InteractionResponseData{
 			CustomID:   fmt.Sprintf("updateAssignment_%s", assignmentID),
-			Title:      "update assignment",
-			Components: views.AssignmentModal(&assignment),
+			Title:      locale.T("modal.update_title"),
+			Components: views.AssignmentModal(locale, &assignment),
 		},
 	})
 	if err != nil {
@@ -66,8 +78,11 @@ func UpdateAssignment(bot *discordgo.Session, interactionCreate *discordgo.Inter
 // UpdateAssignmentSubmit handles the submission of the update assignment modal and updates the assignment.
 func UpdateAssignmentSubmit(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
 	slog.Info(fmt.Sprintf("updateAssignmentSubmit executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), clients.DiscordSession{}, bot), "updateAssignmentSubmit")
-	defer transaction.Finish()
+	// the interaction is the origin of the reminders it schedules, so their delivery continues this trace
+	ctx := clients.WithOrigin(clients.WithSession(context.Background(), bot), interactionCreate.Interaction)
+	ctx, span := tracing.Start(ctx, "updateAssignmentSubmit", clients.OriginFrom(ctx).Attributes()...)
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
 
 	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredMessageUpdate,
@@ -86,7 +101,7 @@ func UpdateAssignmentSubmit(bot *discordgo.Session, interactionCreate *discordgo
 		due, err := dateparse.ParseAny(assignmentData.Components[1].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value)
 		if err != nil {
 			_, err := bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
-				Content: fmt.Sprintf("error parsing due date: %s", err.Error()),
+				Content: locale.T("error.parse_due", err.Error()),
 			})
 			if err != nil {
 				slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
@@ -104,12 +119,26 @@ func UpdateAssignmentSubmit(bot *discordgo.Session, interactionCreate *discordgo
 		assignment.Link = assignmentData.Components[2].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
 	}
 
-	currentAssignment, err := hakaseClient.Backend.ReadAssignment(transaction, assignmentID)
+	if assignmentData.Components[3].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value != "" {
+		kind, err := clients.ParseAssignmentKind(assignmentData.Components[3].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value)
+		if err != nil {
+			_, err := bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
+				Content: locale.T("error.parse_kind", err.Error()),
+			})
+			if err != nil {
+				slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+			}
+			return
+		}
+		assignment.Kind = kind
+	}
+
+	currentAssignment, err := hakaseClient.Backend.ReadAssignment(ctx, assignmentID)
 	if assignment.Due.Equal(time.Time{}) {
 		assignment.Due = currentAssignment.Due
 	} else if err == nil && assignment.Due.Before(currentAssignment.Due) {
 		_, err := bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
-			Content: "new due date before original assignment due date! hakase does not support this.",
+			Content: locale.T("assignment.due_before_original"),
 		})
 		if err != nil {
 			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
@@ -118,7 +147,7 @@ func UpdateAssignmentSubmit(bot *discordgo.Session, interactionCreate *discordgo
 	}
 
 	assignment.ID = currentAssignment.ID
-	updatedAssignment, err := hakaseClient.Backend.UpdateAssignment(transaction, assignment)
+	updatedAssignment, err := hakaseClient.Backend.UpdateAssignment(ctx, assignment)
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "error updating assignment").Error())
 		_, err := bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
@@ -131,10 +160,20 @@ func UpdateAssignmentSubmit(bot *discordgo.Session, interactionCreate *discordgo
 		return
 	}
 
+	// an assignment changed into an exam or given a new due date needs reminders for its new kind and due date.
+	// reminders already scheduled for the old due date are dropped when they fire.
+	updatedAssignment.CourseID = interactionCreate.GuildID
+	content := locale.T("assignment.updated")
+	err = hakaseClient.Notifications.ScheduleAssignmentNotifications(ctx, updatedAssignment.UpcomingReminders(hakaseClient.Reminders, time.Now()))
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to schedule reminders for assignment: %d", updatedAssignment.ID).Error())
+		content = locale.T("assignment.updated_unscheduled")
+	}
+
 	_, err = bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
-		Content:    "assignment updated!",
-		Embeds:     []*discordgo.MessageEmbed{views.AssignmentView(interactionCreate.Member, updatedAssignment)},
-		Components: []discordgo.MessageComponent{views.AssignmentActions(updatedAssignment)},
+		Content:    content,
+		Embeds:     []*discordgo.MessageEmbed{views.AssignmentView(locale, interactionCreate.Member, updatedAssignment)},
+		Components: []discordgo.MessageComponent{views.AssignmentActions(locale, updatedAssignment)},
 	})
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
@@ -144,17 +183,41 @@ func UpdateAssignmentSubmit(bot *discordgo.Session, interactionCreate *discordgo
 // DeleteAssignment deletes an assignment based on user interaction.
 func DeleteAssignment(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
 	slog.Debug(fmt.Sprintf("deleteAssignment executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), clients.DiscordSession{}, bot), "deleteAssignmentAction")
-	defer transaction.Finish()
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "deleteAssignmentAction")
+	defer span.End()
 
 	assignmentID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
-	err := hakaseClient.Backend.DeleteAssignment(transaction, assignmentID)
+	deleteAssignment(ctx, interactionCreate, hakaseClient, assignmentID)
+}
+
+// deleteAssignment deletes assignmentID if the member is an admin and responds with the result.
+func deleteAssignment(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, assignmentID string) {
+	ctx, span := tracing.Start(ctx, "deleteAssignment")
+	defer span.End()
+	bot := clients.Session(ctx)
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: locale.T("admin_required"),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
+	err := hakaseClient.Backend.DeleteAssignment(ctx, assignmentID)
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "unable to delete assignment %s", assignmentID).Error())
 		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("unable to delete assignment %s: %s", assignmentID, err.Error()),
+				Content: locale.T("error.delete_assignment", assignmentID, err.Error()),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -167,7 +230,7 @@ func DeleteAssignment(bot *discordgo.Session, interactionCreate *discordgo.Inter
 	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("assignment %s deleted!", assignmentID),
+			Content: locale.T("assignment.deleted", assignmentID),
 		},
 	})
 	if err != nil {
@@ -10,21 +10,32 @@ import (
 	"github.com/araddon/dateparse"
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/tracing"
 	"github.com/dragonejt/hakase-discord/views"
-	"github.com/getsentry/sentry-go"
 	"github.com/palantir/stacktrace"
 )
 
 // AddAssignment opens a modal for adding a new assignment via Discord interaction.
-func AddAssignment(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate) {
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), clients.DiscordSession{}, bot), "addAssignmentAction")
-	defer transaction.Finish()
+func AddAssignment(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "addAssignmentAction")
+	defer span.End()
 	slog.Debug(fmt.Sprintf("addAssignment executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+
+	openAddAssignmentModal(ctx, interactionCreate, hakaseClient)
+}
+
+// openAddAssignmentModal responds with the add assignment modal if the member is an admin.
+func openAddAssignmentModal(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(ctx, "openAddAssignmentModal")
+	defer span.End()
+	bot := clients.Session(ctx)
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
 	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
 		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "admin permissions needed!",
+				Content: locale.T("admin_required"),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -38,21 +49,23 @@ func AddAssignment(bot *discordgo.Session, interactionCreate *discordgo.Interact
 		Type: discordgo.InteractionResponseModal,
 		Data: &discordgo.InteractionResponseData{
 			CustomID:   "addAssignment",
-			Title:      "add assignment",
-			Components: views.AssignmentModal(nil),
+			Title:      locale.T("modal.add_title"),
+			Components: views.AssignmentModal(locale, nil),
 		},
 	})
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
 	}
-
 }
 
 // AddAssignmentSubmit handles the submission of the add assignment modal and creates the assignment.
 func AddAssignmentSubmit(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
 	slog.Info(fmt.Sprintf("addAssignmentSubmit executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), clients.DiscordSession{}, bot), "addAssignmentSubmit")
-	defer transaction.Finish()
+	// the interaction is the origin of the reminders it schedules, so their delivery continues this trace
+	ctx := clients.WithOrigin(clients.WithSession(context.Background(), bot), interactionCreate.Interaction)
+	ctx, span := tracing.Start(ctx, "addAssignmentSubmit", clients.OriginFrom(ctx).Attributes()...)
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
 
 	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredMessageUpdate,
@@ -65,7 +78,7 @@ func AddAssignmentSubmit(bot *discordgo.Session, interactionCreate *discordgo.In
 	due, err := dateparse.ParseAny(assignmentData.Components[1].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value)
 	if err != nil {
 		_, err := bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
-			Content: fmt.Sprintf("error parsing due date: %s", err.Error()),
+			Content: locale.T("error.parse_due", err.Error()),
 		})
 		if err != nil {
 			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
@@ -83,9 +96,20 @@ func AddAssignmentSubmit(bot *discordgo.Session, interactionCreate *discordgo.In
 		assignment.Link = assignmentData.Components[2].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
 	}
 
+	assignment.Kind, err = clients.ParseAssignmentKind(assignmentData.Components[3].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value)
+	if err != nil {
+		_, err := bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
+			Content: locale.T("error.parse_kind", err.Error()),
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
 	if assignment.Due.Before(time.Now()) {
 		_, err := bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
-			Content: "due date before current time! hakase does not support this.",
+			Content: locale.T("assignment.due_in_past"),
 		})
 		if err != nil {
 			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
@@ -93,7 +117,7 @@ func AddAssignmentSubmit(bot *discordgo.Session, interactionCreate *discordgo.In
 		return
 	}
 
-	createdAssignment, err := hakaseClient.Backend.CreateAssignment(transaction, assignment)
+	createdAssignment, err := hakaseClient.Backend.CreateAssignment(ctx, assignment)
 	if err != nil {
 		_, err := bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
 			Content: err.Error(),
@@ -105,22 +129,18 @@ func AddAssignmentSubmit(bot *discordgo.Session, interactionCreate *discordgo.In
 		return
 	}
 
-	go hakaseClient.Notifications.PublishAssignmentNotification(transaction, clients.AssignmentNotification{
-		AssignmentID: createdAssignment.ID,
-		CourseID:     interactionCreate.GuildID,
-		Before:       time.Hour,
-	})
-
-	go hakaseClient.Notifications.PublishAssignmentNotification(transaction, clients.AssignmentNotification{
-		AssignmentID: createdAssignment.ID,
-		CourseID:     interactionCreate.GuildID,
-		Before:       time.Hour * 24,
-	})
+	createdAssignment.CourseID = interactionCreate.GuildID
+	content := locale.T("assignment.created")
+	err = hakaseClient.Notifications.ScheduleAssignmentNotifications(ctx, createdAssignment.UpcomingReminders(hakaseClient.Reminders, time.Now()))
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to schedule reminders for assignment: %d", createdAssignment.ID).Error())
+		content = locale.T("assignment.created_unscheduled")
+	}
 
 	_, err = bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
-		Content:    "assignment created!",
-		Embeds:     []*discordgo.MessageEmbed{views.AssignmentView(interactionCreate.Member, createdAssignment)},
-		Components: []discordgo.MessageComponent{views.AssignmentActions(createdAssignment)},
+		Content:    content,
+		Embeds:     []*discordgo.MessageEmbed{views.AssignmentView(locale, interactionCreate.Member, createdAssignment)},
+		Components: []discordgo.MessageComponent{views.AssignmentActions(locale, createdAssignment)},
 	})
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
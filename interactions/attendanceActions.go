@@ -0,0 +1,87 @@
+// Package interactions provides handlers for attendance actions (check in, end session).
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// CheckInAttendance checks the student in to an attendance session when they click its check-in button.
+func CheckInAttendance(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "checkInAttendanceAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("checkInAttendance executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	sessionID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	session, err := hakaseClient.Backend.ReadAttendanceSession(ctx, sessionID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading attendance session: %s", sessionID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.read_attendance", err.Error()))
+		return
+	}
+	checkIn(ctx, interactionCreate, hakaseClient, locale, session)
+}
+
+// EndAttendance ends an attendance session early when staff click its end button, so that students can no longer check in.
+func EndAttendance(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "endAttendanceAction")
+	defer span.End()
+	slog.Info(fmt.Sprintf("endAttendance executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return
+	}
+
+	sessionID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	session, err := hakaseClient.Backend.ReadAttendanceSession(ctx, sessionID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading attendance session: %s", sessionID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.read_attendance", err.Error()))
+		return
+	}
+
+	now := time.Now()
+	if session.Active(now) {
+		session.ExpiresAt = now.Truncate(time.Second)
+		_, err = hakaseClient.Backend.UpdateAttendanceSession(ctx, session)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error ending attendance session: %s", sessionID).Error())
+			respondEphemeral(ctx, interactionCreate, locale.T("error.end_attendance", err.Error()))
+			return
+		}
+	}
+
+	// the message is updated even if the session already expired, since it is not updated when sessions expire
+	attendanceLocale := courseLocale(ctx, interactionCreate.GuildID, hakaseClient.Backend)
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{views.AttendanceView(attendanceLocale, session, now)},
+			Components:      views.AttendanceActions(attendanceLocale, session, now),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
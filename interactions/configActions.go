@@ -1,28 +1,31 @@
-// Package interactions provides handlers for course config actions (update notify channel/role).
+// Package interactions provides handlers for course config actions (update notify channel/role, exam countdown, default locale).
 package interactions
 
 import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
 	"github.com/dragonejt/hakase-discord/views"
-	"github.com/getsentry/sentry-go"
 	"github.com/palantir/stacktrace"
 )
 
 // UpdateNotifyChannel updates the notifications channel for a course based on user interaction.
 func UpdateNotifyChannel(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), clients.DiscordSession{}, bot), "updateNotifyChannel")
-	defer transaction.Finish()
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "updateNotifyChannel")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
 	slog.Debug(fmt.Sprintf("updateNotifyChannel executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
 	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
 		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "admin permissions needed!",
+				Content: locale.T("admin_required"),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -33,7 +36,7 @@ func UpdateNotifyChannel(bot *discordgo.Session, interactionCreate *discordgo.In
 	}
 
 	notifyChannel := interactionCreate.MessageComponentData().Values[0]
-	err := hakaseClient.Backend.UpdateCourse(transaction, clients.Course{
+	err := hakaseClient.Backend.UpdateCourse(ctx, clients.Course{
 		CourseID:      interactionCreate.GuildID,
 		NotifyChannel: notifyChannel,
 	})
@@ -42,7 +45,7 @@ func UpdateNotifyChannel(bot *discordgo.Session, interactionCreate *discordgo.In
 		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("error updating course: %s", err.Error()),
+				Content: locale.T("error.update_course", err.Error()),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -51,13 +54,13 @@ func UpdateNotifyChannel(bot *discordgo.Session, interactionCreate *discordgo.In
 		}
 	}
 
-	updatedCourse, err := hakaseClient.Backend.ReadCourse(transaction, interactionCreate.GuildID)
+	updatedCourse, err := hakaseClient.Backend.ReadCourse(ctx, interactionCreate.GuildID)
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "error reading updated course").Error())
 		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("error reading updated course course: %s", err.Error()),
+				Content: locale.T("error.read_updated_course", err.Error()),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -69,8 +72,8 @@ func UpdateNotifyChannel(bot *discordgo.Session, interactionCreate *discordgo.In
 	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: "notifications channel updated!",
-			Embeds:  []*discordgo.MessageEmbed{views.ConfigView(updatedCourse)},
+			Content: locale.T("config.notify_channel_updated"),
+			Embeds:  []*discordgo.MessageEmbed{views.ConfigView(locale, updatedCourse)},
 		},
 	})
 	if err != nil {
@@ -80,14 +83,15 @@ func UpdateNotifyChannel(bot *discordgo.Session, interactionCreate *discordgo.In
 
 // UpdateNotifyRole updates the notifications role for a course based on user interaction.
 func UpdateNotifyRole(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), clients.DiscordSession{}, bot), "updateNotifyRole")
-	defer transaction.Finish()
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "updateNotifyRole")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
 	slog.Debug(fmt.Sprintf("updateNotifyRole executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
 	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
 		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "admin permissions needed!",
+				Content: locale.T("admin_required"),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -98,7 +102,7 @@ func UpdateNotifyRole(bot *discordgo.Session, interactionCreate *discordgo.Inter
 	}
 
 	notifyRole := interactionCreate.MessageComponentData().Values[0]
-	err := hakaseClient.Backend.UpdateCourse(transaction, clients.Course{
+	err := hakaseClient.Backend.UpdateCourse(ctx, clients.Course{
 		CourseID:    interactionCreate.GuildID,
 		NotifyGroup: notifyRole,
 	})
@@ -107,7 +111,7 @@ func UpdateNotifyRole(bot *discordgo.Session, interactionCreate *discordgo.Inter
 		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("error updating course: %s", err.Error()),
+				Content: locale.T("error.update_course", err.Error()),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -116,13 +120,13 @@ func UpdateNotifyRole(bot *discordgo.Session, interactionCreate *discordgo.Inter
 		}
 	}
 
-	updatedCourse, err := hakaseClient.Backend.ReadCourse(transaction, interactionCreate.GuildID)
+	updatedCourse, err := hakaseClient.Backend.ReadCourse(ctx, interactionCreate.GuildID)
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "error reading updated course").Error())
 		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("error reading updated course course: %s", err.Error()),
+				Content: locale.T("error.read_updated_course", err.Error()),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -134,11 +138,238 @@ func UpdateNotifyRole(bot *discordgo.Session, interactionCreate *discordgo.Inter
 	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: "notifications role updated!",
-			Embeds:  []*discordgo.MessageEmbed{views.ConfigView(updatedCourse)},
+			Content: locale.T("config.notify_role_updated"),
+			Embeds:  []*discordgo.MessageEmbed{views.ConfigView(locale, updatedCourse)},
 		},
 	})
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
 	}
 }
+
+// UpdateCourseLocale updates the default locale for a course based on user interaction.
+// The default locale is used for reminders, exam countdowns, and users whose Discord locale is not supported.
+func UpdateCourseLocale(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "updateCourseLocale")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+	slog.Debug(fmt.Sprintf("updateCourseLocale executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: locale.T("admin_required"),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
+	courseLocale := interactionCreate.MessageComponentData().Values[0]
+	err := hakaseClient.Backend.UpdateCourse(ctx, clients.Course{
+		CourseID: interactionCreate.GuildID,
+		Locale:   courseLocale,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error updating course").Error())
+		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: locale.T("error.update_course", err.Error()),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
+	updatedCourse, err := hakaseClient.Backend.ReadCourse(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading updated course").Error())
+		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: locale.T("error.read_updated_course", err.Error()),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
+	// users whose locale is not supported see the response in the new default locale
+	locale = locales.Resolve(string(interactionCreate.Locale), courseLocale)
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: locale.T("config.locale_updated"),
+			Embeds:  []*discordgo.MessageEmbed{views.ConfigView(locale, updatedCourse)},
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// UpdateCountdownChannel updates the exam countdown voice channel for a course based on user interaction.
+// The channel is renamed by the periodic countdown job.
+func UpdateCountdownChannel(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "updateCountdownChannel")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+	slog.Debug(fmt.Sprintf("updateCountdownChannel executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: locale.T("admin_required"),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
+	countdownChannel := interactionCreate.MessageComponentData().Values[0]
+	err := hakaseClient.Backend.UpdateCourse(ctx, clients.Course{
+		CourseID:         interactionCreate.GuildID,
+		CountdownChannel: countdownChannel,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error updating course").Error())
+		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: locale.T("error.update_course", err.Error()),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
+	updatedCourse, err := hakaseClient.Backend.ReadCourse(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading updated course").Error())
+		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: locale.T("error.read_updated_course", err.Error()),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: locale.T("config.countdown_channel_updated"),
+			Embeds:  []*discordgo.MessageEmbed{views.ConfigView(locale, updatedCourse)},
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// PinCountdown posts an exam countdown embed in the current channel, pins it, and saves it so the periodic countdown job keeps it updated.
+func PinCountdown(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "pinCountdownAction")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+	slog.Debug(fmt.Sprintf("pinCountdown executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: locale.T("admin_required"),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	assignments, err := hakaseClient.Backend.ListAssignments(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing assignments").Error())
+		_, err := bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
+			Content: locale.T("error.list_assignments", err.Error()),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
+	message, err := bot.ChannelMessageSendEmbed(interactionCreate.ChannelID, views.CountdownView(locale, assignments, time.Now()))
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error sending countdown").Error())
+		_, err := bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
+			Content: locale.T("error.send_countdown", err.Error()),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
+	err = bot.ChannelMessagePin(message.ChannelID, message.ID)
+	if err != nil {
+		slog.Warn(stacktrace.Propagate(err, "error pinning countdown").Error())
+	}
+
+	err = hakaseClient.Backend.UpdateCourse(ctx, clients.Course{
+		CourseID:            interactionCreate.GuildID,
+		CountdownPinChannel: message.ChannelID,
+		CountdownPinMessage: message.ID,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error updating course").Error())
+		_, err := bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
+			Content: locale.T("error.update_course", err.Error()),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
+	_, err = bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
+		Content: locale.T("config.countdown_pinned"),
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
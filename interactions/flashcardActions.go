@@ -0,0 +1,145 @@
+// Package interactions provides handlers for flashcard study actions (flip, grade).
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// FlipFlashcard shows the back of the card being studied when the student clicks its flip button, with buttons to grade their recall.
+func FlipFlashcard(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "flipFlashcardAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("flipFlashcard executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	deck, card, exists := readFlashcardAction(ctx, interactionCreate, hakaseClient, locale)
+	if !exists {
+		return
+	}
+	reviews, err := hakaseClient.Backend.ListFlashcardReviews(ctx, interactionCreate.GuildID, interactionCreate.Member.User.ID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing flashcard reviews").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.study_flashcards", err.Error()))
+		return
+	}
+	_, remaining, _ := clients.NextFlashcard(deck, reviews, time.Now())
+
+	editFlashcardMessage(ctx, interactionCreate, &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{views.FlashcardView(locale, deck, card, true, remaining)},
+		Components: views.FlashcardActions(locale, deck, card, true),
+	})
+}
+
+// GradeFlashcard records how well the student recalled a card when they click one of its grade buttons, schedules when they
+// should review it again, and shows them the next card to study. A reminder is scheduled for the day the card is next due,
+// which is shared by every card due that day.
+func GradeFlashcard(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	// the interaction is the origin of the reminder it schedules, so its delivery continues this trace
+	ctx := clients.WithOrigin(clients.WithSession(context.Background(), bot), interactionCreate.Interaction)
+	ctx, span := tracing.Start(ctx, "gradeFlashcardAction", clients.OriginFrom(ctx).Attributes()...)
+	defer span.End()
+	slog.Debug(fmt.Sprintf("gradeFlashcard executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	deck, card, exists := readFlashcardAction(ctx, interactionCreate, hakaseClient, locale)
+	if !exists {
+		return
+	}
+	grade := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[3]
+	reviews, err := hakaseClient.Backend.ListFlashcardReviews(ctx, interactionCreate.GuildID, interactionCreate.Member.User.ID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing flashcard reviews").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.grade_flashcard", err.Error()))
+		return
+	}
+
+	now := time.Now()
+	index := slices.IndexFunc(reviews, func(review clients.FlashcardReview) bool {
+		return review.DeckID == deck.ID && review.CardID == card.ID
+	})
+	review := clients.NewFlashcardReview(deck, card.ID, interactionCreate.Member.User.ID)
+	if index >= 0 {
+		review = reviews[index]
+	}
+	review, err = hakaseClient.Backend.UpdateFlashcardReview(ctx, review.Grade(grade, now))
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error updating flashcard review: %d", card.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.grade_flashcard", err.Error()))
+		return
+	}
+	if index >= 0 {
+		reviews[index] = review
+	} else {
+		reviews = append(reviews, review)
+	}
+
+	if review.IntervalDays > 0 {
+		err = hakaseClient.Notifications.ScheduleFlashcardReminder(ctx, clients.NewFlashcardReminderNotification(interactionCreate.GuildID, interactionCreate.Member.User.ID, review.DueAt))
+		if err != nil {
+			// the review was recorded, so the student can keep studying without a reminder
+			slog.Error(stacktrace.Propagate(err, "error scheduling flashcard reminder").Error())
+		}
+	}
+
+	editFlashcardMessage(ctx, interactionCreate, flashcardResponse(locale, deck, reviews, now))
+}
+
+// editFlashcardMessage replaces the flashcard message of a deferred button click with data.
+func editFlashcardMessage(ctx context.Context, interactionCreate *discordgo.InteractionCreate, data *discordgo.InteractionResponseData) {
+	_, err := clients.Session(ctx).InteractionResponseEdit(interactionCreate.Interaction, &discordgo.WebhookEdit{
+		Content:    &data.Content,
+		Embeds:     &data.Embeds,
+		Components: &data.Components,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// readFlashcardAction reads the deck and card of a deferred flashcard button click, and reports whether the card still exists.
+// If it does not, the student is told so.
+func readFlashcardAction(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale) (clients.FlashcardDeck, clients.Flashcard, bool) {
+	ids := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")
+	deck, visible := readFlashcardDeck(ctx, interactionCreate, hakaseClient, locale, ids[1], followupEphemeral)
+	if !visible {
+		return deck, clients.Flashcard{}, false
+	}
+	cardID, err := strconv.Atoi(ids[2])
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error parsing flashcard ID: %s", ids[2]).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("flashcards.deck_not_found"))
+		return deck, clients.Flashcard{}, false
+	}
+	card, exists := deck.Card(cardID)
+	if !exists {
+		followupEphemeral(ctx, interactionCreate, locale.T("flashcards.deck_not_found"))
+	}
+	return deck, card, exists
+}
@@ -0,0 +1,126 @@
+// Package interactions provides handlers for focus timer actions (join, pause, stop).
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// JoinFocus adds the member to a focus timer's participants when they click its join button, or removes them if they already joined.
+func JoinFocus(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "joinFocusAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("joinFocus executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	timer, running := readFocusAction(ctx, interactionCreate, hakaseClient, locale)
+	if !running {
+		return
+	}
+	timer.ToggleParticipant(interactionCreate.Member.User.ID)
+	saveFocusAction(ctx, interactionCreate, hakaseClient, locale, timer)
+}
+
+// PauseFocus pauses a running focus timer, or resumes a paused one, when a participant or staff clicks its pause button.
+func PauseFocus(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "pauseFocusAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("pauseFocus executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	timer, running := readFocusAction(ctx, interactionCreate, hakaseClient, locale)
+	if !running {
+		return
+	}
+	if !canControlFocus(interactionCreate, timer) {
+		respondEphemeral(ctx, interactionCreate, locale.T("focus.participants_only"))
+		return
+	}
+	if timer.Paused() {
+		timer.Resume(time.Now())
+	} else {
+		timer.Pause(time.Now())
+	}
+	saveFocusAction(ctx, interactionCreate, hakaseClient, locale, timer)
+}
+
+// StopFocus stops a focus timer early when a participant or staff clicks its stop button.
+func StopFocus(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "stopFocusAction")
+	defer span.End()
+	slog.Info(fmt.Sprintf("stopFocus executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	timer, running := readFocusAction(ctx, interactionCreate, hakaseClient, locale)
+	if !running {
+		return
+	}
+	if !canControlFocus(interactionCreate, timer) {
+		respondEphemeral(ctx, interactionCreate, locale.T("focus.participants_only"))
+		return
+	}
+
+	err := hakaseClient.Notifications.DeleteFocusTimer(ctx, timer)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error stopping focus timer: %s", timer.ChannelID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.stop_focus", err.Error()))
+		return
+	}
+	timer.Phase = clients.FocusStopped
+	updateFocusMessage(ctx, interactionCreate, hakaseClient, timer)
+}
+
+// readFocusAction reads the focus timer of the button clicked, and reports whether it is running.
+// If it is not, the member is told so.
+func readFocusAction(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale) (clients.FocusTimer, bool) {
+	channelID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	timer, running, err := hakaseClient.Notifications.ReadFocusTimer(ctx, interactionCreate.GuildID, channelID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading focus timer: %s", channelID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.read_focus", err.Error()))
+		return timer, false
+	}
+	if !running {
+		respondEphemeral(ctx, interactionCreate, locale.T("focus.not_running", fmt.Sprintf("<#%s>", channelID)))
+		return timer, false
+	}
+	return timer, true
+}
+
+// saveFocusAction saves a focus timer changed by a button, then updates its message.
+func saveFocusAction(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, timer clients.FocusTimer) {
+	timer, err := hakaseClient.Notifications.SaveFocusTimer(ctx, timer)
+	if err != nil {
+		// the timer changed since it was read, such as by its phase ending, so the member can click again
+		slog.Warn(stacktrace.Propagate(err, "error saving focus timer: %s", timer.ChannelID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.update_focus", err.Error()))
+		return
+	}
+	updateFocusMessage(ctx, interactionCreate, hakaseClient, timer)
+}
+
+// updateFocusMessage updates the message of the button clicked with the focus timer.
+func updateFocusMessage(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, timer clients.FocusTimer) {
+	focusLocale := courseLocale(ctx, interactionCreate.GuildID, hakaseClient.Backend)
+	err := clients.Session(ctx).InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{views.FocusTimerView(focusLocale, timer, time.Now())},
+			Components:      views.FocusTimerActions(focusLocale, timer),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
@@ -0,0 +1,71 @@
+// Package interactions provides fuzzy matching for autocomplete suggestions.
+package interactions
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// fuzzyScore scores how well query matches target as a case-insensitive subsequence.
+// Consecutive matches and matches at the start of a word score higher. It returns false if query is not a subsequence of target.
+func fuzzyScore(query string, target string) (int, bool) {
+	query, target = strings.ToLower(strings.TrimSpace(query)), strings.ToLower(target)
+	if query == "" {
+		return 0, true
+	}
+	if strings.Contains(target, query) {
+		// exact substring matches always rank above scattered subsequence matches
+		score := 100 + len(query)*10
+		if strings.HasPrefix(target, query) {
+			score += 50
+		}
+		return score, true
+	}
+
+	queryRunes, targetRunes := []rune(query), []rune(target)
+	score, queryIndex, previousMatch := 0, 0, -2
+	for targetIndex, targetRune := range targetRunes {
+		if queryIndex == len(queryRunes) {
+			break
+		}
+		if targetRune != queryRunes[queryIndex] {
+			continue
+		}
+		score++
+		if targetIndex == previousMatch+1 {
+			score += 5
+		}
+		if targetIndex == 0 || !unicode.IsLetter(targetRunes[targetIndex-1]) && !unicode.IsDigit(targetRunes[targetIndex-1]) {
+			score += 3
+		}
+		previousMatch = targetIndex
+		queryIndex++
+	}
+
+	return score, queryIndex == len(queryRunes)
+}
+
+// fuzzyFilter returns the indices of targets matching query, ordered from best to worst match.
+func fuzzyFilter(query string, targets []string) []int {
+	type match struct {
+		index int
+		score int
+	}
+	matches := []match{}
+	for index, target := range targets {
+		score, matched := fuzzyScore(query, target)
+		if matched {
+			matches = append(matches, match{index: index, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	indices := make([]int, len(matches))
+	for i, match := range matches {
+		indices[i] = match.index
+	}
+	return indices
+}
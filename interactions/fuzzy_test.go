@@ -0,0 +1,48 @@
+package interactions
+
+import (
+	"testing"
+
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/stretchr/testify/suite"
+)
+
+type FuzzyTestSuite struct {
+	suite.Suite
+}
+
+func TestFuzzy(t *testing.T) {
+	suite.Run(t, new(FuzzyTestSuite))
+}
+
+func (testSuite *FuzzyTestSuite) TestFuzzyScoreSubsequence() {
+	_, matched := fuzzyScore("hw3", "Homework 3")
+	testSuite.True(matched)
+
+	_, matched = fuzzyScore("quiz", "Homework 3")
+	testSuite.False(matched)
+}
+
+func (testSuite *FuzzyTestSuite) TestFuzzyScoreEmptyQuery() {
+	score, matched := fuzzyScore("  ", "Homework 3")
+	testSuite.True(matched)
+	testSuite.Zero(score)
+}
+
+func (testSuite *FuzzyTestSuite) TestFuzzyFilterOrdering() {
+	targets := []string{"Lab Report", "Homework 1", "Midterm", "homework 2 make up"}
+	testSuite.Equal([]int{1, 3}, fuzzyFilter("homework", targets))
+	testSuite.Equal([]int{2}, fuzzyFilter("mdtrm", targets))
+	testSuite.Equal([]int{1, 0}, fuzzyFilter("work", []string{"Homework 1", "Workshop"}))
+}
+
+func (testSuite *FuzzyTestSuite) TestSearchFAQ() {
+	entries := []clients.FAQEntry{
+		{Question: "When is the midterm?", Answer: "The midterm is on March 3rd in the lecture hall."},
+		{Question: "How do I submit homework?", Answer: "Upload a PDF to Gradescope before the deadline."},
+		{Question: "Is there a curve?", Answer: "Grades are curved after the midterm and final."},
+	}
+	testSuite.Equal([]int{0, 2}, searchFAQ("midterm", entries))
+	testSuite.Equal([]int{1}, searchFAQ("gradescope", entries))
+	testSuite.Equal([]int{0, 1, 2}, searchFAQ("", entries))
+}
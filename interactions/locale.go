@@ -0,0 +1,44 @@
+// Package interactions provides the locale used to respond to interactions and to describe application commands.
+package interactions
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/palantir/stacktrace"
+)
+
+// responseLocale returns the locale to respond to an interaction in: the user's locale if it is supported,
+// otherwise the course's default locale. The course is only read from the backend if the user's locale is not supported.
+func responseLocale(ctx context.Context, interaction *discordgo.Interaction, backend clients.BackendClient) locales.Locale {
+	if locale, supported := locales.Lookup(string(interaction.Locale)); supported {
+		return locale
+	}
+
+	course, err := backend.ReadCourse(ctx, interaction.GuildID)
+	if err != nil {
+		slog.Warn(stacktrace.Propagate(err, "error reading course locale: %s", interaction.GuildID).Error())
+		return locales.Default
+	}
+	return locales.Resolve(course.Locale)
+}
+
+// courseLocale returns the course's default locale, for messages shared by everyone in the course such as queues.
+func courseLocale(ctx context.Context, guildID string, backend clients.BackendClient) locales.Locale {
+	course, err := backend.ReadCourse(ctx, guildID)
+	if err != nil {
+		slog.Warn(stacktrace.Propagate(err, "error reading course locale: %s", guildID).Error())
+		return locales.Default
+	}
+	return locales.Resolve(course.Locale)
+}
+
+// localized returns the localizations of key for the description of an application command, or the name of a context menu command.
+// Slash command names are not localized, so that commands are the same in every language and match the documentation.
+func localized(key string) *map[discordgo.Locale]string {
+	localizations := locales.Localizations(key)
+	return &localizations
+}
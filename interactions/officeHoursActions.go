@@ -0,0 +1,253 @@
+// Package interactions provides handlers for office hours queue actions (join, leave, next, close).
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// refreshOfficeHours reads an office hours session and edits its queue message to match, in the course's default locale.
+// The session is returned even if editing the queue message fails.
+func refreshOfficeHours(ctx context.Context, backend clients.BackendClient, officeHoursID string) (clients.OfficeHours, error) {
+	ctx, span := tracing.Start(ctx, "refreshOfficeHours")
+	defer span.End()
+	bot := clients.Session(ctx)
+
+	officeHours, err := backend.ReadOfficeHours(ctx, officeHoursID)
+	if err != nil {
+		return officeHours, stacktrace.Propagate(err, "error reading office hours: %s", officeHoursID)
+	}
+	if officeHours.MessageID == "" {
+		return officeHours, nil
+	}
+
+	locale := courseLocale(ctx, officeHours.CourseID, backend)
+	embeds := []*discordgo.MessageEmbed{views.OfficeHoursQueueView(locale, officeHours)}
+	components := views.OfficeHoursActions(locale, officeHours)
+	_, err = bot.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:              officeHours.MessageID,
+		Channel:         officeHours.ChannelID,
+		Embeds:          &embeds,
+		Components:      &components,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return officeHours, stacktrace.Propagate(err, "error editing office hours queue message: %s", officeHours.MessageID)
+	}
+	return officeHours, nil
+}
+
+// JoinOfficeHours opens a modal for a student to join an office hours queue with their topic, unless they are already waiting.
+func JoinOfficeHours(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "joinOfficeHoursAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("joinOfficeHours executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	officeHoursID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	officeHours, err := hakaseClient.Backend.ReadOfficeHours(ctx, officeHoursID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading office hours: %s", officeHoursID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.read_office_hours", err.Error()))
+		return
+	}
+	if !officeHours.Open() {
+		respondEphemeral(ctx, interactionCreate, locale.T("officehours.not_open"))
+		return
+	}
+	if position := officeHours.Position(interactionCreate.Member.User.ID); position > 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("officehours.already_queued", position, locale.Duration(officeHours.EstimatedWait(position))))
+		return
+	}
+
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID:   fmt.Sprintf("joinOfficeHoursQueue_%s", officeHoursID),
+			Title:      locale.T("modal.queue_title"),
+			Components: views.OfficeHoursModal(locale),
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// JoinOfficeHoursSubmit handles the submission of the join queue modal, adding the student to the queue.
+func JoinOfficeHoursSubmit(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("joinOfficeHoursSubmit executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "joinOfficeHoursSubmit")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	officeHoursID := strings.Split(interactionCreate.ModalSubmitData().CustomID, "_")[1]
+	topic := interactionCreate.ModalSubmitData().Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
+	err = hakaseClient.Backend.JoinOfficeHoursQueue(ctx, officeHoursID, interactionCreate.Member.User.ID, topic)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error joining office hours queue: %s", officeHoursID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.join_office_hours", err.Error()))
+		return
+	}
+
+	officeHours, err := refreshOfficeHours(ctx, hakaseClient.Backend, officeHoursID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error updating office hours queue: %s", officeHoursID).Error())
+	}
+	position := officeHours.Position(interactionCreate.Member.User.ID)
+	followupEphemeral(ctx, interactionCreate, locale.T("officehours.joined", position, locale.Duration(officeHours.EstimatedWait(position))))
+}
+
+// LeaveOfficeHours removes a waiting student from an office hours queue.
+func LeaveOfficeHours(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "leaveOfficeHoursAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("leaveOfficeHours executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	officeHoursID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	officeHours, err := hakaseClient.Backend.ReadOfficeHours(ctx, officeHoursID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading office hours: %s", officeHoursID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.read_office_hours", err.Error()))
+		return
+	}
+	if officeHours.Position(interactionCreate.Member.User.ID) == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("officehours.not_queued"))
+		return
+	}
+
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	err = hakaseClient.Backend.LeaveOfficeHoursQueue(ctx, officeHoursID, interactionCreate.Member.User.ID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error leaving office hours queue: %s", officeHoursID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.leave_office_hours", err.Error()))
+		return
+	}
+
+	_, err = refreshOfficeHours(ctx, hakaseClient.Backend, officeHoursID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error updating office hours queue: %s", officeHoursID).Error())
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("officehours.left"))
+}
+
+// NextOfficeHours pulls the first waiting student from an office hours queue for the staff member,
+// and sends the student a link to the voice channel in a DM, or mentions them in the queue's channel if their DMs are closed.
+func NextOfficeHours(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "nextOfficeHoursAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("nextOfficeHours executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return
+	}
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	officeHoursID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	officeHours, err := hakaseClient.Backend.ReadOfficeHours(ctx, officeHoursID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading office hours: %s", officeHoursID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.read_office_hours", err.Error()))
+		return
+	}
+	if !officeHours.Open() {
+		followupEphemeral(ctx, interactionCreate, locale.T("officehours.not_open"))
+		return
+	}
+	if len(officeHours.Waiting()) == 0 {
+		followupEphemeral(ctx, interactionCreate, locale.T("officehours.queue_empty"))
+		return
+	}
+
+	entry, err := hakaseClient.Backend.NextInOfficeHoursQueue(ctx, officeHoursID, interactionCreate.Member.User.ID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error pulling next student from office hours queue: %s", officeHoursID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.next_office_hours", err.Error()))
+		return
+	}
+
+	// the student's locale is not known outside of their interactions, so they are called in the course's default locale
+	studentLocale := courseLocale(ctx, interactionCreate.GuildID, hakaseClient.Backend)
+	voiceLink := fmt.Sprintf("https://discord.com/channels/%s/%s", interactionCreate.GuildID, officeHours.VoiceChannelID)
+	yourTurn := studentLocale.T("officehours.your_turn", fmt.Sprintf("<@%s>", interactionCreate.Member.User.ID), voiceLink)
+	content := locale.T("officehours.next", fmt.Sprintf("<@%s>", entry.UserID), entry.Topic)
+	dmChannel, err := bot.UserChannelCreate(entry.UserID)
+	if err == nil {
+		_, err = bot.ChannelMessageSend(dmChannel.ID, yourTurn)
+	}
+	if err != nil {
+		slog.Warn(stacktrace.Propagate(err, "error sending office hours DM to: %s", entry.UserID).Error())
+		_, err = bot.ChannelMessageSendComplex(officeHours.ChannelID, &discordgo.MessageSend{
+			Content:         fmt.Sprintf("<@%s> %s", entry.UserID, yourTurn),
+			AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{entry.UserID}},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error mentioning student in office hours channel: %s", entry.UserID).Error())
+		}
+		content = locale.T("officehours.next_no_dm", fmt.Sprintf("<@%s>", entry.UserID), entry.Topic)
+	}
+
+	_, err = refreshOfficeHours(ctx, hakaseClient.Backend, officeHoursID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error updating office hours queue: %s", officeHoursID).Error())
+	}
+	followupEphemeral(ctx, interactionCreate, content)
+}
+
+// CloseOfficeHours closes an office hours session from its queue message.
+func CloseOfficeHours(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "closeOfficeHoursAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("closeOfficeHours executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return
+	}
+
+	officeHoursID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	officeHours, err := hakaseClient.Backend.ReadOfficeHours(ctx, officeHoursID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading office hours: %s", officeHoursID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.read_office_hours", err.Error()))
+		return
+	}
+	if !officeHours.Open() {
+		respondEphemeral(ctx, interactionCreate, locale.T("officehours.not_open"))
+		return
+	}
+	closeOfficeHours(ctx, interactionCreate, hakaseClient, locale, officeHours)
+}
@@ -0,0 +1,152 @@
+// Package interactions provides handlers for poll actions (vote, close, export results).
+package interactions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/palantir/stacktrace"
+)
+
+// VotePoll records the member's vote when they choose options in a poll's menu, replacing their earlier vote, then updates the poll's results.
+func VotePoll(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "votePollAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("votePoll executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	pollID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	poll, err := hakaseClient.Backend.ReadPoll(ctx, pollID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading poll: %s", pollID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.read_poll", err.Error()))
+		return
+	}
+	if !poll.Accepting(time.Now()) {
+		followupEphemeral(ctx, interactionCreate, locale.T("poll.not_accepting"))
+		return
+	}
+
+	values := interactionCreate.MessageComponentData().Values
+	options := make([]int, len(values))
+	for index, value := range values {
+		options[index], err = strconv.Atoi(value)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "invalid poll option: %s", value).Error())
+			followupEphemeral(ctx, interactionCreate, locale.T("error.vote_poll", err.Error()))
+			return
+		}
+	}
+
+	err = hakaseClient.Backend.VotePoll(ctx, pollID, interactionCreate.Member.User.ID, options)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error voting in poll: %s", pollID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.vote_poll", err.Error()))
+		return
+	}
+
+	_, err = clients.RefreshPoll(ctx, hakaseClient.Backend, pollID)
+	if err != nil {
+		// the vote is recorded, and the results are shown correctly after the next vote
+		slog.Warn(stacktrace.Propagate(err, "failed to refresh poll: %s", pollID).Error())
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("poll.voted"))
+}
+
+// ClosePoll closes a poll early when staff click its close button, showing its final results.
+func ClosePoll(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "closePollAction")
+	defer span.End()
+	slog.Info(fmt.Sprintf("closePoll executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return
+	}
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	pollID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	poll, err := hakaseClient.Backend.ReadPoll(ctx, pollID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading poll: %s", pollID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.read_poll", err.Error()))
+		return
+	}
+	if poll.Closed() {
+		followupEphemeral(ctx, interactionCreate, locale.T("poll.already_closed"))
+		return
+	}
+
+	_, err = clients.ClosePoll(ctx, hakaseClient.Backend, poll)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error closing poll: %s", pollID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.close_poll", err.Error()))
+		return
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("poll.closed_early"))
+}
+
+// ExportPoll responds to staff with a poll's results as a CSV file, visible only to them.
+func ExportPoll(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "exportPollAction")
+	defer span.End()
+	slog.Info(fmt.Sprintf("exportPoll executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return
+	}
+
+	pollID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	poll, err := hakaseClient.Backend.ReadPoll(ctx, pollID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading poll: %s", pollID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.read_poll", err.Error()))
+		return
+	}
+	results, err := poll.ResultsCSV()
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error exporting poll: %s", pollID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.export_poll", err.Error()))
+		return
+	}
+
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: locale.T("poll.exported", len(poll.Votes)),
+			Files: []*discordgo.File{
+				{Name: fmt.Sprintf("poll-%d.csv", poll.ID), ContentType: "text/csv", Reader: bytes.NewReader(results)},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
@@ -0,0 +1,122 @@
+// Package interactions provides handlers for question review actions (approve, reject).
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// ApproveQuestion approves a question from its review message, and posts it anonymously in the course's question channel.
+func ApproveQuestion(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "approveQuestionAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("approveQuestion executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	question, course, reviewable := reviewQuestion(ctx, interactionCreate, hakaseClient, locale)
+	if !reviewable {
+		return
+	}
+	if course.QuestionChannel == "" {
+		followupEphemeral(ctx, interactionCreate, locale.T("question.not_configured"))
+		return
+	}
+
+	question.Status, question.ReviewedBy = clients.QuestionApproved, interactionCreate.Member.User.ID
+	questionLocale := locales.Resolve(course.Locale)
+	question, err := postQuestion(ctx, hakaseClient.Backend, questionLocale, question, course.QuestionChannel)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error posting question: %d", question.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.post_question", err.Error()))
+		return
+	}
+
+	editQuestionReview(ctx, interactionCreate, questionLocale, question)
+	followupEphemeral(ctx, interactionCreate, locale.T("question.approved", fmt.Sprintf("<#%s>", question.ChannelID)))
+}
+
+// RejectQuestion rejects a question from its review message, so it is never posted.
+func RejectQuestion(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "rejectQuestionAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("rejectQuestion executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	question, course, reviewable := reviewQuestion(ctx, interactionCreate, hakaseClient, locale)
+	if !reviewable {
+		return
+	}
+
+	question.Status, question.ReviewedBy = clients.QuestionRejected, interactionCreate.Member.User.ID
+	question, err := hakaseClient.Backend.UpdateQuestion(ctx, question)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error rejecting question: %d", question.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.update_question", err.Error()))
+		return
+	}
+
+	editQuestionReview(ctx, interactionCreate, locales.Resolve(course.Locale), question)
+	followupEphemeral(ctx, interactionCreate, locale.T("question.rejected"))
+}
+
+// reviewQuestion checks that a staff member is reviewing a pending question, deferring the response once they are.
+// It returns the question and its course, and whether the question can be reviewed. If it cannot, the staff member was told why.
+func reviewQuestion(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale) (clients.Question, clients.Course, bool) {
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return clients.Question{}, clients.Course{}, false
+	}
+
+	err := clients.Session(ctx).InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	questionID := strings.Split(interactionCreate.MessageComponentData().CustomID, "_")[1]
+	question, err := hakaseClient.Backend.ReadQuestion(ctx, questionID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading question: %s", questionID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.read_question", err.Error()))
+		return question, clients.Course{}, false
+	}
+	if question.Status != clients.QuestionPending {
+		followupEphemeral(ctx, interactionCreate, locale.T("question.already_reviewed"))
+		return question, clients.Course{}, false
+	}
+
+	course, err := hakaseClient.Backend.ReadCourse(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading course").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.read_course", err.Error()))
+		return question, course, false
+	}
+	return question, course, true
+}
+
+// editQuestionReview edits a question's review message to show who reviewed it, removing the review buttons.
+func editQuestionReview(ctx context.Context, interactionCreate *discordgo.InteractionCreate, locale locales.Locale, question clients.Question) {
+	embeds := []*discordgo.MessageEmbed{views.QuestionReviewView(locale, question)}
+	components := views.QuestionReviewActions(locale, question)
+	_, err := clients.Session(ctx).ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:              interactionCreate.Message.ID,
+		Channel:         interactionCreate.ChannelID,
+		Embeds:          &embeds,
+		Components:      &components,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error editing question review message: %d", question.ID).Error())
+	}
+}
@@ -0,0 +1,209 @@
+// Package interactions provides handlers for the /announce slash command.
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// staffPermissions are the permissions a member needs to see staff commands. Handlers still check them, since server admins can override them.
+var staffPermissions int64 = discordgo.PermissionAdministrator
+
+var AnnounceCommand = discordgo.ApplicationCommand{
+	Name:                     "announce",
+	Description:              locales.Default.T("command.announce.description"),
+	DescriptionLocalizations: localized("command.announce.description"),
+	DefaultMemberPermissions: &staffPermissions,
+	Type:                     discordgo.ChatApplicationCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:                     "channel",
+			Description:              locales.Default.T("command.announce.channel.description"),
+			DescriptionLocalizations: locales.Localizations("command.announce.channel.description"),
+			Type:                     discordgo.ApplicationCommandOptionChannel,
+			ChannelTypes:             []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+		},
+		{
+			Name:                     "role",
+			Description:              locales.Default.T("command.announce.role.description"),
+			DescriptionLocalizations: locales.Localizations("command.announce.role.description"),
+			Type:                     discordgo.ApplicationCommandOptionRole,
+		},
+		{
+			Name:                     "schedule",
+			Description:              locales.Default.T("command.announce.schedule.description"),
+			DescriptionLocalizations: locales.Localizations("command.announce.schedule.description"),
+			Type:                     discordgo.ApplicationCommandOptionString,
+		},
+	},
+}
+
+// SlashAnnounce handles the /announce slash command interaction.
+// It validates the options and opens the announcement modal, carrying the channel, role and schedule in the modal's custom ID.
+func SlashAnnounce(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("/announce executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/announce")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return
+	}
+
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(interactionCreate.ApplicationCommandData().Options))
+	for _, opt := range interactionCreate.ApplicationCommandData().Options {
+		optionMap[opt.Name] = opt
+	}
+
+	channelID, roleID, scheduledFor := "", "", int64(0)
+	if opt, exists := optionMap["channel"]; exists {
+		channelID = opt.ChannelValue(nil).ID
+	}
+	if opt, exists := optionMap["role"]; exists {
+		roleID = opt.RoleValue(nil, "").ID
+	}
+	if opt, exists := optionMap["schedule"]; exists {
+		schedule, err := dateparse.ParseAny(opt.StringValue())
+		if err != nil {
+			respondEphemeral(ctx, interactionCreate, locale.T("error.parse_schedule", err.Error()))
+			return
+		}
+		if schedule.Before(time.Now()) {
+			respondEphemeral(ctx, interactionCreate, locale.T("announcement.schedule_in_past"))
+			return
+		}
+		scheduledFor = schedule.Unix()
+	}
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID:   fmt.Sprintf("announce_%s_%s_%d", channelID, roleID, scheduledFor),
+			Title:      locale.T("modal.announce_title"),
+			Components: views.AnnouncementModal(locale),
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// AnnounceSubmit handles the submission of the announcement modal. It creates the announcement,
+// then posts it, or schedules it to be posted by the reminder scheduler if it is scheduled for later.
+// Announcements are posted to the chosen channel, the course's notifications channel, or the channel /announce was used in.
+func AnnounceSubmit(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("announceSubmit executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	// the interaction is the origin of the announcement it schedules, so posting it continues this trace
+	ctx := clients.WithOrigin(clients.WithSession(context.Background(), bot), interactionCreate.Interaction)
+	ctx, span := tracing.Start(ctx, "announceSubmit", clients.OriginFrom(ctx).Attributes()...)
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	customID := strings.Split(interactionCreate.ModalSubmitData().CustomID, "_")
+	scheduledFor, err := strconv.ParseInt(customID[3], 10, 64)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "invalid announcement schedule: %s", customID[3]).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.parse_schedule", err.Error()))
+		return
+	}
+
+	announcementData := interactionCreate.ModalSubmitData()
+	announcement := clients.Announcement{
+		CourseID:     interactionCreate.GuildID,
+		AuthorID:     interactionCreate.Member.User.ID,
+		ChannelID:    customID[1],
+		RoleID:       customID[2],
+		Title:        announcementData.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value,
+		Message:      announcementData.Components[1].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value,
+		ScheduledFor: time.Now().Truncate(time.Second),
+	}
+	scheduled := scheduledFor > 0
+	if scheduled {
+		announcement.ScheduledFor = time.Unix(scheduledFor, 0)
+	}
+	if announcement.ChannelID == "" {
+		announcement.ChannelID = interactionCreate.ChannelID
+		course, err := hakaseClient.Backend.ReadCourse(ctx, interactionCreate.GuildID)
+		if err != nil {
+			slog.Warn(stacktrace.Propagate(err, "error reading course notifications channel: %s", interactionCreate.GuildID).Error())
+		} else if course.NotifyChannel != "" {
+			announcement.ChannelID = course.NotifyChannel
+		}
+	}
+
+	createdAnnouncement, err := hakaseClient.Backend.CreateAnnouncement(ctx, announcement)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error creating announcement").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.create_announcement", err.Error()))
+		return
+	}
+
+	if scheduled {
+		err = hakaseClient.Notifications.ScheduleAnnouncement(ctx, clients.AnnouncementNotification{
+			AnnouncementID: createdAnnouncement.ID,
+			CourseID:       interactionCreate.GuildID,
+			ScheduledFor:   createdAnnouncement.ScheduledFor,
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "failed to schedule announcement: %d", createdAnnouncement.ID).Error())
+			followupEphemeral(ctx, interactionCreate, locale.T("error.schedule_announcement", err.Error()))
+			return
+		}
+		followupEphemeral(ctx, interactionCreate, locale.T("announcement.scheduled", fmt.Sprintf("<#%s>", createdAnnouncement.ChannelID), fmt.Sprintf("<t:%d:F>", createdAnnouncement.ScheduledFor.Unix())))
+		return
+	}
+
+	_, err = clients.PostAnnouncement(ctx, hakaseClient.Backend, createdAnnouncement)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to post announcement: %d", createdAnnouncement.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.post_announcement", err.Error()))
+		return
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("announcement.posted", fmt.Sprintf("<#%s>", createdAnnouncement.ChannelID)))
+}
+
+// respondEphemeral responds to an interaction with a message only the user can see.
+func respondEphemeral(ctx context.Context, interactionCreate *discordgo.InteractionCreate, content string) {
+	err := clients.Session(ctx).InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// followupEphemeral sends a followup message only the user can see, after the interaction's response was deferred.
+func followupEphemeral(ctx context.Context, interactionCreate *discordgo.InteractionCreate, content string) {
+	_, err := clients.Session(ctx).FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
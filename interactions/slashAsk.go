@@ -0,0 +1,283 @@
+// Package interactions provides handlers for the /ask and /questions slash commands.
+package interactions
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// auditPermissions are the permissions a member needs to see who asked a question, for handling abuse.
+var auditPermissions int64 = discordgo.PermissionModerateMembers
+
+// questionThreadArchive is how long, in minutes, a question's thread stays open without new answers.
+const questionThreadArchive = 10080
+
+var AskCommand = discordgo.ApplicationCommand{
+	Name:                     "ask",
+	Description:              locales.Default.T("command.ask.description"),
+	DescriptionLocalizations: localized("command.ask.description"),
+	Type:                     discordgo.ChatApplicationCommand,
+}
+
+var QuestionsCommand = discordgo.ApplicationCommand{
+	Name:                     "questions",
+	Description:              locales.Default.T("command.questions.description"),
+	DescriptionLocalizations: localized("command.questions.description"),
+	DefaultMemberPermissions: &auditPermissions,
+	Type:                     discordgo.ChatApplicationCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:                     "config",
+			Description:              locales.Default.T("command.questions.config.description"),
+			DescriptionLocalizations: locales.Localizations("command.questions.config.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "channel",
+					Description:              locales.Default.T("command.questions.channel.description"),
+					DescriptionLocalizations: locales.Localizations("command.questions.channel.description"),
+					Type:                     discordgo.ApplicationCommandOptionChannel,
+					ChannelTypes:             []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					Required:                 true,
+				},
+				{
+					Name:                     "review",
+					Description:              locales.Default.T("command.questions.review.description"),
+					DescriptionLocalizations: locales.Localizations("command.questions.review.description"),
+					Type:                     discordgo.ApplicationCommandOptionChannel,
+					ChannelTypes:             []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+			},
+		},
+		{
+			Name:                     "asker",
+			Description:              locales.Default.T("command.questions.asker.description"),
+			DescriptionLocalizations: locales.Localizations("command.questions.asker.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "id",
+					Description:              locales.Default.T("command.questions.id.description"),
+					DescriptionLocalizations: locales.Localizations("command.questions.id.description"),
+					Type:                     discordgo.ApplicationCommandOptionInteger,
+					Required:                 true,
+				},
+			},
+		},
+	},
+}
+
+// SlashAsk handles the /ask slash command interaction by opening the question modal.
+// Neither /ask nor its submission log who asked, so that questions stay anonymous outside the audit store.
+func SlashAsk(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("/ask executed in %s", interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/ask")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID:   "askQuestion",
+			Title:      locale.T("modal.question_title"),
+			Components: views.QuestionModal(locale),
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// AskSubmit handles the submission of the question modal. It creates the question with its asker recorded in the audit store,
+// then posts it anonymously, or sends it to the course's review channel for staff to approve first.
+func AskSubmit(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("askSubmit executed in %s", interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "askSubmit")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	course, err := hakaseClient.Backend.ReadCourse(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading course").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.read_course", err.Error()))
+		return
+	}
+	if course.QuestionChannel == "" {
+		followupEphemeral(ctx, interactionCreate, locale.T("question.not_configured"))
+		return
+	}
+
+	reviewChannel := course.ReviewChannel()
+	question := clients.Question{
+		CourseID: interactionCreate.GuildID,
+		Text:     interactionCreate.ModalSubmitData().Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value,
+		Status:   clients.QuestionApproved,
+		AskedAt:  time.Now().Truncate(time.Second),
+	}
+	if reviewChannel != "" {
+		question.Status = clients.QuestionPending
+	}
+	question, err = hakaseClient.Backend.CreateQuestion(ctx, question, interactionCreate.Member.User.ID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error creating question").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.create_question", err.Error()))
+		return
+	}
+
+	questionLocale := locales.Resolve(course.Locale)
+	if reviewChannel != "" {
+		_, err = bot.ChannelMessageSendComplex(reviewChannel, &discordgo.MessageSend{
+			Embeds:          []*discordgo.MessageEmbed{views.QuestionReviewView(questionLocale, question)},
+			Components:      views.QuestionReviewActions(questionLocale, question),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error sending question for review: %d", question.ID).Error())
+			followupEphemeral(ctx, interactionCreate, locale.T("error.post_question", err.Error()))
+			return
+		}
+		followupEphemeral(ctx, interactionCreate, locale.T("question.submitted"))
+		return
+	}
+
+	question, err = postQuestion(ctx, hakaseClient.Backend, questionLocale, question, course.QuestionChannel)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error posting question: %d", question.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.post_question", err.Error()))
+		return
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("question.posted", fmt.Sprintf("<#%s>", cmp.Or(question.ThreadID, question.ChannelID))))
+}
+
+// postQuestion posts an approved question anonymously in channelID, starts a thread under it for answers,
+// and records where it was posted. Failing to start the thread or record the question is only logged, since the question was posted.
+func postQuestion(ctx context.Context, backend clients.BackendClient, locale locales.Locale, question clients.Question, channelID string) (clients.Question, error) {
+	ctx, span := tracing.Start(ctx, "postQuestion")
+	defer span.End()
+	bot := clients.Session(ctx)
+
+	message, err := bot.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{views.QuestionView(locale, question)},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return question, stacktrace.Propagate(err, "error sending question: %d", question.ID)
+	}
+	question.ChannelID, question.MessageID = message.ChannelID, message.ID
+
+	thread, err := bot.MessageThreadStart(message.ChannelID, message.ID, locale.T("question.thread_name", question.ID), questionThreadArchive)
+	if err != nil {
+		slog.Warn(stacktrace.Propagate(err, "error starting question thread: %d", question.ID).Error())
+	} else {
+		question.ThreadID = thread.ID
+	}
+
+	updatedQuestion, err := backend.UpdateQuestion(ctx, question)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error recording posted question: %d", question.ID).Error())
+		return question, nil
+	}
+	return updatedQuestion, nil
+}
+
+// SlashQuestions handles the /questions slash command interaction.
+// It dispatches the config subcommand, which is for staff only, and the asker subcommand, which needs the audit permissions.
+func SlashQuestions(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("/questions executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/questions")
+	defer span.End()
+
+	subcommand := interactionCreate.ApplicationCommandData().Options[0]
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(subcommand.Options))
+	for _, opt := range subcommand.Options {
+		optionMap[opt.Name] = opt
+	}
+
+	switch subcommand.Name {
+	case "config":
+		configQuestions(ctx, interactionCreate, hakaseClient, optionMap)
+	case "asker":
+		questionAsker(ctx, interactionCreate, hakaseClient, optionMap["id"].IntValue())
+	default:
+		slog.Error(fmt.Sprintf("unknown /questions subcommand: %s", subcommand.Name))
+	}
+}
+
+// configQuestions sets the channel questions are posted in, and the channel staff review them in, or turns review off if there is none.
+func configQuestions(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	ctx, span := tracing.Start(ctx, "/questions configQuestions")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return
+	}
+
+	questionChannel, reviewChannel := optionMap["channel"].ChannelValue(nil).ID, ""
+	if opt, exists := optionMap["review"]; exists {
+		reviewChannel = opt.ChannelValue(nil).ID
+	}
+	err := hakaseClient.Backend.UpdateCourse(ctx, clients.Course{
+		CourseID:              interactionCreate.GuildID,
+		QuestionChannel:       questionChannel,
+		QuestionReviewChannel: &reviewChannel,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error updating course").Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.update_course", err.Error()))
+		return
+	}
+
+	if reviewChannel == "" {
+		respondEphemeral(ctx, interactionCreate, locale.T("question.configured", fmt.Sprintf("<#%s>", questionChannel)))
+		return
+	}
+	respondEphemeral(ctx, interactionCreate, locale.T("question.configured_review", fmt.Sprintf("<#%s>", questionChannel), fmt.Sprintf("<#%s>", reviewChannel)))
+}
+
+// questionAsker responds with who asked a question, visible only to the staff member, for handling abuse.
+// Every lookup is logged, and the backend records it in the audit store.
+func questionAsker(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, questionID int64) {
+	ctx, span := tracing.Start(ctx, "/questions questionAsker")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	if interactionCreate.Member.Permissions&(discordgo.PermissionAdministrator|auditPermissions) == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("question.audit_required"))
+		return
+	}
+
+	slog.Info(fmt.Sprintf("asker of question %d viewed by %s (%s) in %s", questionID, interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	audit, err := hakaseClient.Backend.ReadQuestionAudit(ctx, interactionCreate.GuildID, fmt.Sprint(questionID), interactionCreate.Member.User.ID)
+	if errors.Is(err, clients.ErrQuestionNotInCourse) {
+		slog.Warn(fmt.Sprintf("asker of question %d from another course requested by %s (%s) in %s", questionID, interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+		respondEphemeral(ctx, interactionCreate, locale.T("question.not_found", questionID))
+		return
+	}
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading question audit: %d", questionID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.read_question_audit", err.Error()))
+		return
+	}
+	respondEphemeral(ctx, interactionCreate, locale.T("question.asker", audit.QuestionID, fmt.Sprintf("<@%s>", audit.AskerID), fmt.Sprintf("<t:%d:f>", audit.AskedAt.Unix())))
+}
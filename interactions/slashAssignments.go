@@ -8,52 +8,181 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
 	"github.com/dragonejt/hakase-discord/views"
-	"github.com/getsentry/sentry-go"
 	"github.com/palantir/stacktrace"
 )
 
+// assignmentOption is the autocompleted option used by subcommands that act on a single assignment.
+var assignmentOption = &discordgo.ApplicationCommandOption{
+	Name:                     "assignment",
+	Description:              locales.Default.T("command.assignments.assignment.description"),
+	DescriptionLocalizations: locales.Localizations("command.assignments.assignment.description"),
+	Type:                     discordgo.ApplicationCommandOptionInteger,
+	Required:                 true,
+	Autocomplete:             true,
+}
+
 var AssignmentsCommand = discordgo.ApplicationCommand{
-	Name:        "assignments",
-	Description: "configure assignments for due date notifications",
-	Type:        discordgo.ChatApplicationCommand,
+	Name:                     "assignments",
+	Description:              locales.Default.T("command.assignments.description"),
+	DescriptionLocalizations: localized("command.assignments.description"),
+	Type:                     discordgo.ChatApplicationCommand,
 	Options: []*discordgo.ApplicationCommandOption{
 		{
-			Name:        "id",
-			Description: "retrieves assignment with this id",
-			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:                     "view",
+			Description:              locales.Default.T("command.assignments.view.description"),
+			DescriptionLocalizations: locales.Localizations("command.assignments.view.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options:                  []*discordgo.ApplicationCommandOption{assignmentOption},
+		},
+		{
+			Name:                     "add",
+			Description:              locales.Default.T("command.assignments.add.description"),
+			DescriptionLocalizations: locales.Localizations("command.assignments.add.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+		},
+		{
+			Name:                     "edit",
+			Description:              locales.Default.T("command.assignments.edit.description"),
+			DescriptionLocalizations: locales.Localizations("command.assignments.edit.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options:                  []*discordgo.ApplicationCommandOption{assignmentOption},
+		},
+		{
+			Name:                     "delete",
+			Description:              locales.Default.T("command.assignments.delete.description"),
+			DescriptionLocalizations: locales.Localizations("command.assignments.delete.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options:                  []*discordgo.ApplicationCommandOption{assignmentOption},
+		},
+		{
+			Name:                     "list",
+			Description:              locales.Default.T("command.assignments.list.description"),
+			DescriptionLocalizations: locales.Localizations("command.assignments.list.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+		},
+		{
+			Name:                     "search",
+			Description:              locales.Default.T("command.assignments.search.description"),
+			DescriptionLocalizations: locales.Localizations("command.assignments.search.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "query",
+					Description:              locales.Default.T("command.assignments.query.description"),
+					DescriptionLocalizations: locales.Localizations("command.assignments.query.description"),
+					Type:                     discordgo.ApplicationCommandOptionString,
+					Required:                 true,
+					Autocomplete:             true,
+				},
+			},
 		},
 	},
 }
 
 // SlashAssignments handles the /assignments slash command interaction.
-// It retrieves a specific assignment or lists all assignments for the guild.
+// It dispatches the view, add, edit, delete, list and search subcommands.
 func SlashAssignments(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
-	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(interactionCreate.ApplicationCommandData().Options))
-	for _, opt := range interactionCreate.ApplicationCommandData().Options {
+	slog.Info(fmt.Sprintf("/assignments executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/assignments")
+	defer span.End()
+
+	if len(interactionCreate.ApplicationCommandData().Options) == 0 {
+		listAssignments(ctx, interactionCreate, hakaseClient)
+		return
+	}
+	subcommand := interactionCreate.ApplicationCommandData().Options[0]
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(subcommand.Options))
+	for _, opt := range subcommand.Options {
 		optionMap[opt.Name] = opt
 	}
 
-	slog.Info(fmt.Sprintf("/assignments executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), clients.DiscordSession{}, bot), "/assignments")
-	defer transaction.Finish()
+	switch subcommand.Name {
+	case "view":
+		getAssignment(ctx, interactionCreate, hakaseClient, fmt.Sprint(optionMap["assignment"].IntValue()))
+	case "add":
+		openAddAssignmentModal(ctx, interactionCreate, hakaseClient)
+	case "edit":
+		openUpdateAssignmentModal(ctx, interactionCreate, hakaseClient, fmt.Sprint(optionMap["assignment"].IntValue()))
+	case "delete":
+		deleteAssignment(ctx, interactionCreate, hakaseClient, fmt.Sprint(optionMap["assignment"].IntValue()))
+	case "list":
+		listAssignments(ctx, interactionCreate, hakaseClient)
+	case "search":
+		searchAssignments(ctx, interactionCreate, hakaseClient, optionMap["query"].StringValue())
+	default:
+		slog.Error(fmt.Sprintf("unknown /assignments subcommand: %s", subcommand.Name))
+	}
+}
 
-	assignmentID, exists := optionMap["id"]
-	if exists {
-		getAssignment(transaction, interactionCreate, hakaseClient, fmt.Sprint(assignmentID.IntValue()))
+// AssignmentsAutocomplete handles autocomplete for the /assignments slash command.
+// It suggests assignments whose names fuzzy match the focused option's current value.
+func AssignmentsAutocomplete(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/assignments autocomplete")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
 
-	} else {
-		listAssignments(transaction, interactionCreate, hakaseClient)
+	var focused *discordgo.ApplicationCommandInteractionDataOption
+	for _, subcommand := range interactionCreate.ApplicationCommandData().Options {
+		for _, opt := range subcommand.Options {
+			if opt.Focused {
+				focused = opt
+			}
+		}
+	}
+	if focused == nil {
+		slog.Error("no focused option for /assignments autocomplete")
+		return
+	}
+
+	assignments, err := hakaseClient.Backend.ListAssignments(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing assignments for autocomplete").Error())
+		assignments = []clients.Assignment{}
+	}
+
+	names := make([]string, len(assignments))
+	for index, assignment := range assignments {
+		names[index] = assignment.Name
+	}
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, index := range fuzzyFilter(fmt.Sprint(focused.Value), names) {
+		if len(choices) == 25 {
+			// discord limits autocomplete to 25 choices
+			break
+		}
+		assignment := assignments[index]
+		choice := &discordgo.ApplicationCommandOptionChoice{
+			Name:  views.AssignmentChoiceName(locale, assignment),
+			Value: assignment.ID,
+		}
+		if focused.Type == discordgo.ApplicationCommandOptionString {
+			choice.Value = assignment.Name
+		}
+		choices = append(choices, choice)
 	}
 
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
 }
 
 // getAssignment retrieves and responds with a specific assignment's details.
-func getAssignment(span *sentry.Span, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, assignmentID string) {
-	span = span.StartChild("/assignments getAssignment")
-	defer span.Finish()
-	bot := span.GetTransaction().Context().Value(clients.DiscordSession{}).(*discordgo.Session)
-	assignment, err := hakaseClient.Backend.ReadAssignment(span, assignmentID)
+func getAssignment(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, assignmentID string) {
+	ctx, span := tracing.Start(ctx, "/assignments getAssignment")
+	defer span.End()
+	bot := clients.Session(ctx)
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+	assignment, err := hakaseClient.Backend.ReadAssignment(ctx, assignmentID)
 
 	if err != nil {
 		err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
@@ -69,8 +198,8 @@ func getAssignment(span *sentry.Span, interactionCreate *discordgo.InteractionCr
 		err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Embeds:     []*discordgo.MessageEmbed{views.AssignmentView(interactionCreate.Member, assignment)},
-				Components: []discordgo.MessageComponent{views.AssignmentActions(assignment)},
+				Embeds:     []*discordgo.MessageEmbed{views.AssignmentView(locale, interactionCreate.Member, assignment)},
+				Components: []discordgo.MessageComponent{views.AssignmentActions(locale, assignment)},
 			},
 		})
 		if err != nil {
@@ -80,11 +209,12 @@ func getAssignment(span *sentry.Span, interactionCreate *discordgo.InteractionCr
 }
 
 // listAssignments retrieves and responds with a list of assignments for the guild.
-func listAssignments(span *sentry.Span, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
-	span = span.StartChild("/assignments listAssignments")
-	defer span.Finish()
-	bot := span.GetTransaction().Context().Value(clients.DiscordSession{}).(*discordgo.Session)
-	assignments, err := hakaseClient.Backend.ListAssignments(span, interactionCreate.GuildID)
+func listAssignments(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(ctx, "/assignments listAssignments")
+	defer span.End()
+	bot := clients.Session(ctx)
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+	assignments, err := hakaseClient.Backend.ListAssignments(ctx, interactionCreate.GuildID)
 
 	if err != nil {
 		err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
@@ -100,12 +230,53 @@ func listAssignments(span *sentry.Span, interactionCreate *discordgo.Interaction
 		err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Embeds:     []*discordgo.MessageEmbed{views.AssignmentsListView(interactionCreate.Member, assignments)},
-				Components: []discordgo.MessageComponent{views.AssignmentsListActions()},
+				Embeds:     []*discordgo.MessageEmbed{views.AssignmentsListView(locale, interactionCreate.Member, assignments)},
+				Components: []discordgo.MessageComponent{views.AssignmentsListActions(locale)},
+			},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+	}
+}
+
+// searchAssignments responds with the assignments for the guild whose names fuzzy match query, best match first.
+func searchAssignments(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, query string) {
+	ctx, span := tracing.Start(ctx, "/assignments searchAssignments")
+	defer span.End()
+	bot := clients.Session(ctx)
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+	assignments, err := hakaseClient.Backend.ListAssignments(ctx, interactionCreate.GuildID)
+
+	if err != nil {
+		err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: err.Error(),
 			},
 		})
 		if err != nil {
 			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
 		}
+		return
+	}
+
+	names := make([]string, len(assignments))
+	for index, assignment := range assignments {
+		names[index] = assignment.Name
+	}
+	matches := []clients.Assignment{}
+	for _, index := range fuzzyFilter(query, names) {
+		matches = append(matches, assignments[index])
+	}
+
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{views.AssignmentsListView(locale, interactionCreate.Member, matches)},
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
 	}
 }
@@ -0,0 +1,374 @@
+// Package interactions provides handlers for the /attendance slash command.
+package interactions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// maxAttendanceMinutes is the longest an attendance session can accept check-ins, long enough for a lecture.
+const maxAttendanceMinutes = 180
+
+var minAttendanceMinutes = 1.0
+
+var AttendanceCommand = discordgo.ApplicationCommand{
+	Name:                     "attendance",
+	Description:              locales.Default.T("command.attendance.description"),
+	DescriptionLocalizations: localized("command.attendance.description"),
+	Type:                     discordgo.ChatApplicationCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:                     "start",
+			Description:              locales.Default.T("command.attendance.start.description"),
+			DescriptionLocalizations: locales.Localizations("command.attendance.start.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "minutes",
+					Description:              locales.Default.T("command.attendance.minutes.description"),
+					DescriptionLocalizations: locales.Localizations("command.attendance.minutes.description"),
+					Type:                     discordgo.ApplicationCommandOptionInteger,
+					Required:                 true,
+					MinValue:                 &minAttendanceMinutes,
+					MaxValue:                 maxAttendanceMinutes,
+				},
+				{
+					Name:                     "late",
+					Description:              locales.Default.T("command.attendance.late.description"),
+					DescriptionLocalizations: locales.Localizations("command.attendance.late.description"),
+					Type:                     discordgo.ApplicationCommandOptionInteger,
+					MinValue:                 &minAttendanceMinutes,
+					MaxValue:                 maxAttendanceMinutes,
+				},
+				{
+					Name:                     "mode",
+					Description:              locales.Default.T("command.attendance.mode.description"),
+					DescriptionLocalizations: locales.Localizations("command.attendance.mode.description"),
+					Type:                     discordgo.ApplicationCommandOptionString,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{
+							Name:              locales.Default.T("command.attendance.mode.code"),
+							NameLocalizations: locales.Localizations("command.attendance.mode.code"),
+							Value:             "code",
+						},
+						{
+							Name:              locales.Default.T("command.attendance.mode.button"),
+							NameLocalizations: locales.Localizations("command.attendance.mode.button"),
+							Value:             "button",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "checkin",
+			Description:              locales.Default.T("command.attendance.checkin.description"),
+			DescriptionLocalizations: locales.Localizations("command.attendance.checkin.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "code",
+					Description:              locales.Default.T("command.attendance.code.description"),
+					DescriptionLocalizations: locales.Localizations("command.attendance.code.description"),
+					Type:                     discordgo.ApplicationCommandOptionString,
+					Required:                 true,
+					MaxLength:                20,
+				},
+			},
+		},
+		{
+			Name:                     "report",
+			Description:              locales.Default.T("command.attendance.report.description"),
+			DescriptionLocalizations: locales.Localizations("command.attendance.report.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "session",
+					Description:              locales.Default.T("command.attendance.session.description"),
+					DescriptionLocalizations: locales.Localizations("command.attendance.session.description"),
+					Type:                     discordgo.ApplicationCommandOptionInteger,
+				},
+			},
+		},
+	},
+}
+
+// SlashAttendance handles the /attendance slash command interaction.
+// It dispatches the start and report subcommands, which are for staff only, and the checkin subcommand for students.
+func SlashAttendance(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("/attendance executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/attendance")
+	defer span.End()
+
+	subcommand := interactionCreate.ApplicationCommandData().Options[0]
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(subcommand.Options))
+	for _, opt := range subcommand.Options {
+		optionMap[opt.Name] = opt
+	}
+
+	if subcommand.Name != "checkin" && interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend).T("admin_required"))
+		return
+	}
+
+	switch subcommand.Name {
+	case "start":
+		startAttendance(ctx, interactionCreate, hakaseClient, optionMap)
+	case "checkin":
+		checkInWithCode(ctx, interactionCreate, hakaseClient, optionMap["code"].StringValue())
+	case "report":
+		attendanceReport(ctx, interactionCreate, hakaseClient, optionMap)
+	default:
+		slog.Error(fmt.Sprintf("unknown /attendance subcommand: %s", subcommand.Name))
+	}
+}
+
+// startAttendance starts an attendance session accepting check-ins for the chosen number of minutes, and responds to staff with its code.
+// In button mode, a message with a check-in button is also posted in the channel /attendance was used in.
+func startAttendance(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	ctx, span := tracing.Start(ctx, "/attendance startAttendance")
+	defer span.End()
+	bot := clients.Session(ctx)
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	now := time.Now().Truncate(time.Second)
+	minutes := optionMap["minutes"].IntValue()
+	session := clients.AttendanceSession{
+		CourseID:  interactionCreate.GuildID,
+		HostID:    interactionCreate.Member.User.ID,
+		ChannelID: interactionCreate.ChannelID,
+		StartedAt: now,
+		ExpiresAt: now.Add(time.Duration(minutes) * time.Minute),
+	}
+	if opt, exists := optionMap["late"]; exists {
+		if opt.IntValue() >= minutes {
+			respondEphemeral(ctx, interactionCreate, locale.T("attendance.late_too_long"))
+			return
+		}
+		session.LateAfter = now.Add(time.Duration(opt.IntValue()) * time.Minute)
+	}
+	mode := "code"
+	if opt, exists := optionMap["mode"]; exists {
+		mode = opt.StringValue()
+	}
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	session.Code, err = clients.NewAttendanceCode()
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error generating attendance code").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.create_attendance", err.Error()))
+		return
+	}
+	session, err = hakaseClient.Backend.CreateAttendanceSession(ctx, session)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error creating attendance session").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.create_attendance", err.Error()))
+		return
+	}
+	expires := fmt.Sprintf("<t:%d:R>", session.ExpiresAt.Unix())
+
+	if mode != "button" {
+		followupEphemeral(ctx, interactionCreate, locale.T("attendance.started_code", session.Code, expires))
+		return
+	}
+
+	attendanceLocale := courseLocale(ctx, interactionCreate.GuildID, hakaseClient.Backend)
+	message, err := bot.ChannelMessageSendComplex(session.ChannelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{views.AttendanceView(attendanceLocale, session, time.Now())},
+		Components:      views.AttendanceActions(attendanceLocale, session, time.Now()),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error posting attendance message: %d", session.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.post_attendance", session.Code, err.Error()))
+		return
+	}
+
+	session.MessageID = message.ID
+	_, err = hakaseClient.Backend.UpdateAttendanceSession(ctx, session)
+	if err != nil {
+		// students still check in with the button, but the message is not updated when staff end the session
+		slog.Error(stacktrace.Propagate(err, "error recording attendance message: %d", session.ID).Error())
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("attendance.started_button", session.Code, expires))
+}
+
+// checkInWithCode checks the student in to the course's attendance session with a code.
+func checkInWithCode(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, code string) {
+	ctx, span := tracing.Start(ctx, "/attendance checkInWithCode")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := clients.Session(ctx).InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	sessions, err := hakaseClient.Backend.ListAttendanceSessions(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing attendance sessions").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.list_attendance", err.Error()))
+		return
+	}
+	session, exists := clients.AttendanceSessionByCode(sessions, code)
+	if !exists {
+		followupEphemeral(ctx, interactionCreate, locale.T("attendance.invalid_code"))
+		return
+	}
+	checkIn(ctx, interactionCreate, hakaseClient, locale, session)
+}
+
+// checkIn checks the student in to an attendance session after the interaction's response was deferred,
+// telling them if they already checked in, the session expired, or they are late.
+func checkIn(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, session clients.AttendanceSession) {
+	now := time.Now()
+	if previous, exists := session.CheckIn(interactionCreate.Member.User.ID); exists {
+		followupEphemeral(ctx, interactionCreate, locale.T("attendance.already_checked_in", fmt.Sprintf("<t:%d:t>", previous.CheckedInAt.Unix())))
+		return
+	}
+	if !session.Active(now) {
+		followupEphemeral(ctx, interactionCreate, locale.T("attendance.expired"))
+		return
+	}
+
+	late := session.IsLate(now)
+	err := hakaseClient.Backend.CheckInAttendance(ctx, clients.AttendanceCheckIn{
+		SessionID:   session.ID,
+		UserID:      interactionCreate.Member.User.ID,
+		CheckedInAt: now.Truncate(time.Second),
+		Late:        late,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error checking in to attendance session: %d", session.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.check_in_attendance", err.Error()))
+		return
+	}
+	if late {
+		followupEphemeral(ctx, interactionCreate, locale.T("attendance.checked_in_late"))
+		return
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("attendance.checked_in"))
+}
+
+// attendanceReport responds to staff with an attendance report as a CSV file: the check-ins of one session if one is chosen,
+// or the attendance of every student over all of the course's sessions.
+func attendanceReport(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	ctx, span := tracing.Start(ctx, "/attendance attendanceReport")
+	defer span.End()
+	bot := clients.Session(ctx)
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	sessions, err := hakaseClient.Backend.ListAttendanceSessions(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing attendance sessions").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.list_attendance", err.Error()))
+		return
+	}
+	if len(sessions) == 0 {
+		followupEphemeral(ctx, interactionCreate, locale.T("attendance.no_sessions"))
+		return
+	}
+
+	content, name, report := "", "", []byte{}
+	if opt, exists := optionMap["session"]; exists {
+		// sessions are looked up among the course's, so staff cannot read another course's attendance
+		index := slices.IndexFunc(sessions, func(session clients.AttendanceSession) bool { return int64(session.ID) == opt.IntValue() })
+		if index < 0 {
+			followupEphemeral(ctx, interactionCreate, locale.T("attendance.session_not_found", opt.IntValue()))
+			return
+		}
+		session := sessions[index]
+		late := 0
+		for _, checkIn := range session.CheckIns {
+			if checkIn.Late {
+				late++
+			}
+		}
+		content = locale.T("attendance.session_report", session.ID, fmt.Sprintf("<t:%d:f>", session.StartedAt.Unix()), len(session.CheckIns)-late, late)
+		name = fmt.Sprintf("attendance-%d.csv", session.ID)
+		report, err = session.ReportCSV()
+	} else {
+		var roster []string
+		roster, err = courseRoster(ctx, interactionCreate.GuildID)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error listing course members").Error())
+			followupEphemeral(ctx, interactionCreate, locale.T("error.attendance_report", err.Error()))
+			return
+		}
+		content = locale.T("attendance.student_report", len(sessions), len(clients.SummarizeAttendance(sessions, roster)))
+		name = "attendance.csv"
+		report, err = clients.StudentAttendanceCSV(sessions, roster)
+	}
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error writing attendance report").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.attendance_report", err.Error()))
+		return
+	}
+
+	_, err = bot.FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
+		Content: content,
+		Files: []*discordgo.File{
+			{Name: name, ContentType: "text/csv", Reader: bytes.NewReader(report)},
+		},
+		Flags: discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// courseRoster lists the user IDs of the students in the course's guild, so that students who never checked in are reported absent.
+// Listing members needs the Server Members privileged intent.
+func courseRoster(ctx context.Context, guildID string) ([]string, error) {
+	bot := clients.Session(ctx)
+	guild, err := bot.State.Guild(guildID)
+	if err != nil {
+		guild, err = bot.Guild(guildID)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "error reading guild: %s", guildID)
+		}
+	}
+
+	members := []*discordgo.Member{}
+	after := ""
+	for {
+		page, err := bot.GuildMembers(guildID, after, 1000)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "error listing guild members: %s", guildID)
+		}
+		members = append(members, page...)
+		if len(page) < 1000 {
+			return clients.AttendanceRoster(guild, members), nil
+		}
+		after = page[len(page)-1].User.ID
+	}
+}
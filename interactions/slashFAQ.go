@@ -0,0 +1,309 @@
+// Package interactions provides handlers for the /question and /faq slash commands, and the accept answer context menu command.
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// answeredPrefix marks the names of Q&A threads with an accepted answer.
+const answeredPrefix = "✅ "
+
+// faqResults is the most FAQ entries shown for a search.
+const faqResults = 3
+
+var QuestionCommand = discordgo.ApplicationCommand{
+	Name:                     "question",
+	Description:              locales.Default.T("command.question.description"),
+	DescriptionLocalizations: localized("command.question.description"),
+	Type:                     discordgo.ChatApplicationCommand,
+}
+
+// AcceptAnswerCommand is the message context menu command accepting a message as the answer to its Q&A thread.
+// Its name is the menu item's label, so it is localized. Discord still sends the English name when it is used.
+var AcceptAnswerCommand = discordgo.ApplicationCommand{
+	Name:              "Accept answer",
+	NameLocalizations: localized("command.accept_answer.name"),
+	Type:              discordgo.MessageApplicationCommand,
+}
+
+var FAQCommand = discordgo.ApplicationCommand{
+	Name:                     "faq",
+	Description:              locales.Default.T("command.faq.description"),
+	DescriptionLocalizations: localized("command.faq.description"),
+	Type:                     discordgo.ChatApplicationCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:                     "search",
+			Description:              locales.Default.T("command.faq.search.description"),
+			DescriptionLocalizations: locales.Localizations("command.faq.search.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "query",
+					Description:              locales.Default.T("command.faq.query.description"),
+					DescriptionLocalizations: locales.Localizations("command.faq.query.description"),
+					Type:                     discordgo.ApplicationCommandOptionString,
+					Required:                 true,
+					Autocomplete:             true,
+				},
+			},
+		},
+	},
+}
+
+// SlashQuestion handles the /question slash command interaction by opening the Q&A thread modal.
+func SlashQuestion(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("/question executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/question")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID:   "createQAThread",
+			Title:      locale.T("modal.qa_thread_title"),
+			Components: views.QAThreadModal(locale),
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// QAThreadSubmit handles the submission of the Q&A thread modal. It posts the question in the channel /question was used in,
+// and starts a thread under it for answers.
+func QAThreadSubmit(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("qaThreadSubmit executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "qaThreadSubmit")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	questionData := interactionCreate.ModalSubmitData()
+	thread := clients.QAThread{
+		CourseID:  interactionCreate.GuildID,
+		AskerID:   interactionCreate.Member.User.ID,
+		Title:     questionData.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value,
+		Question:  questionData.Components[1].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value,
+		ChannelID: interactionCreate.ChannelID,
+		CreatedAt: time.Now().Truncate(time.Second),
+	}
+
+	// the question is read by everyone in the channel, so it is posted in the course's default locale
+	threadLocale := courseLocale(ctx, interactionCreate.GuildID, hakaseClient.Backend)
+	message, err := bot.ChannelMessageSendComplex(thread.ChannelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{views.QAThreadView(threadLocale, thread)},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error posting question: %s", thread.ChannelID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.post_qa_thread", err.Error()))
+		return
+	}
+
+	discordThread, err := bot.MessageThreadStart(message.ChannelID, message.ID, thread.Title, questionThreadArchive)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error starting Q&A thread: %s", message.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.post_qa_thread", err.Error()))
+		return
+	}
+	thread.ThreadID = discordThread.ID
+
+	_, err = hakaseClient.Backend.CreateQAThread(ctx, thread)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error creating Q&A thread: %s", thread.ThreadID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.create_qa_thread", err.Error()))
+		return
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("faq.thread_created", fmt.Sprintf("<#%s>", thread.ThreadID)))
+}
+
+// AcceptAnswer handles the accept answer context menu command, accepting a message in a Q&A thread as its answer and adding it to the FAQ.
+// Only the asker and staff can accept an answer, and accepting another answer replaces it.
+func AcceptAnswer(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("acceptAnswer executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "acceptAnswer")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	thread, err := hakaseClient.Backend.ReadQAThread(ctx, interactionCreate.ChannelID)
+	if err != nil {
+		slog.Warn(stacktrace.Propagate(err, "error reading Q&A thread: %s", interactionCreate.ChannelID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.read_qa_thread", err.Error()))
+		return
+	}
+	if thread.AskerID != interactionCreate.Member.User.ID && interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		followupEphemeral(ctx, interactionCreate, locale.T("faq.accept_not_allowed"))
+		return
+	}
+
+	commandData := interactionCreate.ApplicationCommandData()
+	answer := commandData.Resolved.Messages[commandData.TargetID]
+	if answer == nil || strings.TrimSpace(answer.Content) == "" {
+		followupEphemeral(ctx, interactionCreate, locale.T("faq.empty_answer"))
+		return
+	}
+
+	entry, err := hakaseClient.Backend.AcceptAnswer(ctx, clients.FAQEntry{
+		CourseID:       interactionCreate.GuildID,
+		ThreadID:       thread.ThreadID,
+		Question:       thread.Title,
+		Answer:         answer.Content,
+		AnswerAuthorID: answer.Author.ID,
+		AnswerURL:      fmt.Sprintf("https://discord.com/channels/%s/%s/%s", interactionCreate.GuildID, answer.ChannelID, answer.ID),
+		AcceptedBy:     interactionCreate.Member.User.ID,
+		AcceptedAt:     time.Now().Truncate(time.Second),
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error accepting answer: %s", answer.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.accept_answer", err.Error()))
+		return
+	}
+
+	threadLocale := courseLocale(ctx, interactionCreate.GuildID, hakaseClient.Backend)
+	_, err = bot.ChannelMessageSendComplex(thread.ThreadID, &discordgo.MessageSend{
+		Content:         threadLocale.T("faq.accepted", fmt.Sprintf("<@%s>", entry.AcceptedBy)),
+		Reference:       answer.Reference(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Warn(stacktrace.Propagate(err, "error announcing accepted answer: %s", thread.ThreadID).Error())
+	}
+	_, err = bot.ChannelEdit(thread.ThreadID, &discordgo.ChannelEdit{Name: answeredPrefix + thread.Title})
+	if err != nil {
+		slog.Warn(stacktrace.Propagate(err, "error marking Q&A thread answered: %s", thread.ThreadID).Error())
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("faq.answer_accepted"))
+}
+
+// SlashFAQ handles the /faq slash command interaction, responding with the FAQ entries best matching the search.
+// Choosing an autocomplete suggestion searches by the entry's ID, so that exactly that entry is shown.
+func SlashFAQ(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("/faq executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/faq")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	query := interactionCreate.ApplicationCommandData().Options[0].Options[0].StringValue()
+	entries, err := hakaseClient.Backend.ListFAQ(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing FAQ").Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.list_faq", err.Error()))
+		return
+	}
+
+	embeds := []*discordgo.MessageEmbed{}
+	for _, entry := range entries {
+		if fmt.Sprint(entry.ID) == query {
+			embeds = append(embeds, views.FAQView(locale, entry))
+		}
+	}
+	if len(embeds) == 0 {
+		for _, index := range searchFAQ(query, entries) {
+			if len(embeds) == faqResults {
+				break
+			}
+			embeds = append(embeds, views.FAQView(locale, entries[index]))
+		}
+	}
+	if len(embeds) == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("faq.no_results", query))
+		return
+	}
+
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          embeds,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// FAQAutocomplete suggests the FAQ entries best matching the search as the user types it.
+func FAQAutocomplete(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/faq autocomplete")
+	defer span.End()
+
+	query := interactionCreate.ApplicationCommandData().Options[0].Options[0].StringValue()
+	entries, err := hakaseClient.Backend.ListFAQ(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing FAQ for autocomplete").Error())
+		entries = []clients.FAQEntry{}
+	}
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, index := range searchFAQ(query, entries) {
+		if len(choices) == 25 {
+			// discord limits autocomplete to 25 choices
+			break
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  views.FAQChoiceName(entries[index]),
+			Value: fmt.Sprint(entries[index].ID),
+		})
+	}
+
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// searchFAQ returns the indices of the FAQ entries matching query, best match first.
+// Entries whose question matches rank above entries where only the answer matches.
+func searchFAQ(query string, entries []clients.FAQEntry) []int {
+	questions := make([]string, len(entries))
+	for index, entry := range entries {
+		questions[index] = entry.Question
+	}
+
+	matched := fuzzyFilter(query, questions)
+	seen := make(map[int]bool, len(matched))
+	for _, index := range matched {
+		seen[index] = true
+	}
+	// answers are long enough to contain most queries as a scattered subsequence, so they only match as a substring
+	query = strings.ToLower(strings.TrimSpace(query))
+	for index, entry := range entries {
+		if !seen[index] && query != "" && strings.Contains(strings.ToLower(entry.Answer), query) {
+			matched = append(matched, index)
+		}
+	}
+	return matched
+}
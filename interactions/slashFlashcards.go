@@ -0,0 +1,412 @@
+// Package interactions provides handlers for the /flashcards slash command.
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// maxFlashcardCSVSize is the largest CSV file of cards that is downloaded, far more than MaxFlashcards cards need.
+const maxFlashcardCSVSize = 1 << 20
+
+var FlashcardsCommand = discordgo.ApplicationCommand{
+	Name:                     "flashcards",
+	Description:              locales.Default.T("command.flashcards.description"),
+	DescriptionLocalizations: localized("command.flashcards.description"),
+	Type:                     discordgo.ChatApplicationCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:                     "study",
+			Description:              locales.Default.T("command.flashcards.study.description"),
+			DescriptionLocalizations: locales.Localizations("command.flashcards.study.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "deck",
+					Description:              locales.Default.T("command.flashcards.deck.description"),
+					DescriptionLocalizations: locales.Localizations("command.flashcards.deck.description"),
+					Type:                     discordgo.ApplicationCommandOptionString,
+					Required:                 true,
+					Autocomplete:             true,
+				},
+			},
+		},
+		{
+			Name:                     "create",
+			Description:              locales.Default.T("command.flashcards.create.description"),
+			DescriptionLocalizations: locales.Localizations("command.flashcards.create.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "name",
+					Description:              locales.Default.T("command.flashcards.name.description"),
+					DescriptionLocalizations: locales.Localizations("command.flashcards.name.description"),
+					Type:                     discordgo.ApplicationCommandOptionString,
+					MaxLength:                100,
+				},
+				{
+					Name:                     "csv",
+					Description:              locales.Default.T("command.flashcards.csv.description"),
+					DescriptionLocalizations: locales.Localizations("command.flashcards.csv.description"),
+					Type:                     discordgo.ApplicationCommandOptionAttachment,
+				},
+				{
+					Name:                     "shared",
+					Description:              locales.Default.T("command.flashcards.shared.description"),
+					DescriptionLocalizations: locales.Localizations("command.flashcards.shared.description"),
+					Type:                     discordgo.ApplicationCommandOptionBoolean,
+				},
+			},
+		},
+		{
+			Name:                     "decks",
+			Description:              locales.Default.T("command.flashcards.decks.description"),
+			DescriptionLocalizations: locales.Localizations("command.flashcards.decks.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+		},
+		{
+			Name:                     "delete",
+			Description:              locales.Default.T("command.flashcards.delete.description"),
+			DescriptionLocalizations: locales.Localizations("command.flashcards.delete.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "deck",
+					Description:              locales.Default.T("command.flashcards.deck.description"),
+					DescriptionLocalizations: locales.Localizations("command.flashcards.deck.description"),
+					Type:                     discordgo.ApplicationCommandOptionString,
+					Required:                 true,
+					Autocomplete:             true,
+				},
+			},
+		},
+	},
+}
+
+// SlashFlashcards handles the /flashcards slash command interaction.
+// Students study decks shared with the course and their own decks, and create personal decks. Staff create decks shared with the course.
+func SlashFlashcards(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("/flashcards executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/flashcards")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	subcommand := interactionCreate.ApplicationCommandData().Options[0]
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(subcommand.Options))
+	for _, opt := range subcommand.Options {
+		optionMap[opt.Name] = opt
+	}
+
+	switch subcommand.Name {
+	case "study":
+		studyFlashcards(ctx, interactionCreate, hakaseClient, locale, optionMap["deck"].StringValue())
+	case "create":
+		createFlashcardDeck(ctx, interactionCreate, hakaseClient, locale, optionMap)
+	case "decks":
+		listFlashcardDecks(ctx, interactionCreate, hakaseClient, locale)
+	case "delete":
+		deleteFlashcardDeck(ctx, interactionCreate, hakaseClient, locale, optionMap["deck"].StringValue())
+	default:
+		slog.Error(fmt.Sprintf("unknown /flashcards subcommand: %s", subcommand.Name))
+	}
+}
+
+// studyFlashcards shows the student the next card to study in a deck, in a message only they can see.
+func studyFlashcards(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, deckID string) {
+	ctx, span := tracing.Start(ctx, "/flashcards studyFlashcards")
+	defer span.End()
+
+	deck, visible := readFlashcardDeck(ctx, interactionCreate, hakaseClient, locale, deckID, respondEphemeral)
+	if !visible {
+		return
+	}
+	reviews, err := hakaseClient.Backend.ListFlashcardReviews(ctx, interactionCreate.GuildID, interactionCreate.Member.User.ID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing flashcard reviews").Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.study_flashcards", err.Error()))
+		return
+	}
+
+	data := flashcardResponse(locale, deck, reviews, time.Now())
+	data.Flags = discordgo.MessageFlagsEphemeral
+	err = clients.Session(ctx).InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// flashcardResponse returns the message showing the next card to study in a deck at now, or that there are none left.
+func flashcardResponse(locale locales.Locale, deck clients.FlashcardDeck, reviews []clients.FlashcardReview, now time.Time) *discordgo.InteractionResponseData {
+	card, remaining, exists := clients.NextFlashcard(deck, reviews, now)
+	if !exists {
+		return &discordgo.InteractionResponseData{
+			Content:    views.FlashcardsDoneView(locale, deck, reviews),
+			Embeds:     []*discordgo.MessageEmbed{},
+			Components: []discordgo.MessageComponent{},
+		}
+	}
+	return &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{views.FlashcardView(locale, deck, card, false, remaining)},
+		Components: views.FlashcardActions(locale, deck, card, false),
+	}
+}
+
+// createFlashcardDeck creates a deck from an attached CSV file of cards, or opens a modal to write its cards.
+// Only staff can create decks shared with the course.
+func createFlashcardDeck(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	ctx, span := tracing.Start(ctx, "/flashcards createFlashcardDeck")
+	defer span.End()
+	bot := clients.Session(ctx)
+
+	shared := false
+	if opt, exists := optionMap["shared"]; exists {
+		shared = opt.BoolValue()
+	}
+	if shared && interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return
+	}
+	name := ""
+	if opt, exists := optionMap["name"]; exists {
+		name = strings.TrimSpace(opt.StringValue())
+	}
+
+	opt, exists := optionMap["csv"]
+	if !exists {
+		err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseModal,
+			Data: &discordgo.InteractionResponseData{
+				CustomID:   fmt.Sprintf("createFlashcardDeck_%t", shared),
+				Title:      locale.T("modal.deck_title"),
+				Components: views.FlashcardDeckModal(locale, name),
+			},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+		}
+		return
+	}
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	attachment := interactionCreate.ApplicationCommandData().Resolved.Attachments[opt.Value.(string)]
+	if attachment.Size > maxFlashcardCSVSize {
+		followupEphemeral(ctx, interactionCreate, locale.T("flashcards.csv_too_large"))
+		return
+	}
+	data, err := downloadAttachment(ctx, attachment)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error downloading flashcard CSV: %s", attachment.URL).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.create_flashcard_deck", err.Error()))
+		return
+	}
+	cards, err := clients.ParseFlashcardCSV(locale, data)
+	if err != nil {
+		followupEphemeral(ctx, interactionCreate, locale.T("flashcards.invalid_cards", err.Error()))
+		return
+	}
+	if name == "" {
+		name = strings.TrimSuffix(attachment.Filename, path.Ext(attachment.Filename))
+	}
+	saveFlashcardDeck(ctx, interactionCreate, hakaseClient, locale, name, cards, shared)
+}
+
+// downloadAttachment downloads an attachment's contents from Discord's CDN.
+func downloadAttachment(ctx context.Context, attachment *discordgo.MessageAttachment) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, attachment.URL, nil)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error creating attachment request")
+	}
+	response, err := clients.Session(ctx).Client.Do(request)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "error downloading attachment")
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, stacktrace.NewError("failed status code downloading attachment: %d", response.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(response.Body, maxFlashcardCSVSize))
+}
+
+// FlashcardDeckSubmit handles the submission of the flashcard deck modal, creating the deck from the cards written one per line.
+func FlashcardDeckSubmit(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("flashcardDeckSubmit executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "flashcardDeckSubmit")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	deckData := interactionCreate.ModalSubmitData()
+	shared := strings.Split(deckData.CustomID, "_")[1] == "true"
+	name := strings.TrimSpace(deckData.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value)
+	cards, err := clients.ParseFlashcardLines(locale, deckData.Components[1].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value)
+	if err != nil {
+		followupEphemeral(ctx, interactionCreate, locale.T("flashcards.invalid_cards", err.Error()))
+		return
+	}
+	saveFlashcardDeck(ctx, interactionCreate, hakaseClient, locale, name, cards, shared)
+}
+
+// saveFlashcardDeck creates a deck with cards, shared with the course or personal to the member.
+func saveFlashcardDeck(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, name string, cards []clients.Flashcard, shared bool) {
+	deck := clients.FlashcardDeck{
+		CourseID:  interactionCreate.GuildID,
+		OwnerID:   interactionCreate.Member.User.ID,
+		Name:      name,
+		Cards:     cards,
+		CreatedAt: time.Now().Truncate(time.Second),
+	}
+	if shared {
+		deck.OwnerID = ""
+	}
+
+	deck, err := hakaseClient.Backend.CreateFlashcardDeck(ctx, deck)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error creating flashcard deck").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.create_flashcard_deck", err.Error()))
+		return
+	}
+	if shared {
+		followupEphemeral(ctx, interactionCreate, locale.T("flashcards.created_shared", deck.Name, len(deck.Cards)))
+		return
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("flashcards.created_personal", deck.Name, len(deck.Cards)))
+}
+
+// listFlashcardDecks shows the student the decks they can study, with how many cards are left to study in each.
+func listFlashcardDecks(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale) {
+	ctx, span := tracing.Start(ctx, "/flashcards listFlashcardDecks")
+	defer span.End()
+
+	decks, err := hakaseClient.Backend.ListFlashcardDecks(ctx, interactionCreate.GuildID, interactionCreate.Member.User.ID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing flashcard decks").Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.list_flashcard_decks", err.Error()))
+		return
+	}
+	reviews, err := hakaseClient.Backend.ListFlashcardReviews(ctx, interactionCreate.GuildID, interactionCreate.Member.User.ID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing flashcard reviews").Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.list_flashcard_decks", err.Error()))
+		return
+	}
+	clients.SortFlashcardDecks(decks)
+
+	err = clients.Session(ctx).InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{views.FlashcardDecksView(locale, decks, reviews, time.Now())},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// deleteFlashcardDeck deletes a deck. Students can delete their own decks, and staff can delete decks shared with the course.
+func deleteFlashcardDeck(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, deckID string) {
+	ctx, span := tracing.Start(ctx, "/flashcards deleteFlashcardDeck")
+	defer span.End()
+
+	deck, visible := readFlashcardDeck(ctx, interactionCreate, hakaseClient, locale, deckID, respondEphemeral)
+	if !visible {
+		return
+	}
+	if deck.Shared() && interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return
+	}
+
+	err := hakaseClient.Backend.DeleteFlashcardDeck(ctx, deckID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error deleting flashcard deck: %s", deckID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.delete_flashcard_deck", err.Error()))
+		return
+	}
+	respondEphemeral(ctx, interactionCreate, locale.T("flashcards.deleted", deck.Name))
+}
+
+// readFlashcardDeck reads a deck, and reports whether the member can study it. If they cannot, they are told so with reply.
+func readFlashcardDeck(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, deckID string, reply func(context.Context, *discordgo.InteractionCreate, string)) (clients.FlashcardDeck, bool) {
+	deck, err := hakaseClient.Backend.ReadFlashcardDeck(ctx, deckID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading flashcard deck: %s", deckID).Error())
+		reply(ctx, interactionCreate, locale.T("flashcards.deck_not_found"))
+		return deck, false
+	}
+	if !deck.VisibleTo(interactionCreate.GuildID, interactionCreate.Member.User.ID) {
+		reply(ctx, interactionCreate, locale.T("flashcards.deck_not_found"))
+		return deck, false
+	}
+	return deck, true
+}
+
+// FlashcardsAutocomplete suggests the decks the member can study whose names match what they have typed.
+func FlashcardsAutocomplete(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/flashcards autocomplete")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	query := strings.ToLower(interactionCreate.ApplicationCommandData().Options[0].Options[0].StringValue())
+	decks, err := hakaseClient.Backend.ListFlashcardDecks(ctx, interactionCreate.GuildID, interactionCreate.Member.User.ID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing flashcard decks for autocomplete").Error())
+		decks = []clients.FlashcardDeck{}
+	}
+	clients.SortFlashcardDecks(decks)
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, deck := range decks {
+		if len(choices) == 25 {
+			// discord limits autocomplete to 25 choices
+			break
+		}
+		if !strings.Contains(strings.ToLower(deck.Name), query) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  views.FlashcardDeckChoiceName(locale, deck),
+			Value: fmt.Sprint(deck.ID),
+		})
+	}
+
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
@@ -0,0 +1,239 @@
+// Package interactions provides handlers for the /focus slash command.
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+const defaultFocusCycles = 4
+
+var (
+	minFocusMinutes = float64(1)
+	minFocusCycles  = float64(1)
+)
+
+// focusChannelTypes are the channels focus timers can run in: voice channels, for their text chat, and threads.
+var focusChannelTypes = []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice, discordgo.ChannelTypeGuildPublicThread, discordgo.ChannelTypeGuildPrivateThread}
+
+var FocusCommand = discordgo.ApplicationCommand{
+	Name:                     "focus",
+	Description:              locales.Default.T("command.focus.description"),
+	DescriptionLocalizations: localized("command.focus.description"),
+	Type:                     discordgo.ChatApplicationCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:                     "start",
+			Description:              locales.Default.T("command.focus.start.description"),
+			DescriptionLocalizations: locales.Localizations("command.focus.start.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "focus",
+					Description:              locales.Default.T("command.focus.focus.description"),
+					DescriptionLocalizations: locales.Localizations("command.focus.focus.description"),
+					Type:                     discordgo.ApplicationCommandOptionInteger,
+					Required:                 true,
+					MinValue:                 &minFocusMinutes,
+					MaxValue:                 120,
+				},
+				{
+					Name:                     "break",
+					Description:              locales.Default.T("command.focus.break.description"),
+					DescriptionLocalizations: locales.Localizations("command.focus.break.description"),
+					Type:                     discordgo.ApplicationCommandOptionInteger,
+					Required:                 true,
+					MinValue:                 &minFocusMinutes,
+					MaxValue:                 60,
+				},
+				{
+					Name:                     "cycles",
+					Description:              locales.Default.T("command.focus.cycles.description"),
+					DescriptionLocalizations: locales.Localizations("command.focus.cycles.description"),
+					Type:                     discordgo.ApplicationCommandOptionInteger,
+					MinValue:                 &minFocusCycles,
+					MaxValue:                 12,
+				},
+				{
+					Name:                     "channel",
+					Description:              locales.Default.T("command.focus.channel.description"),
+					DescriptionLocalizations: locales.Localizations("command.focus.channel.description"),
+					Type:                     discordgo.ApplicationCommandOptionChannel,
+					ChannelTypes:             focusChannelTypes,
+				},
+			},
+		},
+		{
+			Name:                     "stop",
+			Description:              locales.Default.T("command.focus.stop.description"),
+			DescriptionLocalizations: locales.Localizations("command.focus.stop.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "channel",
+					Description:              locales.Default.T("command.focus.channel.description"),
+					DescriptionLocalizations: locales.Localizations("command.focus.channel.description"),
+					Type:                     discordgo.ApplicationCommandOptionChannel,
+					ChannelTypes:             focusChannelTypes,
+				},
+			},
+		},
+	},
+}
+
+// SlashFocus handles the /focus slash command interaction.
+// It starts a pomodoro timer in a voice channel or thread, or stops the timer running there.
+func SlashFocus(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("/focus executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/focus")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	subcommand := interactionCreate.ApplicationCommandData().Options[0]
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(subcommand.Options))
+	for _, opt := range subcommand.Options {
+		optionMap[opt.Name] = opt
+	}
+
+	channelID := interactionCreate.ChannelID
+	if opt, exists := optionMap["channel"]; exists {
+		channelID = opt.ChannelValue(nil).ID
+	}
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	switch subcommand.Name {
+	case "start":
+		cycles := defaultFocusCycles
+		if opt, exists := optionMap["cycles"]; exists {
+			cycles = int(opt.IntValue())
+		}
+		startFocus(ctx, interactionCreate, hakaseClient, locale, channelID, int(optionMap["focus"].IntValue()), int(optionMap["break"].IntValue()), cycles)
+	case "stop":
+		stopFocus(ctx, interactionCreate, hakaseClient, locale, channelID)
+	default:
+		slog.Error(fmt.Sprintf("unknown /focus subcommand: %s", subcommand.Name))
+	}
+}
+
+// startFocus starts a focus timer in a voice channel or thread, posting its embed there.
+func startFocus(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, channelID string, focusMinutes int, breakMinutes int, cycles int) {
+	ctx, span := tracing.Start(ctx, "/focus startFocus")
+	defer span.End()
+	bot := clients.Session(ctx)
+
+	channel, err := bot.Channel(channelID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading channel: %s", channelID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.start_focus", err.Error()))
+		return
+	}
+	if !slices.Contains(focusChannelTypes, channel.Type) {
+		followupEphemeral(ctx, interactionCreate, locale.T("focus.voice_or_thread"))
+		return
+	}
+
+	_, running, err := hakaseClient.Notifications.ReadFocusTimer(ctx, interactionCreate.GuildID, channelID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading focus timer: %s", channelID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.start_focus", err.Error()))
+		return
+	}
+	if running {
+		followupEphemeral(ctx, interactionCreate, locale.T("focus.already_running", fmt.Sprintf("<#%s>", channelID)))
+		return
+	}
+
+	now := time.Now()
+	timer := clients.NewFocusTimer(interactionCreate.GuildID, channelID, interactionCreate.Member.User.ID, focusMinutes, breakMinutes, cycles, now)
+	focusLocale := courseLocale(ctx, interactionCreate.GuildID, hakaseClient.Backend)
+	message, err := bot.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{views.FocusTimerView(focusLocale, timer, now)},
+		Components:      views.FocusTimerActions(focusLocale, timer),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error posting focus timer in channel: %s", channelID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.start_focus", err.Error()))
+		return
+	}
+
+	timer.MessageID = message.ID
+	_, err = hakaseClient.Notifications.SaveFocusTimer(ctx, timer)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error saving focus timer: %s", channelID).Error())
+		// without a saved timer the posted embed would never change, so remove it
+		deleteErr := bot.ChannelMessageDelete(channelID, message.ID)
+		if deleteErr != nil {
+			slog.Error(stacktrace.Propagate(deleteErr, "error deleting focus timer message: %s", message.ID).Error())
+		}
+		followupEphemeral(ctx, interactionCreate, locale.T("error.start_focus", err.Error()))
+		return
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("focus.started", fmt.Sprintf("<#%s>", channelID)))
+}
+
+// stopFocus stops the focus timer running in a channel, if the member is participating in it or is staff.
+func stopFocus(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, channelID string) {
+	ctx, span := tracing.Start(ctx, "/focus stopFocus")
+	defer span.End()
+
+	timer, running, err := hakaseClient.Notifications.ReadFocusTimer(ctx, interactionCreate.GuildID, channelID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading focus timer: %s", channelID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.stop_focus", err.Error()))
+		return
+	}
+	if !running {
+		followupEphemeral(ctx, interactionCreate, locale.T("focus.not_running", fmt.Sprintf("<#%s>", channelID)))
+		return
+	}
+	if !canControlFocus(interactionCreate, timer) {
+		followupEphemeral(ctx, interactionCreate, locale.T("focus.participants_only"))
+		return
+	}
+
+	err = hakaseClient.Notifications.DeleteFocusTimer(ctx, timer)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error stopping focus timer: %s", channelID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.stop_focus", err.Error()))
+		return
+	}
+	timer.Phase = clients.FocusStopped
+
+	focusLocale := courseLocale(ctx, interactionCreate.GuildID, hakaseClient.Backend)
+	embeds := []*discordgo.MessageEmbed{views.FocusTimerView(focusLocale, timer, time.Now())}
+	components := views.FocusTimerActions(focusLocale, timer)
+	_, err = clients.Session(ctx).ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:              timer.MessageID,
+		Channel:         timer.ChannelID,
+		Embeds:          &embeds,
+		Components:      &components,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error editing focus timer message: %s", timer.MessageID).Error())
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("focus.stopped_by_you", fmt.Sprintf("<#%s>", channelID)))
+}
+
+// canControlFocus reports whether the member can pause or stop a focus timer: its participants and staff can.
+func canControlFocus(interactionCreate *discordgo.InteractionCreate, timer clients.FocusTimer) bool {
+	return slices.Contains(timer.Participants, interactionCreate.Member.User.ID) || interactionCreate.Member.Permissions&discordgo.PermissionAdministrator != 0
+}
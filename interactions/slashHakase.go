@@ -10,28 +10,33 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
 	"github.com/dragonejt/hakase-discord/views"
-	"github.com/getsentry/sentry-go"
 	"github.com/palantir/stacktrace"
 )
 
 var HakaseCommand = discordgo.ApplicationCommand{
-	Name:        "hakase",
-	Description: "course configuration",
-	Type:        discordgo.ChatApplicationCommand,
+	Name:                     "hakase",
+	Description:              locales.Default.T("command.hakase.description"),
+	DescriptionLocalizations: localized("command.hakase.description"),
+	Type:                     discordgo.ChatApplicationCommand,
 	Options: []*discordgo.ApplicationCommandOption{
 		{
-			Name:        "cmd",
-			Description: "subcommand to execute",
-			Type:        discordgo.ApplicationCommandOptionString,
+			Name:                     "cmd",
+			Description:              locales.Default.T("command.hakase.cmd.description"),
+			DescriptionLocalizations: locales.Localizations("command.hakase.cmd.description"),
+			Type:                     discordgo.ApplicationCommandOptionString,
 			Choices: []*discordgo.ApplicationCommandOptionChoice{
 				{
-					Name:  "rock-paper-scissors",
-					Value: "rock-paper-scissors",
+					Name:              locales.Default.T("command.hakase.cmd.rock-paper-scissors"),
+					NameLocalizations: locales.Localizations("command.hakase.cmd.rock-paper-scissors"),
+					Value:             "rock-paper-scissors",
 				},
 				{
-					Name:  "config",
-					Value: "config",
+					Name:              locales.Default.T("command.hakase.cmd.config"),
+					NameLocalizations: locales.Localizations("command.hakase.cmd.config"),
+					Value:             "config",
 				},
 			},
 		},
@@ -56,30 +61,31 @@ func SlashHakase(bot *discordgo.Session, interactionCreate *discordgo.Interactio
 	}
 
 	slog.Info(fmt.Sprintf("/hakase executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
-	transaction := sentry.StartTransaction(context.WithValue(context.Background(), clients.DiscordSession{}, bot), "/hakase")
-	defer transaction.Finish()
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/hakase")
+	defer span.End()
 
 	subcommand, exists := optionMap["cmd"]
 	if !exists {
-		ping(transaction, interactionCreate, hakaseClient)
+		ping(ctx, interactionCreate, hakaseClient)
 	} else {
 		switch subcommand.StringValue() {
 		case "rock-paper-scissors":
-			rockPaperScissors(transaction, interactionCreate)
+			rockPaperScissors(ctx, interactionCreate)
 		case "config":
-			config(transaction, interactionCreate, hakaseClient)
+			config(ctx, interactionCreate, hakaseClient)
 		}
 	}
 }
 
 // ping responds to the /hakase command with a pong and backend response time.
-func ping(span *sentry.Span, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
-	span = span.StartChild("/hakase ping")
-	defer span.Finish()
-	bot := span.GetTransaction().Context().Value(clients.DiscordSession{}).(*discordgo.Session)
+func ping(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(ctx, "/hakase ping")
+	defer span.End()
+	bot := clients.Session(ctx)
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
 
 	start := time.Now()
-	err := hakaseClient.Backend.HeadCourse(span, interactionCreate.GuildID)
+	err := hakaseClient.Backend.HeadCourse(ctx, interactionCreate.GuildID)
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "error pinging backend").Error())
 	}
@@ -87,7 +93,7 @@ func ping(span *sentry.Span, interactionCreate *discordgo.InteractionCreate, hak
 	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("hakase pong! response time: %dms", time.Since(start).Milliseconds()),
+			Content: locale.T("pong", time.Since(start).Milliseconds()),
 		},
 	})
 	if err != nil {
@@ -97,10 +103,10 @@ func ping(span *sentry.Span, interactionCreate *discordgo.InteractionCreate, hak
 }
 
 // rockPaperScissors responds with a random rock-paper-scissors GIF.
-func rockPaperScissors(span *sentry.Span, interactionCreate *discordgo.InteractionCreate) {
-	span = span.StartChild("/hakase rockPaperScissors")
-	defer span.Finish()
-	bot := span.GetTransaction().Context().Value(clients.DiscordSession{}).(*discordgo.Session)
+func rockPaperScissors(ctx context.Context, interactionCreate *discordgo.InteractionCreate) {
+	ctx, span := tracing.Start(ctx, "/hakase rockPaperScissors")
+	defer span.End()
+	bot := clients.Session(ctx)
 
 	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -114,18 +120,19 @@ func rockPaperScissors(span *sentry.Span, interactionCreate *discordgo.Interacti
 }
 
 // config responds with the course configuration embed and components.
-func config(span *sentry.Span, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
-	span = span.StartChild("/hakase config")
-	defer span.Finish()
-	bot := span.GetTransaction().Context().Value(clients.DiscordSession{}).(*discordgo.Session)
+func config(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(ctx, "/hakase config")
+	defer span.End()
+	bot := clients.Session(ctx)
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
 
-	course, err := hakaseClient.Backend.ReadCourse(span, interactionCreate.GuildID)
+	course, err := hakaseClient.Backend.ReadCourse(ctx, interactionCreate.GuildID)
 	if err != nil {
 		slog.Error(stacktrace.Propagate(err, "error reading course").Error())
 		err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("error reading course: %s", err.Error()),
+				Content: locale.T("error.read_course", err.Error()),
 			},
 		})
 		if err != nil {
@@ -136,8 +143,8 @@ func config(span *sentry.Span, interactionCreate *discordgo.InteractionCreate, h
 	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Embeds:     []*discordgo.MessageEmbed{views.ConfigView(course)},
-			Components: views.ConfigActions(),
+			Embeds:     []*discordgo.MessageEmbed{views.ConfigView(locale, course)},
+			Components: views.ConfigActions(locale),
 		},
 	})
 	if err != nil {
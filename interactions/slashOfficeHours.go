@@ -0,0 +1,222 @@
+// Package interactions provides handlers for the /officehours slash command.
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+var OfficeHoursCommand = discordgo.ApplicationCommand{
+	Name:                     "officehours",
+	Description:              locales.Default.T("command.officehours.description"),
+	DescriptionLocalizations: localized("command.officehours.description"),
+	DefaultMemberPermissions: &staffPermissions,
+	Type:                     discordgo.ChatApplicationCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:                     "open",
+			Description:              locales.Default.T("command.officehours.open.description"),
+			DescriptionLocalizations: locales.Localizations("command.officehours.open.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "voice",
+					Description:              locales.Default.T("command.officehours.voice.description"),
+					DescriptionLocalizations: locales.Localizations("command.officehours.voice.description"),
+					Type:                     discordgo.ApplicationCommandOptionChannel,
+					ChannelTypes:             []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice, discordgo.ChannelTypeGuildStageVoice},
+					Required:                 true,
+				},
+			},
+		},
+		{
+			Name:                     "close",
+			Description:              locales.Default.T("command.officehours.close.description"),
+			DescriptionLocalizations: locales.Localizations("command.officehours.close.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+		},
+		{
+			Name:                     "stats",
+			Description:              locales.Default.T("command.officehours.stats.description"),
+			DescriptionLocalizations: locales.Localizations("command.officehours.stats.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+		},
+	},
+}
+
+// SlashOfficeHours handles the /officehours slash command interaction.
+// It dispatches the open, close and stats subcommands, which are for staff only.
+func SlashOfficeHours(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("/officehours executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/officehours")
+	defer span.End()
+
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend).T("admin_required"))
+		return
+	}
+
+	subcommand := interactionCreate.ApplicationCommandData().Options[0]
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(subcommand.Options))
+	for _, opt := range subcommand.Options {
+		optionMap[opt.Name] = opt
+	}
+
+	switch subcommand.Name {
+	case "open":
+		openOfficeHours(ctx, interactionCreate, hakaseClient, optionMap["voice"].ChannelValue(nil).ID)
+	case "close":
+		closeOpenOfficeHours(ctx, interactionCreate, hakaseClient)
+	case "stats":
+		officeHoursStatistics(ctx, interactionCreate, hakaseClient)
+	default:
+		slog.Error(fmt.Sprintf("unknown /officehours subcommand: %s", subcommand.Name))
+	}
+}
+
+// openOfficeHours opens an office hours session helping students in the voice channel,
+// and posts its queue message in the channel /officehours was used in. A course has at most one open session.
+func openOfficeHours(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, voiceChannelID string) {
+	ctx, span := tracing.Start(ctx, "/officehours openOfficeHours")
+	defer span.End()
+	bot := clients.Session(ctx)
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	sessions, err := hakaseClient.Backend.ListOfficeHours(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing office hours").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.list_office_hours", err.Error()))
+		return
+	}
+	if open, exists := clients.OpenOfficeHours(sessions); exists {
+		followupEphemeral(ctx, interactionCreate, locale.T("officehours.already_open", fmt.Sprintf("<#%s>", open.ChannelID)))
+		return
+	}
+
+	officeHours, err := hakaseClient.Backend.CreateOfficeHours(ctx, clients.OfficeHours{
+		CourseID:       interactionCreate.GuildID,
+		HostID:         interactionCreate.Member.User.ID,
+		ChannelID:      interactionCreate.ChannelID,
+		VoiceChannelID: voiceChannelID,
+		OpenedAt:       time.Now(),
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error creating office hours").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.create_office_hours", err.Error()))
+		return
+	}
+
+	queueLocale := courseLocale(ctx, interactionCreate.GuildID, hakaseClient.Backend)
+	message, err := bot.ChannelMessageSendComplex(officeHours.ChannelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{views.OfficeHoursQueueView(queueLocale, officeHours)},
+		Components:      views.OfficeHoursActions(queueLocale, officeHours),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error posting office hours queue: %d", officeHours.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.post_office_hours", err.Error()))
+		return
+	}
+
+	officeHours.MessageID = message.ID
+	_, err = hakaseClient.Backend.UpdateOfficeHours(ctx, officeHours)
+	if err != nil {
+		// the queue works without its message ID, but it is not updated as students join
+		slog.Error(stacktrace.Propagate(err, "error recording office hours queue message: %d", officeHours.ID).Error())
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("officehours.opened"))
+}
+
+// closeOpenOfficeHours closes the course's open office hours session.
+func closeOpenOfficeHours(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(ctx, "/officehours closeOfficeHours")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	sessions, err := hakaseClient.Backend.ListOfficeHours(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing office hours").Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.list_office_hours", err.Error()))
+		return
+	}
+	officeHours, exists := clients.OpenOfficeHours(sessions)
+	if !exists {
+		respondEphemeral(ctx, interactionCreate, locale.T("officehours.not_open"))
+		return
+	}
+	closeOfficeHours(ctx, interactionCreate, hakaseClient, locale, officeHours)
+}
+
+// closeOfficeHours closes an office hours session, updates its queue message, and responds with how many students were helped.
+func closeOfficeHours(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, officeHours clients.OfficeHours) {
+	bot := clients.Session(ctx)
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	officeHours.ClosedAt = time.Now()
+	_, err = hakaseClient.Backend.UpdateOfficeHours(ctx, officeHours)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error closing office hours: %d", officeHours.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.close_office_hours", err.Error()))
+		return
+	}
+
+	refreshed, err := refreshOfficeHours(ctx, hakaseClient.Backend, fmt.Sprint(officeHours.ID))
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error updating office hours queue: %d", officeHours.ID).Error())
+	}
+	if refreshed.ID != 0 {
+		officeHours = refreshed
+	}
+	statistics := clients.SummarizeOfficeHours([]clients.OfficeHours{officeHours}, time.Now())
+	followupEphemeral(ctx, interactionCreate, locale.T("officehours.closed_summary", statistics.Helped, statistics.Unhelped))
+}
+
+// officeHoursStatistics responds to staff with statistics over every office hours session of the course.
+func officeHoursStatistics(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(ctx, "/officehours officeHoursStatistics")
+	defer span.End()
+	bot := clients.Session(ctx)
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	sessions, err := hakaseClient.Backend.ListOfficeHours(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing office hours").Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.list_office_hours", err.Error()))
+		return
+	}
+
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{views.OfficeHoursStatisticsView(locale, clients.SummarizeOfficeHours(sessions, time.Now()))},
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
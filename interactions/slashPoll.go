@@ -0,0 +1,181 @@
+// Package interactions provides handlers for the /poll slash command.
+package interactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// pollOptionSeparator separates the options of a poll in the options option of /poll.
+	pollOptionSeparator = ";"
+	// maxPollOptionLength is the longest a poll option can be, since discord limits select menu option labels to 100 characters.
+	maxPollOptionLength = 100
+	minPollDuration     = time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+var PollCommand = discordgo.ApplicationCommand{
+	Name:                     "poll",
+	Description:              locales.Default.T("command.poll.description"),
+	DescriptionLocalizations: localized("command.poll.description"),
+	DefaultMemberPermissions: &staffPermissions,
+	Type:                     discordgo.ChatApplicationCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:                     "question",
+			Description:              locales.Default.T("command.poll.question.description"),
+			DescriptionLocalizations: locales.Localizations("command.poll.question.description"),
+			Type:                     discordgo.ApplicationCommandOptionString,
+			Required:                 true,
+			MaxLength:                256,
+		},
+		{
+			Name:                     "options",
+			Description:              locales.Default.T("command.poll.options.description"),
+			DescriptionLocalizations: locales.Localizations("command.poll.options.description"),
+			Type:                     discordgo.ApplicationCommandOptionString,
+			Required:                 true,
+		},
+		{
+			Name:                     "multiple",
+			Description:              locales.Default.T("command.poll.multiple.description"),
+			DescriptionLocalizations: locales.Localizations("command.poll.multiple.description"),
+			Type:                     discordgo.ApplicationCommandOptionBoolean,
+		},
+		{
+			Name:                     "anonymous",
+			Description:              locales.Default.T("command.poll.anonymous.description"),
+			DescriptionLocalizations: locales.Localizations("command.poll.anonymous.description"),
+			Type:                     discordgo.ApplicationCommandOptionBoolean,
+		},
+		{
+			Name:                     "duration",
+			Description:              locales.Default.T("command.poll.duration.description"),
+			DescriptionLocalizations: locales.Localizations("command.poll.duration.description"),
+			Type:                     discordgo.ApplicationCommandOptionString,
+		},
+	},
+}
+
+// SlashPoll handles the /poll slash command interaction. It creates the poll and posts it in the channel /poll was used in,
+// then schedules it to be closed by the reminder scheduler if it has a duration. Polls are anonymous unless staff choose otherwise.
+func SlashPoll(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("/poll executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	// the interaction is the origin of the poll closing it schedules, so closing the poll continues this trace
+	ctx := clients.WithOrigin(clients.WithSession(context.Background(), bot), interactionCreate.Interaction)
+	ctx, span := tracing.Start(ctx, "/poll", clients.OriginFrom(ctx).Attributes()...)
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return
+	}
+
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(interactionCreate.ApplicationCommandData().Options))
+	for _, opt := range interactionCreate.ApplicationCommandData().Options {
+		optionMap[opt.Name] = opt
+	}
+
+	options, err := parsePollOptions(locale, optionMap["options"].StringValue())
+	if err != nil {
+		respondEphemeral(ctx, interactionCreate, err.Error())
+		return
+	}
+
+	poll := clients.Poll{
+		CourseID:  interactionCreate.GuildID,
+		AuthorID:  interactionCreate.Member.User.ID,
+		ChannelID: interactionCreate.ChannelID,
+		Question:  optionMap["question"].StringValue(),
+		Options:   options,
+		Anonymous: true,
+		CreatedAt: time.Now().Truncate(time.Second),
+	}
+	if opt, exists := optionMap["multiple"]; exists {
+		poll.MultipleChoice = opt.BoolValue()
+	}
+	if opt, exists := optionMap["anonymous"]; exists {
+		poll.Anonymous = opt.BoolValue()
+	}
+	if opt, exists := optionMap["duration"]; exists {
+		duration, err := time.ParseDuration(opt.StringValue())
+		if err != nil {
+			respondEphemeral(ctx, interactionCreate, locale.T("error.parse_poll_duration", opt.StringValue()))
+			return
+		}
+		if duration < minPollDuration || duration > maxPollDuration {
+			respondEphemeral(ctx, interactionCreate, locale.T("poll.duration_range"))
+			return
+		}
+		poll.ClosesAt = poll.CreatedAt.Add(duration)
+	}
+
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	createdPoll, err := hakaseClient.Backend.CreatePoll(ctx, poll)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error creating poll").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.create_poll", err.Error()))
+		return
+	}
+
+	createdPoll, err = clients.PostPoll(ctx, hakaseClient.Backend, createdPoll)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to post poll: %d", createdPoll.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.post_poll", err.Error()))
+		return
+	}
+
+	if createdPoll.ClosesAt.IsZero() {
+		followupEphemeral(ctx, interactionCreate, locale.T("poll.posted"))
+		return
+	}
+	err = hakaseClient.Notifications.SchedulePollClose(ctx, clients.PollCloseNotification{
+		PollID:   createdPoll.ID,
+		CourseID: createdPoll.CourseID,
+		ClosesAt: createdPoll.ClosesAt,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "failed to schedule closing poll: %d", createdPoll.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.schedule_poll_close", err.Error()))
+		return
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("poll.posted_closes", fmt.Sprintf("<t:%d:R>", createdPoll.ClosesAt.Unix())))
+}
+
+// parsePollOptions splits the options of a poll, returning a localized error if there are too few or too many, or one is too long.
+func parsePollOptions(locale locales.Locale, value string) ([]string, error) {
+	options := []string{}
+	for option := range strings.SplitSeq(value, pollOptionSeparator) {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			continue
+		}
+		if len([]rune(option)) > maxPollOptionLength {
+			return nil, errors.New(locale.T("poll.option_too_long", maxPollOptionLength))
+		}
+		options = append(options, option)
+	}
+	if len(options) < 2 || len(options) > clients.MaxPollOptions {
+		return nil, errors.New(locale.T("poll.option_count", clients.MaxPollOptions))
+	}
+	return options, nil
+}
@@ -0,0 +1,372 @@
+// Package interactions provides handlers for the /studygroup slash command.
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+var (
+	minStudyGroupSize = float64(clients.MinStudyGroupSize)
+	maxStudyGroupSize = float64(clients.MaxStudyGroupSize)
+)
+
+var StudyGroupCommand = discordgo.ApplicationCommand{
+	Name:                     "studygroup",
+	Description:              locales.Default.T("command.studygroup.description"),
+	DescriptionLocalizations: localized("command.studygroup.description"),
+	Type:                     discordgo.ChatApplicationCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:                     "join",
+			Description:              locales.Default.T("command.studygroup.join.description"),
+			DescriptionLocalizations: locales.Localizations("command.studygroup.join.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "topics",
+					Description:              locales.Default.T("command.studygroup.topics.description"),
+					DescriptionLocalizations: locales.Localizations("command.studygroup.topics.description"),
+					Type:                     discordgo.ApplicationCommandOptionString,
+					Required:                 true,
+					MaxLength:                200,
+				},
+			},
+		},
+		{
+			Name:                     "leave",
+			Description:              locales.Default.T("command.studygroup.leave.description"),
+			DescriptionLocalizations: locales.Localizations("command.studygroup.leave.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+		},
+		{
+			Name:                     "rematch",
+			Description:              locales.Default.T("command.studygroup.rematch.description"),
+			DescriptionLocalizations: locales.Localizations("command.studygroup.rematch.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+		},
+		{
+			Name:                     "config",
+			Description:              locales.Default.T("command.studygroup.config.description"),
+			DescriptionLocalizations: locales.Localizations("command.studygroup.config.description"),
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:                     "channel",
+					Description:              locales.Default.T("command.studygroup.channel.description"),
+					DescriptionLocalizations: locales.Localizations("command.studygroup.channel.description"),
+					Type:                     discordgo.ApplicationCommandOptionChannel,
+					ChannelTypes:             []discordgo.ChannelType{discordgo.ChannelTypeGuildCategory, discordgo.ChannelTypeGuildText},
+					Required:                 true,
+				},
+				{
+					Name:                     "size",
+					Description:              locales.Default.T("command.studygroup.size.description"),
+					DescriptionLocalizations: locales.Localizations("command.studygroup.size.description"),
+					Type:                     discordgo.ApplicationCommandOptionInteger,
+					MinValue:                 &minStudyGroupSize,
+					MaxValue:                 maxStudyGroupSize,
+				},
+			},
+		},
+	},
+}
+
+// SlashStudyGroup handles the /studygroup slash command interaction.
+// It dispatches the join, leave and rematch subcommands for students, and the config subcommand for staff.
+func SlashStudyGroup(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	slog.Info(fmt.Sprintf("/studygroup executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "/studygroup")
+	defer span.End()
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	subcommand := interactionCreate.ApplicationCommandData().Options[0]
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(subcommand.Options))
+	for _, opt := range subcommand.Options {
+		optionMap[opt.Name] = opt
+	}
+
+	if subcommand.Name == "config" {
+		configStudyGroups(ctx, interactionCreate, hakaseClient, locale, optionMap)
+		return
+	}
+
+	err := bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+
+	groups, err := hakaseClient.Backend.ListStudyGroups(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing study groups").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.list_study_groups", err.Error()))
+		return
+	}
+	group, inGroup := clients.StudyGroupOf(groups, interactionCreate.Member.User.ID)
+
+	switch subcommand.Name {
+	case "join":
+		if inGroup {
+			followupEphemeral(ctx, interactionCreate, locale.T("studygroup.already_in_group", fmt.Sprintf("<#%s>", group.ChannelID)))
+			return
+		}
+		joinStudyGroups(ctx, interactionCreate, hakaseClient, locale, optionMap["topics"].StringValue())
+	case "leave":
+		if inGroup {
+			leaveStudyGroup(ctx, interactionCreate, hakaseClient, locale, group, false)
+			return
+		}
+		leaveStudyGroupRequests(ctx, interactionCreate, hakaseClient, locale)
+	case "rematch":
+		if !inGroup {
+			followupEphemeral(ctx, interactionCreate, locale.T("studygroup.not_in_group"))
+			return
+		}
+		leaveStudyGroup(ctx, interactionCreate, hakaseClient, locale, group, true)
+	default:
+		slog.Error(fmt.Sprintf("unknown /studygroup subcommand: %s", subcommand.Name))
+	}
+}
+
+// joinStudyGroups adds the student to the students waiting to be matched into study groups with their topics,
+// then lets them choose when they are available. New students are available at every time until they choose.
+func joinStudyGroups(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, topics string) {
+	ctx, span := tracing.Start(ctx, "/studygroup joinStudyGroups")
+	defer span.End()
+
+	request := clients.StudyGroupRequest{
+		CourseID:     interactionCreate.GuildID,
+		UserID:       interactionCreate.Member.User.ID,
+		Topics:       clients.ParseTopics(topics),
+		Availability: clients.StudyGroupSlots,
+		CreatedAt:    time.Now().Truncate(time.Second),
+	}
+	if len(request.Topics) == 0 {
+		followupEphemeral(ctx, interactionCreate, locale.T("studygroup.no_topics"))
+		return
+	}
+
+	course, err := hakaseClient.Backend.ReadCourse(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading course: %s", interactionCreate.GuildID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.read_course", err.Error()))
+		return
+	}
+	if course.StudyGroupChannel == "" {
+		followupEphemeral(ctx, interactionCreate, locale.T("studygroup.not_configured"))
+		return
+	}
+
+	requests, err := hakaseClient.Backend.ListStudyGroupRequests(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing study group requests").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.join_study_groups", err.Error()))
+		return
+	}
+	if existing, waiting := clients.StudyGroupRequestOf(requests, request.UserID); waiting {
+		// changing topics keeps the student's place and availability, so that students who waited longest are still matched first
+		existing.Topics = request.Topics
+		request, err = hakaseClient.Backend.UpdateStudyGroupRequest(ctx, existing)
+	} else {
+		request, err = hakaseClient.Backend.CreateStudyGroupRequest(ctx, request)
+	}
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error joining study groups").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.join_study_groups", err.Error()))
+		return
+	}
+
+	_, err = clients.Session(ctx).FollowupMessageCreate(interactionCreate.Interaction, false, &discordgo.WebhookParams{
+		Content:    locale.T("studygroup.joined", strings.Join(request.Topics, ", ")),
+		Components: views.StudyGroupAvailabilityMenu(locale, request.Availability),
+		Flags:      discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
+
+// leaveStudyGroupRequests removes the student from the students waiting to be matched, if they are waiting.
+func leaveStudyGroupRequests(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale) {
+	requests, err := hakaseClient.Backend.ListStudyGroupRequests(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing study group requests").Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.leave_study_group", err.Error()))
+		return
+	}
+	request, waiting := clients.StudyGroupRequestOf(requests, interactionCreate.Member.User.ID)
+	if !waiting {
+		followupEphemeral(ctx, interactionCreate, locale.T("studygroup.not_in_group"))
+		return
+	}
+	err = hakaseClient.Backend.DeleteStudyGroupRequest(ctx, fmt.Sprint(request.ID))
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error deleting study group request: %d", request.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.leave_study_group", err.Error()))
+		return
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("studygroup.left_waiting"))
+}
+
+// leaveStudyGroup removes the student from their study group and its channels. A group left with one member is disbanded,
+// and its last member waits to be matched again. Students rematching also wait to be matched again, with the same topics and availability.
+func leaveStudyGroup(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, group clients.StudyGroup, rematch bool) {
+	ctx, span := tracing.Start(ctx, "/studygroup leaveStudyGroup")
+	defer span.End()
+	bot := clients.Session(ctx)
+	userID := interactionCreate.Member.User.ID
+	member, _ := group.Member(userID)
+
+	remaining := group.Without(userID)
+	disband := len(remaining.Members) < clients.MinStudyGroupSize
+	if disband {
+		remaining.DisbandedAt = time.Now().Truncate(time.Second)
+	}
+	_, err := hakaseClient.Backend.UpdateStudyGroup(ctx, remaining)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error leaving study group: %d", group.ID).Error())
+		followupEphemeral(ctx, interactionCreate, locale.T("error.leave_study_group", err.Error()))
+		return
+	}
+
+	rejoining := []clients.StudyGroupMember{}
+	if rematch {
+		rejoining = append(rejoining, member)
+	}
+	if disband {
+		rejoining = append(rejoining, remaining.Members...)
+		disbandStudyGroup(ctx, remaining)
+	} else {
+		removeStudyGroupMember(ctx, group, userID)
+		_, err = bot.ChannelMessageSendComplex(group.ChannelID, &discordgo.MessageSend{
+			Content:         courseLocale(ctx, interactionCreate.GuildID, hakaseClient.Backend).T("studygroup.member_left", fmt.Sprintf("<@%s>", userID)),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error announcing member leaving study group: %d", group.ID).Error())
+		}
+	}
+
+	for _, rejoin := range rejoining {
+		_, err = hakaseClient.Backend.CreateStudyGroupRequest(ctx, clients.StudyGroupRequest{
+			CourseID:     group.CourseID,
+			UserID:       rejoin.UserID,
+			Topics:       rejoin.Topics,
+			Availability: rejoin.Availability,
+			CreatedAt:    time.Now().Truncate(time.Second),
+		})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error adding %s back to study group requests", rejoin.UserID).Error())
+			if rejoin.UserID == userID {
+				followupEphemeral(ctx, interactionCreate, locale.T("error.rematch_study_group", err.Error()))
+				return
+			}
+		}
+	}
+
+	if rematch {
+		followupEphemeral(ctx, interactionCreate, locale.T("studygroup.rematching"))
+		return
+	}
+	followupEphemeral(ctx, interactionCreate, locale.T("studygroup.left_group"))
+}
+
+// removeStudyGroupMember removes a member's access to their study group's channels or thread.
+func removeStudyGroupMember(ctx context.Context, group clients.StudyGroup, userID string) {
+	bot := clients.Session(ctx)
+	if group.VoiceChannelID == "" {
+		// groups without a voice channel meet in a private thread, or in a text channel if creating their voice channel failed
+		err := bot.ThreadMemberRemove(group.ChannelID, userID)
+		if err == nil {
+			return
+		}
+	}
+	for _, channelID := range []string{group.ChannelID, group.VoiceChannelID} {
+		if channelID == "" {
+			continue
+		}
+		err := bot.ChannelPermissionDelete(channelID, userID)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error removing %s from study group channel: %s", userID, channelID).Error())
+		}
+	}
+}
+
+// disbandStudyGroup deletes a disbanded study group's channels, or archives and locks its thread.
+func disbandStudyGroup(ctx context.Context, group clients.StudyGroup) {
+	bot := clients.Session(ctx)
+	channel, err := bot.Channel(group.ChannelID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error reading study group channel: %s", group.ChannelID).Error())
+		return
+	}
+
+	if channel.IsThread() {
+		archived, locked := true, true
+		_, err = bot.ChannelEdit(group.ChannelID, &discordgo.ChannelEdit{Archived: &archived, Locked: &locked})
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error archiving study group thread: %s", group.ChannelID).Error())
+		}
+		return
+	}
+	for _, channelID := range []string{group.ChannelID, group.VoiceChannelID} {
+		if channelID == "" {
+			continue
+		}
+		_, err = bot.ChannelDelete(channelID)
+		if err != nil {
+			slog.Error(stacktrace.Propagate(err, "error deleting study group channel: %s", channelID).Error())
+		}
+	}
+}
+
+// configStudyGroups sets where the course's study groups meet and how large they are, for staff only.
+// Choosing a category gives each group private text and voice channels in it, and choosing a text channel gives each group a private thread in it.
+func configStudyGroups(ctx context.Context, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient, locale locales.Locale, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	ctx, span := tracing.Start(ctx, "/studygroup configStudyGroups")
+	defer span.End()
+
+	if interactionCreate.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respondEphemeral(ctx, interactionCreate, locale.T("admin_required"))
+		return
+	}
+
+	channelID := optionMap["channel"].ChannelValue(nil).ID
+	course := clients.Course{
+		CourseID:          interactionCreate.GuildID,
+		StudyGroupChannel: channelID,
+		StudyGroupMode:    clients.StudyGroupThreads,
+		StudyGroupSize:    clients.DefaultStudyGroupSize,
+	}
+	if channel, exists := interactionCreate.ApplicationCommandData().Resolved.Channels[channelID]; exists && channel.Type == discordgo.ChannelTypeGuildCategory {
+		course.StudyGroupMode = clients.StudyGroupChannels
+	}
+	if opt, exists := optionMap["size"]; exists {
+		course.StudyGroupSize = int(opt.IntValue())
+	}
+
+	err := hakaseClient.Backend.UpdateCourse(ctx, course)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error configuring study groups: %s", interactionCreate.GuildID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.update_course", err.Error()))
+		return
+	}
+	if course.StudyGroupMode == clients.StudyGroupChannels {
+		respondEphemeral(ctx, interactionCreate, locale.T("studygroup.configured_channels", course.StudyGroupSize, fmt.Sprintf("<#%s>", channelID)))
+		return
+	}
+	respondEphemeral(ctx, interactionCreate, locale.T("studygroup.configured_threads", course.StudyGroupSize, fmt.Sprintf("<#%s>", channelID)))
+}
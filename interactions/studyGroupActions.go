@@ -0,0 +1,53 @@
+// Package interactions provides handlers for study group actions (choose availability).
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/dragonejt/hakase-discord/views"
+	"github.com/palantir/stacktrace"
+)
+
+// StudyGroupAvailability saves when a waiting student can study when they choose from the availability menu.
+func StudyGroupAvailability(bot *discordgo.Session, interactionCreate *discordgo.InteractionCreate, hakaseClient clients.HakaseClient) {
+	ctx, span := tracing.Start(clients.WithSession(context.Background(), bot), "studyGroupAvailabilityAction")
+	defer span.End()
+	slog.Debug(fmt.Sprintf("studyGroupAvailability executed by %s (%s) in %s", interactionCreate.Member.User.Username, interactionCreate.Member.User.ID, interactionCreate.GuildID))
+	locale := responseLocale(ctx, interactionCreate.Interaction, hakaseClient.Backend)
+
+	requests, err := hakaseClient.Backend.ListStudyGroupRequests(ctx, interactionCreate.GuildID)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error listing study group requests").Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.join_study_groups", err.Error()))
+		return
+	}
+	request, waiting := clients.StudyGroupRequestOf(requests, interactionCreate.Member.User.ID)
+	if !waiting {
+		respondEphemeral(ctx, interactionCreate, locale.T("studygroup.not_waiting"))
+		return
+	}
+
+	request.Availability = interactionCreate.MessageComponentData().Values
+	request, err = hakaseClient.Backend.UpdateStudyGroupRequest(ctx, request)
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error updating study group request: %d", request.ID).Error())
+		respondEphemeral(ctx, interactionCreate, locale.T("error.join_study_groups", err.Error()))
+		return
+	}
+
+	err = bot.InteractionRespond(interactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    locale.T("studygroup.availability_saved", views.StudyGroupSlotNames(locale, request.Availability)),
+			Components: views.StudyGroupAvailabilityMenu(locale, request.Availability),
+		},
+	})
+	if err != nil {
+		slog.Error(stacktrace.Propagate(err, "error responding to interaction").Error())
+	}
+}
@@ -0,0 +1,93 @@
+// Package lifecycle tracks in-flight interaction handlers so that the bot can shut down without losing work.
+package lifecycle
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/palantir/stacktrace"
+)
+
+// Manager tracks in-flight interaction handlers, including the reminders they publish.
+// Once draining, it stops accepting new handlers and waits for tracked handlers to finish.
+// A nil Manager runs handlers without tracking them.
+type Manager struct {
+	lock     sync.Mutex
+	inFlight int
+	draining bool
+	idle     chan struct{}
+	idleOnce sync.Once
+}
+
+// New creates a Manager that is accepting handlers.
+func New() *Manager {
+	return &Manager{idle: make(chan struct{})}
+}
+
+// start tracks a handler, returning false if the manager is draining.
+func (manager *Manager) start() bool {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	if manager.draining {
+		return false
+	}
+	manager.inFlight++
+	return true
+}
+
+// done stops tracking a handler, signalling idle if the manager is draining and nothing is in flight.
+func (manager *Manager) done() {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	manager.inFlight--
+	if manager.draining && manager.inFlight == 0 {
+		manager.idleOnce.Do(func() { close(manager.idle) })
+	}
+}
+
+// Handle runs an interaction handler and tracks it until it returns.
+// It reports false without running the handler if the manager is draining.
+func (manager *Manager) Handle(handler func()) bool {
+	if manager == nil {
+		handler()
+		return true
+	}
+	if !manager.start() {
+		return false
+	}
+	defer manager.done()
+	handler()
+	return true
+}
+
+// Draining reports whether the manager has stopped accepting new handlers.
+func (manager *Manager) Draining() bool {
+	if manager == nil {
+		return false
+	}
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	return manager.draining
+}
+
+// Drain stops accepting new handlers and waits for in-flight handlers to finish, or for ctx to be done.
+func (manager *Manager) Drain(ctx context.Context) error {
+	manager.lock.Lock()
+	manager.draining = true
+	inFlight := manager.inFlight
+	if inFlight == 0 {
+		manager.idleOnce.Do(func() { close(manager.idle) })
+	}
+	manager.lock.Unlock()
+
+	slog.Info("draining in-flight handlers", "in_flight", inFlight)
+	select {
+	case <-manager.idle:
+		return nil
+	case <-ctx.Done():
+		manager.lock.Lock()
+		defer manager.lock.Unlock()
+		return stacktrace.Propagate(ctx.Err(), "%d handlers still in flight", manager.inFlight)
+	}
+}
@@ -0,0 +1,72 @@
+package lifecycle_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/lifecycle"
+	"github.com/stretchr/testify/suite"
+)
+
+type LifecycleTestSuite struct {
+	suite.Suite
+	manager *lifecycle.Manager
+}
+
+func TestLifecycle(t *testing.T) {
+	suite.Run(t, new(LifecycleTestSuite))
+}
+
+func (testSuite *LifecycleTestSuite) SetupTest() {
+	testSuite.manager = lifecycle.New()
+}
+
+func (testSuite *LifecycleTestSuite) TestDrainWaitsForHandlers() {
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	go testSuite.manager.Handle(func() {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		close(finished)
+	})
+	<-started
+
+	testSuite.NoError(testSuite.manager.Drain(context.Background()))
+	testSuite.True(testSuite.manager.Draining())
+	select {
+	case <-finished:
+	default:
+		testSuite.Fail("drain returned before the handler finished")
+	}
+}
+
+func (testSuite *LifecycleTestSuite) TestDrainRejectsNewHandlers() {
+	testSuite.NoError(testSuite.manager.Drain(context.Background()))
+	testSuite.False(testSuite.manager.Handle(func() {
+		testSuite.Fail("handler ran while draining")
+	}))
+}
+
+func (testSuite *LifecycleTestSuite) TestDrainDeadline() {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	go testSuite.manager.Handle(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	testSuite.Error(testSuite.manager.Drain(ctx))
+}
+
+func (testSuite *LifecycleTestSuite) TestNilManager() {
+	var manager *lifecycle.Manager
+	ran := false
+	testSuite.True(manager.Handle(func() { ran = true }))
+	testSuite.True(ran)
+	testSuite.False(manager.Draining())
+}
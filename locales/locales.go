@@ -0,0 +1,195 @@
+// Package locales provides the message catalog for user-facing strings, with a bundle of messages for each supported language.
+// Responses use the user's Discord locale if it is supported, falling back to the course's default locale, then English.
+package locales
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"gopkg.in/yaml.v3"
+)
+
+// Locale is the name of a message bundle, such as en or zh-CN.
+type Locale string
+
+// Default is the locale used when neither the user's locale nor the course's default locale is supported.
+const Default Locale = "en"
+
+// Supported are the locales with a message bundle, in the order they are offered to courses.
+var Supported = []Locale{"en", "es", "zh-CN", "ja"}
+
+// Names are the names of the supported locales in their own language.
+var Names = map[Locale]string{
+	"en":    "English",
+	"es":    "Español",
+	"zh-CN": "中文（简体）",
+	"ja":    "日本語",
+}
+
+// discordLocales maps the Discord locales that have a message bundle to their bundle.
+var discordLocales = map[discordgo.Locale]Locale{
+	discordgo.EnglishUS:    "en",
+	discordgo.EnglishGB:    "en",
+	discordgo.SpanishES:    "es",
+	discordgo.SpanishLATAM: "es",
+	discordgo.ChineseCN:    "zh-CN",
+	discordgo.Japanese:     "ja",
+}
+
+//go:embed bundles/*.yaml
+var bundleFiles embed.FS
+
+// bundles are the messages of each supported locale, by key.
+var bundles = loadBundles()
+
+// loadBundles parses the embedded message bundles. The bundles are part of the binary, so a malformed bundle is a programming error.
+func loadBundles() map[Locale]map[string]string {
+	bundles := make(map[Locale]map[string]string, len(Supported))
+	for _, locale := range Supported {
+		file, err := bundleFiles.ReadFile(fmt.Sprintf("bundles/%s.yaml", locale))
+		if err != nil {
+			panic(fmt.Sprintf("missing message bundle for locale %s: %s", locale, err))
+		}
+		messages := map[string]string{}
+		err = yaml.Unmarshal(file, &messages)
+		if err != nil {
+			panic(fmt.Sprintf("invalid message bundle for locale %s: %s", locale, err))
+		}
+		bundles[locale] = messages
+	}
+	return bundles
+}
+
+// Lookup returns the supported locale for a Discord locale or a locale name, and whether it is supported.
+func Lookup(name string) (Locale, bool) {
+	if locale, exists := discordLocales[discordgo.Locale(name)]; exists {
+		return locale, true
+	}
+	if slices.Contains(Supported, Locale(name)) {
+		return Locale(name), true
+	}
+	return Default, false
+}
+
+// Resolve returns the first supported locale in names, such as the user's locale and then the course's default locale, or Default.
+func Resolve(names ...string) Locale {
+	for _, name := range names {
+		if locale, supported := Lookup(name); supported {
+			return locale
+		}
+	}
+	return Default
+}
+
+// Keys returns the message keys in the locale's bundle, sorted.
+func (locale Locale) Keys() []string {
+	keys := make([]string, 0, len(bundles[locale]))
+	for key := range bundles[locale] {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// T returns the message for key in the locale, formatted with args. Messages missing from the locale fall back to Default.
+func (locale Locale) T(key string, args ...any) string {
+	message, exists := bundles[locale][key]
+	if !exists {
+		message, exists = bundles[Default][key]
+	}
+	if !exists {
+		slog.Error(fmt.Sprintf("missing message for key: %s", key))
+		return key
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Duration formats a duration in its largest whole unit, such as "2 weeks", "3 days" or "1 hour".
+// Weeks are used only for whole weeks, so that reminders such as 10 days before are not rounded down to 1 week.
+func (locale Locale) Duration(duration time.Duration) string {
+	week, day := 7*24*time.Hour, 24*time.Hour
+
+	count, unit := int(duration/time.Minute), "minute"
+	if duration >= week && duration%week == 0 {
+		count, unit = int(duration/week), "week"
+	} else if duration >= day {
+		count, unit = int(duration/day), "day"
+	} else if duration >= time.Hour {
+		count, unit = int(duration/time.Hour), "hour"
+	}
+
+	if count == 1 {
+		return locale.T(fmt.Sprintf("duration.%s.one", unit), count)
+	}
+	return locale.T(fmt.Sprintf("duration.%s.other", unit), count)
+}
+
+// Localizations returns the message for key in every Discord locale with a bundle other than Default,
+// for the localized names and descriptions of application commands.
+func Localizations(key string) map[discordgo.Locale]string {
+	localizations := map[discordgo.Locale]string{}
+	for discordLocale, locale := range discordLocales {
+		if locale == Default {
+			continue
+		}
+		localizations[discordLocale] = locale.T(key)
+	}
+	return localizations
+}
+
+// String returns the locale name.
+func (locale Locale) String() string {
+	return string(locale)
+}
+
+// Validate returns an error listing every message missing from a bundle, every message that is not in Default,
+// and every message whose formatting verbs differ from Default, such as a translation missing a %s.
+func Validate() error {
+	errs := []error{}
+	for _, locale := range Supported[1:] {
+		for key, message := range bundles[Default] {
+			translation, exists := bundles[locale][key]
+			if !exists {
+				errs = append(errs, fmt.Errorf("%s is missing key: %s", locale, key))
+			} else if !slices.Equal(verbs(message), verbs(translation)) {
+				errs = append(errs, fmt.Errorf("%s has different formatting verbs for key %s: %q", locale, key, translation))
+			}
+		}
+		for key := range bundles[locale] {
+			if _, exists := bundles[Default][key]; !exists {
+				errs = append(errs, fmt.Errorf("%s has key not in %s: %s", locale, Default, key))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// verbs returns the formatting verbs in a message, such as %s and %d, ignoring escaped percent signs.
+func verbs(message string) []string {
+	found := []string{}
+	for index := 0; index < len(message); index++ {
+		if message[index] != '%' || index+1 >= len(message) {
+			continue
+		}
+		end := strings.IndexAny(message[index+1:], "%vdsfqxtc")
+		if end < 0 {
+			break
+		}
+		verb := message[index+1 : index+2+end]
+		index += 1 + end
+		if verb != "%" {
+			found = append(found, verb[len(verb)-1:])
+		}
+	}
+	slices.Sort(found)
+	return found
+}
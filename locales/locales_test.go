@@ -0,0 +1,85 @@
+package locales_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/locales"
+	"github.com/stretchr/testify/suite"
+)
+
+type LocalesTestSuite struct {
+	suite.Suite
+}
+
+func TestLocales(t *testing.T) {
+	suite.Run(t, new(LocalesTestSuite))
+}
+
+func (testSuite *LocalesTestSuite) TestBundlesComplete() {
+	testSuite.NoError(locales.Validate())
+	for _, locale := range locales.Supported {
+		testSuite.Equal(locales.Default.Keys(), locale.Keys(), locale)
+		testSuite.Contains(locales.Names, locale)
+	}
+}
+
+// TestKeysUsedExist fails when code uses a message key that is not in the default bundle.
+func (testSuite *LocalesTestSuite) TestKeysUsedExist() {
+	keyPattern := regexp.MustCompile(`(?:\.T|Localizations|localized)\("([^"]+)"`)
+	keys := locales.Default.Keys()
+
+	err := filepath.WalkDir("..", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, match := range keyPattern.FindAllStringSubmatch(string(source), -1) {
+			key := match[1]
+			testSuite.True(slices.Contains(keys, key), "%s uses missing key: %s", path, key)
+		}
+		return nil
+	})
+	testSuite.NoError(err)
+}
+
+func (testSuite *LocalesTestSuite) TestResolve() {
+	testSuite.Equal(locales.Locale("es"), locales.Resolve(string(discordgo.SpanishLATAM)))
+	testSuite.Equal(locales.Locale("zh-CN"), locales.Resolve(string(discordgo.ChineseCN)))
+	// unsupported user locales fall back to the course's default locale, then English
+	testSuite.Equal(locales.Locale("ja"), locales.Resolve(string(discordgo.French), "ja"))
+	testSuite.Equal(locales.Default, locales.Resolve(string(discordgo.French), ""))
+}
+
+func (testSuite *LocalesTestSuite) TestT() {
+	testSuite.Equal("assignment 1 deleted!", locales.Default.T("assignment.deleted", "1"))
+	testSuite.Equal("¡tarea 1 eliminada!", locales.Locale("es").T("assignment.deleted", "1"))
+	testSuite.Equal("missing.key", locales.Default.T("missing.key"))
+}
+
+func (testSuite *LocalesTestSuite) TestDuration() {
+	testSuite.Equal("2 weeks", locales.Default.Duration(14*24*time.Hour))
+	testSuite.Equal("10 days", locales.Default.Duration(10*24*time.Hour))
+	testSuite.Equal("1 hour", locales.Default.Duration(time.Hour))
+	testSuite.Equal("30 minutes", locales.Default.Duration(30*time.Minute))
+	testSuite.Equal("3 días", locales.Locale("es").Duration(3*24*time.Hour))
+	testSuite.Equal("1 日", locales.Locale("ja").Duration(24*time.Hour))
+}
+
+func (testSuite *LocalesTestSuite) TestLocalizations() {
+	localizations := locales.Localizations("command.hakase.description")
+	testSuite.Equal("configuración del curso", localizations[discordgo.SpanishES])
+	testSuite.Equal("configuración del curso", localizations[discordgo.SpanishLATAM])
+	testSuite.Equal("コースの設定", localizations[discordgo.Japanese])
+	testSuite.NotContains(localizations, discordgo.EnglishUS)
+}
@@ -0,0 +1,17 @@
+// Package metrics provides the metrics recorded by the bot.
+package metrics
+
+var (
+	// InteractionsHandled counts Discord interactions by type (command, component, modal, autocomplete) and name.
+	InteractionsHandled = NewCounter("hakase_interactions_handled_total", "Discord interactions handled.", "type", "name")
+	// HandlerLatency observes how long interaction handlers take in seconds by interaction type.
+	HandlerLatency = NewHistogram("hakase_interaction_handler_seconds", "Interaction handler latency in seconds.", DefaultBuckets, "type")
+	// BackendErrors counts failed backend API requests by response status, or "transport" if no response was received.
+	BackendErrors = NewCounter("hakase_backend_errors_total", "Failed backend API requests.", "status")
+	// RemindersSent counts assignment reminders posted to Discord.
+	RemindersSent = NewCounter("hakase_reminders_sent_total", "Assignment reminders sent.")
+	// RemindersFailed counts assignment reminders that failed to post to Discord.
+	RemindersFailed = NewCounter("hakase_reminders_failed_total", "Assignment reminders that failed to send.")
+	// Redeliveries counts JetStream messages delivered more than once.
+	Redeliveries = NewCounter("hakase_jetstream_redeliveries_total", "JetStream messages redelivered to the consumer.")
+)
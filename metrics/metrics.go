@@ -0,0 +1,232 @@
+// Package metrics provides process metrics exposed in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// metric is a metric family that can write itself in the Prometheus text format.
+type metric interface {
+	write(writer io.Writer) error
+}
+
+var (
+	registryLock sync.Mutex
+	registry     []metric
+)
+
+// register adds a metric to the registry written by WriteTo.
+func register(m metric) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	registry = append(registry, m)
+}
+
+// WriteTo writes every registered metric in the Prometheus text exposition format.
+func WriteTo(writer io.Writer) error {
+	registryLock.Lock()
+	metrics := append([]metric{}, registry...)
+	registryLock.Unlock()
+
+	for _, m := range metrics {
+		err := m.write(writer)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// labelString formats label names and values as {name="value",...}, escaping values.
+func labelString(names []string, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for index, name := range names {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(values[index])
+		pairs[index] = fmt.Sprintf(`%s="%s"`, name, value)
+	}
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ","))
+}
+
+// formatFloat formats a sample value the way Prometheus expects.
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// Counter is a monotonically increasing metric, optionally partitioned by labels.
+type Counter struct {
+	name       string
+	help       string
+	labelNames []string
+	lock       sync.Mutex
+	values     map[string]float64
+	labels     map[string][]string
+}
+
+// NewCounter creates and registers a counter with the given label names.
+func NewCounter(name string, help string, labelNames ...string) *Counter {
+	counter := &Counter{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     map[string]float64{},
+		labels:     map[string][]string{},
+	}
+	register(counter)
+	return counter
+}
+
+// Inc increments the counter for the label values by one.
+func (counter *Counter) Inc(labelValues ...string) {
+	counter.Add(1, labelValues...)
+}
+
+// Add increments the counter for the label values by value.
+func (counter *Counter) Add(value float64, labelValues ...string) {
+	if len(labelValues) != len(counter.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", counter.name, len(counter.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	counter.lock.Lock()
+	defer counter.lock.Unlock()
+	counter.values[key] += value
+	counter.labels[key] = labelValues
+}
+
+// Value returns the counter's value for the label values.
+func (counter *Counter) Value(labelValues ...string) float64 {
+	counter.lock.Lock()
+	defer counter.lock.Unlock()
+	return counter.values[strings.Join(labelValues, "\xff")]
+}
+
+func (counter *Counter) write(writer io.Writer) error {
+	counter.lock.Lock()
+	defer counter.lock.Unlock()
+
+	_, err := fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s counter\n", counter.name, counter.help, counter.name)
+	if err != nil {
+		return err
+	}
+	if len(counter.labelNames) == 0 && len(counter.values) == 0 {
+		_, err = fmt.Fprintf(writer, "%s 0\n", counter.name)
+		return err
+	}
+
+	keys := make([]string, 0, len(counter.values))
+	for key := range counter.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		_, err = fmt.Fprintf(writer, "%s%s %s\n", counter.name, labelString(counter.labelNames, counter.labels[key]), formatFloat(counter.values[key]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histogram samples observations into cumulative buckets, optionally partitioned by labels.
+type Histogram struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+	lock       sync.Mutex
+	series     map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// DefaultBuckets are latency buckets in seconds suited to Discord interaction handlers.
+var DefaultBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// NewHistogram creates and registers a histogram with the given upper bucket bounds and label names.
+func NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	histogram := &Histogram{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     map[string]*histogramSeries{},
+	}
+	register(histogram)
+	return histogram
+}
+
+// Observe records a value for the label values.
+func (histogram *Histogram) Observe(value float64, labelValues ...string) {
+	if len(labelValues) != len(histogram.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", histogram.name, len(histogram.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+	series, exists := histogram.series[key]
+	if !exists {
+		series = &histogramSeries{labels: labelValues, counts: make([]uint64, len(histogram.buckets))}
+		histogram.series[key] = series
+	}
+	for index, bound := range histogram.buckets {
+		if value <= bound {
+			series.counts[index]++
+		}
+	}
+	series.count++
+	series.sum += value
+}
+
+func (histogram *Histogram) write(writer io.Writer) error {
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+
+	_, err := fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s histogram\n", histogram.name, histogram.help, histogram.name)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(histogram.series))
+	for key := range histogram.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		series := histogram.series[key]
+		bucketLabelNames := append(append([]string{}, histogram.labelNames...), "le")
+		for index, bound := range histogram.buckets {
+			bucketLabels := append(append([]string{}, series.labels...), formatFloat(bound))
+			_, err = fmt.Fprintf(writer, "%s_bucket%s %d\n", histogram.name, labelString(bucketLabelNames, bucketLabels), series.counts[index])
+			if err != nil {
+				return err
+			}
+		}
+		infLabels := append(append([]string{}, series.labels...), "+Inf")
+		_, err = fmt.Fprintf(writer, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			histogram.name, labelString(bucketLabelNames, infLabels), series.count,
+			histogram.name, labelString(histogram.labelNames, series.labels), formatFloat(series.sum),
+			histogram.name, labelString(histogram.labelNames, series.labels), series.count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
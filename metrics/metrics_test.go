@@ -0,0 +1,54 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dragonejt/hakase-discord/metrics"
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsTestSuite struct {
+	suite.Suite
+}
+
+func TestMetrics(t *testing.T) {
+	suite.Run(t, new(MetricsTestSuite))
+}
+
+func (testSuite *MetricsTestSuite) TestCounter() {
+	counter := metrics.NewCounter("test_counter_total", "Test counter.", "status")
+	counter.Inc("500")
+	counter.Add(2, "500")
+	counter.Inc(`quote"`)
+	testSuite.Equal(float64(3), counter.Value("500"))
+
+	output := strings.Builder{}
+	testSuite.Require().NoError(metrics.WriteTo(&output))
+	testSuite.Contains(output.String(), "# TYPE test_counter_total counter\n")
+	testSuite.Contains(output.String(), "test_counter_total{status=\"500\"} 3\n")
+	testSuite.Contains(output.String(), "test_counter_total{status=\"quote\\\"\"} 1\n")
+}
+
+func (testSuite *MetricsTestSuite) TestUnlabelledCounterStartsAtZero() {
+	metrics.NewCounter("test_unlabelled_total", "Test unlabelled counter.")
+
+	output := strings.Builder{}
+	testSuite.Require().NoError(metrics.WriteTo(&output))
+	testSuite.Contains(output.String(), "test_unlabelled_total 0\n")
+}
+
+func (testSuite *MetricsTestSuite) TestHistogram() {
+	histogram := metrics.NewHistogram("test_latency_seconds", "Test latency.", []float64{0.1, 1}, "type")
+	histogram.Observe(0.05, "command")
+	histogram.Observe(0.5, "command")
+
+	output := strings.Builder{}
+	testSuite.Require().NoError(metrics.WriteTo(&output))
+	testSuite.Contains(output.String(), "# TYPE test_latency_seconds histogram\n")
+	testSuite.Contains(output.String(), "test_latency_seconds_bucket{type=\"command\",le=\"0.1\"} 1\n")
+	testSuite.Contains(output.String(), "test_latency_seconds_bucket{type=\"command\",le=\"1\"} 2\n")
+	testSuite.Contains(output.String(), "test_latency_seconds_bucket{type=\"command\",le=\"+Inf\"} 2\n")
+	testSuite.Contains(output.String(), "test_latency_seconds_sum{type=\"command\"} 0.55\n")
+	testSuite.Contains(output.String(), "test_latency_seconds_count{type=\"command\"} 2\n")
+}
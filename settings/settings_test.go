@@ -0,0 +1,114 @@
+package settings_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dragonejt/hakase-discord/settings"
+	"github.com/stretchr/testify/suite"
+)
+
+type SettingsTestSuite struct {
+	suite.Suite
+}
+
+func TestSettings(t *testing.T) {
+	suite.Run(t, new(SettingsTestSuite))
+}
+
+func (testSuite *SettingsTestSuite) SetupTest() {
+	for _, name := range []string{"CONFIG_FILE", "ENV", "LOG_LEVEL", "DISCORD_BOT_TOKEN", "DEV_GUILD_ID", "BACKEND_URL", "BACKEND_API_KEY", "BACKEND_TIMEOUT", "NATS_URL", "STREAM_NAME", "PUBLISH_TIMEOUT", "SENTRY_DSN", "HEALTH_ADDR", "SHUTDOWN_TIMEOUT", "DUPLICATE_WINDOW", "SHARD_ID", "SHARD_COUNT", "TRACE_EXPORTER"} {
+		testSuite.T().Setenv(name, "")
+	}
+}
+
+func (testSuite *SettingsTestSuite) setRequired() {
+	testSuite.T().Setenv("DISCORD_BOT_TOKEN", "env-discord-bot-token")
+	testSuite.T().Setenv("BACKEND_URL", "http://localhost:8000")
+	testSuite.T().Setenv("BACKEND_API_KEY", "env-backend-api-key")
+	testSuite.T().Setenv("NATS_URL", "nats://localhost:4222")
+	testSuite.T().Setenv("STREAM_NAME", "hakase_discord_local")
+}
+
+func (testSuite *SettingsTestSuite) TestLoadMissingRequired() {
+	_, err := settings.Load("")
+	testSuite.Error(err)
+	for _, name := range []string{"DISCORD_BOT_TOKEN", "BACKEND_URL", "BACKEND_API_KEY", "NATS_URL", "STREAM_NAME"} {
+		testSuite.Contains(err.Error(), name)
+	}
+}
+
+func (testSuite *SettingsTestSuite) TestLoadDefaults() {
+	testSuite.setRequired()
+	testSuite.T().Setenv("ENV", "development")
+
+	config, err := settings.Load("")
+	testSuite.NoError(err)
+	testSuite.Equal("debug", config.LogLevel)
+	testSuite.Equal(10*time.Second, config.BackendTimeout)
+	testSuite.Equal(10*time.Second, config.PublishTimeout)
+	testSuite.Equal(30*time.Second, config.ShutdownTimeout)
+	testSuite.Equal(time.Hour, config.DuplicateWindow)
+	testSuite.False(config.Production())
+}
+
+func (testSuite *SettingsTestSuite) TestLoadFileWithEnvOverrides() {
+	testSuite.T().Setenv("BACKEND_API_KEY", "env-backend-api-key")
+	testSuite.T().Setenv("PUBLISH_TIMEOUT", "3s")
+
+	config, err := settings.Load("testdata/config.yaml")
+	testSuite.NoError(err)
+	testSuite.True(config.Production())
+	testSuite.Equal("info", config.LogLevel)
+	testSuite.Equal("file-discord-bot-token", config.DiscordBotToken)
+	testSuite.Equal("env-backend-api-key", config.BackendAPIKey)
+	testSuite.Equal(5*time.Second, config.BackendTimeout)
+	testSuite.Equal(3*time.Second, config.PublishTimeout)
+	testSuite.Equal([]time.Duration{7 * 24 * time.Hour, 24 * time.Hour}, config.Reminders["exam"])
+}
+
+func (testSuite *SettingsTestSuite) TestLoadInvalid() {
+	testSuite.setRequired()
+	testSuite.T().Setenv("BACKEND_URL", "localhost")
+	testSuite.T().Setenv("STREAM_NAME", "hakase.discord")
+	testSuite.T().Setenv("LOG_LEVEL", "verbose")
+
+	_, err := settings.Load("")
+	testSuite.Error(err)
+	testSuite.Contains(err.Error(), "BACKEND_URL")
+	testSuite.Contains(err.Error(), "STREAM_NAME")
+	testSuite.Contains(err.Error(), "LOG_LEVEL")
+
+	testSuite.T().Setenv("BACKEND_TIMEOUT", "soon")
+	_, err = settings.Load("")
+	testSuite.Error(err)
+}
+
+func (testSuite *SettingsTestSuite) TestLoadShards() {
+	testSuite.setRequired()
+	testSuite.T().Setenv("SHARD_ID", "2")
+	testSuite.T().Setenv("SHARD_COUNT", "4")
+
+	config, err := settings.Load("")
+	testSuite.NoError(err)
+	testSuite.Equal(2, config.ShardID)
+	testSuite.Equal(4, config.ShardCount)
+
+	testSuite.T().Setenv("SHARD_ID", "4")
+	_, err = settings.Load("")
+	testSuite.Error(err)
+	testSuite.Contains(err.Error(), "SHARD_ID")
+}
+
+func (testSuite *SettingsTestSuite) TestRedacted() {
+	config, err := settings.Load("testdata/config.yaml")
+	testSuite.NoError(err)
+
+	for _, output := range []string{config.String(), config.Redacted().NATSUrl} {
+		testSuite.False(strings.Contains(output, "file-discord-bot-token"))
+		testSuite.False(strings.Contains(output, "file-backend-api-key"))
+		testSuite.False(strings.Contains(output, "secret"))
+	}
+	testSuite.Contains(config.String(), "https://hakase.dragonejt.dev")
+}
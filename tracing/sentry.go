@@ -0,0 +1,135 @@
+// Package tracing provides the span processor that exports OpenTelemetry spans to Sentry, and the sentry-trace propagator.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sentrySpanProcessor mirrors OpenTelemetry spans as Sentry spans with the same trace and span IDs.
+// Local root spans become Sentry transactions, and their descendants become child spans.
+type sentrySpanProcessor struct {
+	lock  sync.Mutex
+	spans map[trace.SpanID]*sentry.Span
+}
+
+func newSentrySpanProcessor() *sentrySpanProcessor {
+	return &sentrySpanProcessor{spans: map[trace.SpanID]*sentry.Span{}}
+}
+
+// OnStart starts a Sentry transaction for a local root span, or a child of the parent's Sentry span.
+func (processor *sentrySpanProcessor) OnStart(parent context.Context, span sdktrace.ReadWriteSpan) {
+	processor.lock.Lock()
+	defer processor.lock.Unlock()
+
+	spanContext := span.SpanContext()
+	sentrySpan := (*sentry.Span)(nil)
+	if parentSpan, exists := processor.spans[span.Parent().SpanID()]; exists && span.Parent().IsValid() {
+		sentrySpan = parentSpan.StartChild(span.Name())
+	} else {
+		sentrySpan = sentry.StartTransaction(context.Background(), span.Name(), sentry.WithSpanSampled(sentry.SampledTrue))
+		if span.Parent().IsRemote() {
+			sentrySpan.ParentSpanID = sentry.SpanID(span.Parent().SpanID())
+		}
+	}
+	sentrySpan.TraceID = sentry.TraceID(spanContext.TraceID())
+	sentrySpan.SpanID = sentry.SpanID(spanContext.SpanID())
+	sentrySpan.Description = span.Name()
+	sentrySpan.StartTime = span.StartTime()
+	processor.spans[spanContext.SpanID()] = sentrySpan
+}
+
+// OnEnd finishes the Sentry span with the span's status, attributes and end time.
+func (processor *sentrySpanProcessor) OnEnd(span sdktrace.ReadOnlySpan) {
+	processor.lock.Lock()
+	sentrySpan, exists := processor.spans[span.SpanContext().SpanID()]
+	delete(processor.spans, span.SpanContext().SpanID())
+	processor.lock.Unlock()
+	if !exists {
+		return
+	}
+
+	sentrySpan.Status = sentry.SpanStatusOK
+	if span.Status().Code == codes.Error {
+		sentrySpan.Status = sentry.SpanStatusInternalError
+	}
+	for _, attribute := range span.Attributes() {
+		sentrySpan.SetData(string(attribute.Key), attribute.Value.AsInterface())
+	}
+	sentrySpan.EndTime = span.EndTime()
+	sentrySpan.Finish()
+}
+
+// Shutdown flushes spans buffered by Sentry.
+func (processor *sentrySpanProcessor) Shutdown(ctx context.Context) error {
+	return processor.ForceFlush(ctx)
+}
+
+// ForceFlush flushes spans buffered by Sentry until ctx is done.
+func (processor *sentrySpanProcessor) ForceFlush(ctx context.Context) error {
+	sentry.FlushWithContext(ctx)
+	return nil
+}
+
+// sentryTraceHeader is the header Sentry SDKs use to propagate the trace ID, parent span ID and sampling decision.
+const sentryTraceHeader = "sentry-trace"
+
+// sentryTracePropagator propagates trace context in the sentry-trace header, for producers and consumers using Sentry SDKs
+// instead of W3C trace context.
+type sentryTracePropagator struct{}
+
+// Inject writes the span context in ctx as <trace ID>-<span ID>-<sampled>.
+func (sentryTracePropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return
+	}
+	sampled := "0"
+	if spanContext.IsSampled() {
+		sampled = "1"
+	}
+	carrier.Set(sentryTraceHeader, fmt.Sprintf("%s-%s-%s", spanContext.TraceID(), spanContext.SpanID(), sampled))
+}
+
+// Extract continues the trace in the sentry-trace header, unless ctx already carries a remote span context, such as from traceparent.
+func (sentryTracePropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	if trace.SpanContextFromContext(ctx).IsRemote() {
+		return ctx
+	}
+	parts := strings.Split(carrier.Get(sentryTraceHeader), "-")
+	if len(parts) < 2 {
+		return ctx
+	}
+	traceID, err := trace.TraceIDFromHex(parts[0])
+	if err != nil {
+		return ctx
+	}
+	spanID, err := trace.SpanIDFromHex(parts[1])
+	if err != nil {
+		return ctx
+	}
+	// a missing sampling decision defers to this service's sampler, which samples by default
+	flags := trace.FlagsSampled
+	if len(parts) > 2 && parts[2] == "0" {
+		flags = 0
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	}))
+}
+
+// Fields returns the header set by the propagator.
+func (sentryTracePropagator) Fields() []string {
+	return []string{sentryTraceHeader}
+}
@@ -0,0 +1,96 @@
+// Package tracing provides OpenTelemetry tracing for the bot, with spans carried in context.Context.
+// Spans are exported to stdout or an OTLP collector, and to Sentry when Sentry is configured.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"os"
+
+	"github.com/dragonejt/hakase-discord/settings"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the bot's spans.
+const tracerName = "github.com/dragonejt/hakase-discord"
+
+// Start starts a span named name as a child of the span in ctx, if any, and returns a context carrying the new span.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// Error records err on the span in ctx and marks the span as failed.
+func Error(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Inject writes the trace context in ctx to carrier, such as HTTP request headers.
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// Extract returns ctx with the remote trace context read from carrier, so that spans started from it continue the trace.
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// Setup configures the global tracer provider and trace context propagation with W3C traceparent, baggage and sentry-trace headers.
+// Spans are exported with the configured trace exporter, and to Sentry if Sentry is initialized.
+// The returned function flushes and stops exporting spans.
+func Setup(config settings.Config, sentryEnabled bool) (func(context.Context) error, error) {
+	hostname, _ := os.Hostname()
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "hakase-discord"),
+			attribute.String("deployment.environment", config.Env),
+			attribute.String("host.name", hostname),
+		)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	}
+
+	switch config.TraceExporter {
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "failed to create stdout trace exporter")
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case "otlp":
+		// the endpoint and headers are configured by the standard OTEL_EXPORTER_OTLP_* environment variables
+		exporter, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "failed to create OTLP trace exporter")
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+	if sentryEnabled {
+		options = append(options, sdktrace.WithSpanProcessor(newSentrySpanProcessor()))
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}, sentryTracePropagator{}))
+
+	return provider.Shutdown, nil
+}
+
+// Unsampled returns a copy of ctx with an unsampled parent span, so that spans started from it are not exported.
+// It is used for frequent background work such as readiness checks.
+func Unsampled(ctx context.Context) context.Context {
+	traceID := trace.TraceID{}
+	spanID := trace.SpanID{}
+	_, _ = rand.Read(traceID[:])
+	_, _ = rand.Read(spanID[:])
+	return trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+}
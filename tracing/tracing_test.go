@@ -0,0 +1,79 @@
+package tracing_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dragonejt/hakase-discord/settings"
+	"github.com/dragonejt/hakase-discord/tracing"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type TracingTestSuite struct {
+	suite.Suite
+	shutdown func(context.Context) error
+}
+
+func TestTracing(t *testing.T) {
+	suite.Run(t, new(TracingTestSuite))
+}
+
+func (testSuite *TracingTestSuite) SetupTest() {
+	shutdown, err := tracing.Setup(settings.Default(), false)
+	testSuite.Require().NoError(err)
+	testSuite.shutdown = shutdown
+}
+
+func (testSuite *TracingTestSuite) TearDownTest() {
+	testSuite.NoError(testSuite.shutdown(context.Background()))
+}
+
+func (testSuite *TracingTestSuite) TestStartChild() {
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	defer parent.End()
+	_, child := tracing.Start(ctx, "child")
+	defer child.End()
+
+	testSuite.True(child.SpanContext().IsValid())
+	testSuite.Equal(parent.SpanContext().TraceID(), child.SpanContext().TraceID())
+}
+
+func (testSuite *TracingTestSuite) TestPropagationThroughNATSHeaders() {
+	ctx, span := tracing.Start(context.Background(), "publish")
+	defer span.End()
+
+	message := nats.NewMsg("hakase_discord.assignments.0")
+	tracing.Inject(ctx, propagation.HeaderCarrier(message.Header))
+	testSuite.NotEmpty(message.Header.Get("Traceparent"))
+
+	consumerCtx := tracing.Extract(context.Background(), propagation.HeaderCarrier(message.Header))
+	_, consumerSpan := tracing.Start(consumerCtx, "consume")
+	defer consumerSpan.End()
+	testSuite.Equal(span.SpanContext().TraceID(), consumerSpan.SpanContext().TraceID())
+}
+
+func (testSuite *TracingTestSuite) TestSentryTracePropagation() {
+	ctx, span := tracing.Start(context.Background(), "publish")
+	defer span.End()
+
+	headers := propagation.MapCarrier{}
+	tracing.Inject(ctx, headers)
+	testSuite.Equal(fmt.Sprintf("%s-%s-1", span.SpanContext().TraceID(), span.SpanContext().SpanID()), headers.Get("sentry-trace"))
+
+	// a producer using a Sentry SDK sends only sentry-trace
+	sentryHeaders := propagation.MapCarrier{"sentry-trace": headers.Get("sentry-trace")}
+	_, consumerSpan := tracing.Start(tracing.Extract(context.Background(), sentryHeaders), "consume")
+	defer consumerSpan.End()
+	testSuite.Equal(span.SpanContext().TraceID(), consumerSpan.SpanContext().TraceID())
+	testSuite.True(consumerSpan.SpanContext().IsSampled())
+}
+
+func (testSuite *TracingTestSuite) TestUnsampled() {
+	_, span := tracing.Start(tracing.Unsampled(context.Background()), "readyz")
+	defer span.End()
+	testSuite.True(span.SpanContext().IsValid())
+	testSuite.False(span.SpanContext().IsSampled())
+}
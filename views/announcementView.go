@@ -0,0 +1,65 @@
+// Package views provides Discord message embeds and components for course announcements.
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+)
+
+// acknowledgementsShown is the most acknowledgements listed in the summary, which stays well under the embed description limit.
+const acknowledgementsShown = 50
+
+// AnnouncementModal returns modal components for composing an announcement.
+func AnnouncementModal(locale locales.Locale) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:  "announcementTitle",
+					Label:     locale.T("modal.announcement_title"),
+					Style:     discordgo.TextInputShort,
+					Required:  true,
+					MaxLength: 256,
+				},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:  "announcementMessage",
+					Label:     locale.T("modal.announcement_message"),
+					Style:     discordgo.TextInputParagraph,
+					Required:  true,
+					MaxLength: 4000,
+				},
+			},
+		},
+	}
+}
+
+// AnnouncementAcknowledgementsView returns a Discord message embed summarizing who acknowledged an announcement, earliest first.
+func AnnouncementAcknowledgementsView(locale locales.Locale, announcement clients.Announcement) *discordgo.MessageEmbed {
+	acknowledgements := announcement.Acknowledgements
+	mentions := make([]string, 0, min(len(acknowledgements), acknowledgementsShown))
+	for _, userID := range acknowledgements[:min(len(acknowledgements), acknowledgementsShown)] {
+		mentions = append(mentions, fmt.Sprintf("<@%s>", userID))
+	}
+	if len(acknowledgements) > acknowledgementsShown {
+		mentions = append(mentions, locale.T("announcement.acknowledgements_more", len(acknowledgements)-acknowledgementsShown))
+	}
+
+	description := locale.T("announcement.acknowledgements_none")
+	if len(mentions) > 0 {
+		description = strings.Join(mentions, "\n")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       locale.T("announcement.acknowledgements_title", announcement.Title),
+		Description: description,
+		Footer:      &discordgo.MessageEmbedFooter{Text: locale.T("announcement.acknowledgements_count", len(acknowledgements))},
+	}
+}
@@ -3,30 +3,34 @@ package views
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
 )
 
 // AssignmentsListView returns a Discord message embed for a list of assignments for the given member.
-// It displays assignment IDs, names, and due dates.
-func AssignmentsListView(member *discordgo.Member, assignments []clients.Assignment) *discordgo.MessageEmbed {
+// It displays assignment IDs, names, and due dates, which Discord shows in each viewer's language and time zone.
+func AssignmentsListView(locale locales.Locale, member *discordgo.Member, assignments []clients.Assignment) *discordgo.MessageEmbed {
 	embed := discordgo.MessageEmbed{
-		Title:       "assignments",
-		Description: fmt.Sprintf("%d assignments in course", len(assignments)),
+		Title:       locale.T("assignments.title"),
+		Description: locale.T("assignments.count", len(assignments)),
 		Author:      &discordgo.MessageEmbedAuthor{Name: member.User.Username, IconURL: member.User.AvatarURL("")},
 	}
 
 	for _, assignment := range assignments {
+		name := assignment.Name
+		if assignment.IsExam() {
+			name = locale.T("assignments.exam", assignment.Name)
+		}
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:   fmt.Sprintf("id: %d", assignment.ID),
-			Value:  assignment.Name,
+			Name:   locale.T("assignments.id", assignment.ID),
+			Value:  name,
 			Inline: true,
 		})
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:   "due:",
-			Value:  assignment.Due.Format(time.RFC1123),
+			Name:   locale.T("assignments.due"),
+			Value:  fmt.Sprintf("<t:%d:F>", assignment.Due.Unix()),
 			Inline: true,
 		})
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
@@ -41,14 +45,14 @@ func AssignmentsListView(member *discordgo.Member, assignments []clients.Assignm
 }
 
 // AssignmentsListActions returns action buttons for the assignment list, such as adding a new assignment.
-func AssignmentsListActions() *discordgo.ActionsRow {
+func AssignmentsListActions(locale locales.Locale) *discordgo.ActionsRow {
 	return &discordgo.ActionsRow{
 		Components: []discordgo.MessageComponent{
 			discordgo.Button{
 				Emoji: &discordgo.ComponentEmoji{
 					Name: "➕",
 				},
-				Label:    "add",
+				Label:    locale.T("button.add"),
 				Style:    discordgo.PrimaryButton,
 				CustomID: "addAssignmentAction",
 			},
@@ -7,29 +7,51 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
 )
 
 // AssignmentView returns a Discord message embed for the given assignment and member.
 // It displays assignment details such as name, due date, author, and link.
-func AssignmentView(member *discordgo.Member, assignment clients.Assignment) *discordgo.MessageEmbed {
+// The due date is a Discord timestamp, which Discord shows in each viewer's language and time zone.
+func AssignmentView(locale locales.Locale, member *discordgo.Member, assignment clients.Assignment) *discordgo.MessageEmbed {
+	due := fmt.Sprintf("<t:%d:F>", assignment.Due.Unix())
+	description := locale.T("assignment.due", due)
+	if assignment.IsExam() {
+		description = locale.T("assignment.exam", due)
+	}
+
 	return &discordgo.MessageEmbed{
 		Title:       assignment.Name,
-		Description: fmt.Sprintf("due %s", assignment.Due.Format(time.RFC1123)),
+		Description: description,
 		Author:      &discordgo.MessageEmbedAuthor{Name: member.User.Username, IconURL: member.User.AvatarURL("")},
 		URL:         assignment.Link,
-		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("id %d", assignment.ID)},
+		Footer:      &discordgo.MessageEmbedFooter{Text: locale.T("assignment.id", assignment.ID)},
+	}
+}
+
+// AssignmentChoiceName returns the autocomplete choice label for an assignment, such as "Homework 1 (due Jan 2)".
+func AssignmentChoiceName(locale locales.Locale, assignment clients.Assignment) string {
+	due := assignment.Due.Format(locale.T("date.short"))
+	name := locale.T("assignment.choice", assignment.Name, due)
+	if assignment.IsExam() {
+		name = locale.T("assignment.choice_exam", assignment.Name, due)
+	}
+	if runes := []rune(name); len(runes) > 100 {
+		// discord limits choice names to 100 characters
+		name = string(runes[:100])
 	}
+	return name
 }
 
 // AssignmentActions returns action buttons for editing or removing the given assignment.
-func AssignmentActions(assignment clients.Assignment) *discordgo.ActionsRow {
+func AssignmentActions(locale locales.Locale, assignment clients.Assignment) *discordgo.ActionsRow {
 	return &discordgo.ActionsRow{
 		Components: []discordgo.MessageComponent{
 			discordgo.Button{
 				Emoji: &discordgo.ComponentEmoji{
 					Name: "📝",
 				},
-				Label:    "edit",
+				Label:    locale.T("button.edit"),
 				Style:    discordgo.PrimaryButton,
 				CustomID: fmt.Sprintf("updateAssignmentAction_%d", assignment.ID),
 			},
@@ -37,7 +59,7 @@ func AssignmentActions(assignment clients.Assignment) *discordgo.ActionsRow {
 				Emoji: &discordgo.ComponentEmoji{
 					Name: "🗑️",
 				},
-				Label:    "remove",
+				Label:    locale.T("button.remove"),
 				Style:    discordgo.SecondaryButton,
 				CustomID: fmt.Sprintf("deleteAssignmentAction_%d", assignment.ID),
 			},
@@ -47,24 +69,29 @@ func AssignmentActions(assignment clients.Assignment) *discordgo.ActionsRow {
 
 // AssignmentModal returns modal components for creating or updating an assignment.
 // If assignment is nil, it creates a new assignment modal.
-func AssignmentModal(assignment *clients.Assignment) []discordgo.MessageComponent {
+// The due date placeholder stays in the RFC 1123 format that the due date is parsed in.
+func AssignmentModal(locale locales.Locale, assignment *clients.Assignment) []discordgo.MessageComponent {
 
 	newAssignment := assignment == nil
 
 	if newAssignment {
 		assignment = &clients.Assignment{
 			// placeholder data
-			Name: "Assignment 1",
+			Name: locale.T("modal.name_placeholder"),
+			Kind: clients.HomeworkAssignment,
 			Due:  time.Now(),
 			Link: "https://canvas.instructure.com",
 		}
 	}
+	if assignment.Kind == "" {
+		assignment.Kind = clients.HomeworkAssignment
+	}
 	return []discordgo.MessageComponent{
 		discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
 				discordgo.TextInput{
 					CustomID:    "assignmentName",
-					Label:       "assignment name:",
+					Label:       locale.T("modal.name"),
 					Style:       discordgo.TextInputShort,
 					Placeholder: assignment.Name,
 					Required:    newAssignment,
@@ -76,7 +103,7 @@ func AssignmentModal(assignment *clients.Assignment) []discordgo.MessageComponen
 			Components: []discordgo.MessageComponent{
 				discordgo.TextInput{
 					CustomID:    "assignmentDue",
-					Label:       "due date:",
+					Label:       locale.T("modal.due"),
 					Style:       discordgo.TextInputShort,
 					Placeholder: assignment.Due.Format(time.RFC1123),
 					Required:    newAssignment,
@@ -88,7 +115,7 @@ func AssignmentModal(assignment *clients.Assignment) []discordgo.MessageComponen
 			Components: []discordgo.MessageComponent{
 				discordgo.TextInput{
 					CustomID:    "assignmentLink",
-					Label:       "link:",
+					Label:       locale.T("modal.link"),
 					Style:       discordgo.TextInputShort,
 					Placeholder: assignment.Link,
 					Required:    false,
@@ -96,5 +123,17 @@ func AssignmentModal(assignment *clients.Assignment) []discordgo.MessageComponen
 				},
 			},
 		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "assignmentKind",
+					Label:       locale.T("modal.kind"),
+					Style:       discordgo.TextInputShort,
+					Placeholder: assignment.Kind,
+					Required:    false,
+					MaxLength:   20,
+				},
+			},
+		},
 	}
 }
@@ -0,0 +1,62 @@
+// Package views provides Discord message embeds and components for attendance sessions.
+package views
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+)
+
+// AttendanceView returns a Discord message embed for students to check in to an attendance session with its button,
+// or that the session ended. The code is left out, so that it is only shared with students in the room.
+func AttendanceView(locale locales.Locale, session clients.AttendanceSession, now time.Time) *discordgo.MessageEmbed {
+	if !session.Active(now) {
+		return &discordgo.MessageEmbed{
+			Title:       locale.T("attendance.title"),
+			Description: locale.T("attendance.ended", len(session.CheckIns)),
+			Timestamp:   session.ExpiresAt.Format(time.RFC3339),
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       locale.T("attendance.title"),
+		Description: locale.T("attendance.description", fmt.Sprintf("<@%s>", session.HostID)),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: locale.T("attendance.expires"), Value: fmt.Sprintf("<t:%d:R>", session.ExpiresAt.Unix()), Inline: true},
+		},
+		Timestamp: session.StartedAt.Format(time.RFC3339),
+	}
+	if !session.LateAfter.IsZero() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: locale.T("attendance.late_after"), Value: fmt.Sprintf("<t:%d:t>", session.LateAfter.Unix()), Inline: true})
+	}
+	return embed
+}
+
+// AttendanceActions returns Discord message components for students to check in to an attendance session,
+// and for staff to end it early. An ended session has no components.
+func AttendanceActions(locale locales.Locale, session clients.AttendanceSession, now time.Time) []discordgo.MessageComponent {
+	if !session.Active(now) {
+		return []discordgo.MessageComponent{}
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Emoji:    &discordgo.ComponentEmoji{Name: "🙋"},
+					Label:    locale.T("button.check_in"),
+					Style:    discordgo.PrimaryButton,
+					CustomID: fmt.Sprintf("checkInAttendance_%d", session.ID),
+				},
+				discordgo.Button{
+					Emoji:    &discordgo.ComponentEmoji{Name: "🔒"},
+					Label:    locale.T("button.end_attendance"),
+					Style:    discordgo.DangerButton,
+					CustomID: fmt.Sprintf("endAttendance_%d", session.ID),
+				},
+			},
+		},
+	}
+}
@@ -6,43 +6,78 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
 )
 
 // ConfigView returns a Discord message embed displaying the configuration for a course.
-// It shows the notifications channel and role for the given course.
-func ConfigView(course clients.Course) *discordgo.MessageEmbed {
-	notifyChannel, notifyRole := course.NotifyChannel, course.NotifyGroup
+// It shows the notifications channel and role, the exam countdown channel, the question channels, and the default language for the given course.
+func ConfigView(locale locales.Locale, course clients.Course) *discordgo.MessageEmbed {
+	notifyChannel, notifyRole, countdownChannel := course.NotifyChannel, course.NotifyGroup, course.CountdownChannel
 	if notifyChannel != "" {
 		notifyChannel = fmt.Sprintf("<#%s>", notifyChannel)
 	}
 	if notifyRole != "" {
 		notifyRole = fmt.Sprintf("<@&%s>", notifyRole)
 	}
+	if countdownChannel != "" {
+		countdownChannel = fmt.Sprintf("<#%s>", countdownChannel)
+	}
+	questionChannel, reviewChannel := course.QuestionChannel, course.ReviewChannel()
+	if questionChannel != "" {
+		questionChannel = fmt.Sprintf("<#%s>", questionChannel)
+	}
+	if reviewChannel != "" {
+		reviewChannel = fmt.Sprintf("<#%s>", reviewChannel)
+	}
+
+	courseLocale := locales.Resolve(course.Locale)
 
 	return &discordgo.MessageEmbed{
-		Title: "course config",
+		Title: locale.T("config.title"),
 		Fields: []*discordgo.MessageEmbedField{
 			{
-				Name:  "notifications channel",
+				Name:  locale.T("config.notify_channel"),
 				Value: notifyChannel,
 			},
 			{
-				Name:  "notifications role",
+				Name:  locale.T("config.notify_role"),
 				Value: notifyRole,
 			},
+			{
+				Name:  locale.T("config.countdown_channel"),
+				Value: countdownChannel,
+			},
+			{
+				Name:  locale.T("config.question_channel"),
+				Value: questionChannel,
+			},
+			{
+				Name:  locale.T("config.question_review_channel"),
+				Value: reviewChannel,
+			},
+			{
+				Name:  locale.T("config.locale"),
+				Value: locales.Names[courseLocale],
+			},
 		},
 	}
 }
 
-// ConfigActions returns Discord message components for updating the course's notifications channel and role.
-func ConfigActions() []discordgo.MessageComponent {
+// ConfigActions returns Discord message components for updating the course's notifications channel and role,
+// the exam countdown voice channel, and default language, and pinning an exam countdown.
+func ConfigActions(locale locales.Locale) []discordgo.MessageComponent {
+	localeOptions := make([]discordgo.SelectMenuOption, len(locales.Supported))
+	for index, supported := range locales.Supported {
+		localeOptions[index] = discordgo.SelectMenuOption{Label: locales.Names[supported], Value: supported.String()}
+	}
+
 	return []discordgo.MessageComponent{
 		&discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
 				discordgo.SelectMenu{
 					MenuType:    discordgo.ChannelSelectMenu,
 					CustomID:    "updateNotifyChannel",
-					Placeholder: "update notifications channel",
+					Placeholder: locale.T("config.update_notify_channel"),
 				},
 			},
 		},
@@ -51,7 +86,39 @@ func ConfigActions() []discordgo.MessageComponent {
 				discordgo.SelectMenu{
 					MenuType:    discordgo.RoleSelectMenu,
 					CustomID:    "updateNotifyRole",
-					Placeholder: "update notifications role",
+					Placeholder: locale.T("config.update_notify_role"),
+				},
+			},
+		},
+		&discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					MenuType:     discordgo.ChannelSelectMenu,
+					CustomID:     "updateCountdownChannel",
+					Placeholder:  locale.T("config.update_countdown_channel"),
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+				},
+			},
+		},
+		&discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					MenuType:    discordgo.StringSelectMenu,
+					CustomID:    "updateCourseLocale",
+					Placeholder: locale.T("config.update_locale"),
+					Options:     localeOptions,
+				},
+			},
+		},
+		&discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Emoji: &discordgo.ComponentEmoji{
+						Name: "📌",
+					},
+					Label:    locale.T("config.pin_countdown"),
+					Style:    discordgo.SecondaryButton,
+					CustomID: "pinCountdownAction",
 				},
 			},
 		},
@@ -0,0 +1,73 @@
+// Package views provides Discord message embeds and channel names for exam countdowns.
+package views
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+)
+
+// nextExam returns the earliest exam in assignments that is due after now, or nil if there is none.
+func nextExam(assignments []clients.Assignment, now time.Time) *clients.Assignment {
+	var exam *clients.Assignment
+	for index := range assignments {
+		assignment := &assignments[index]
+		if !assignment.IsExam() || !assignment.Due.After(now) {
+			continue
+		}
+		if exam == nil || assignment.Due.Before(exam.Due) {
+			exam = assignment
+		}
+	}
+	return exam
+}
+
+// CountdownChannelName returns the voice channel name counting down to the next exam, such as "Midterm in 5 days".
+func CountdownChannelName(locale locales.Locale, assignments []clients.Assignment, now time.Time) string {
+	exam := nextExam(assignments, now)
+	if exam == nil {
+		return locale.T("countdown.none")
+	}
+
+	name := locale.T("countdown.channel", exam.Name, locale.Duration(exam.Due.Sub(now)))
+	if runes := []rune(name); len(runes) > 100 {
+		// discord limits channel names to 100 characters
+		name = string(runes[:100])
+	}
+	return name
+}
+
+// CountdownView returns a Discord message embed counting down to the next exam and listing the upcoming exams.
+func CountdownView(locale locales.Locale, assignments []clients.Assignment, now time.Time) *discordgo.MessageEmbed {
+	embed := discordgo.MessageEmbed{
+		Title:     locale.T("countdown.title"),
+		Footer:    &discordgo.MessageEmbedFooter{Text: locale.T("countdown.footer")},
+		Timestamp: now.Format(time.RFC3339),
+	}
+
+	exam := nextExam(assignments, now)
+	if exam == nil {
+		embed.Description = locale.T("countdown.none")
+		return &embed
+	}
+	embed.Description = locale.T("countdown.next", exam.Name, locale.Duration(exam.Due.Sub(now)))
+
+	for _, assignment := range assignments {
+		if !assignment.IsExam() || !assignment.Due.After(now) {
+			continue
+		}
+		if len(embed.Fields) == 25 {
+			// discord limits embeds to 25 fields
+			break
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  assignment.Name,
+			Value: fmt.Sprintf("<t:%d:F> (<t:%d:R>)", assignment.Due.Unix(), assignment.Due.Unix()),
+		})
+	}
+
+	return &embed
+}
@@ -0,0 +1,79 @@
+// Package views provides Discord message embeds and components for Q&A threads and the FAQ.
+package views
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+)
+
+// QAThreadModal returns modal components for asking a question in a new Q&A thread.
+func QAThreadModal(locale locales.Locale) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "qaThreadTitle",
+					Label:       locale.T("modal.qa_title"),
+					Style:       discordgo.TextInputShort,
+					Placeholder: locale.T("modal.qa_title_placeholder"),
+					Required:    true,
+					// the title names the thread, and discord limits thread names to 100 characters
+					MaxLength: 98,
+				},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:  "qaThreadQuestion",
+					Label:     locale.T("modal.qa_question"),
+					Style:     discordgo.TextInputParagraph,
+					Required:  false,
+					MaxLength: 4000,
+				},
+			},
+		},
+	}
+}
+
+// QAThreadView returns a Discord message embed displaying the question a Q&A thread was started from.
+func QAThreadView(locale locales.Locale, thread clients.QAThread) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       thread.Title,
+		Description: thread.Question,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: locale.T("faq.asked_by"), Value: fmt.Sprintf("<@%s>", thread.AskerID)},
+		},
+		Footer:    &discordgo.MessageEmbedFooter{Text: locale.T("faq.thread_footer")},
+		Timestamp: thread.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// FAQView returns a Discord message embed displaying an FAQ entry, linking to the accepted answer in its thread.
+func FAQView(locale locales.Locale, entry clients.FAQEntry) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       entry.Question,
+		URL:         entry.AnswerURL,
+		Description: entry.Answer,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: locale.T("faq.answered_by"), Value: fmt.Sprintf("<@%s>", entry.AnswerAuthorID), Inline: true},
+			{Name: locale.T("faq.accepted_by"), Value: fmt.Sprintf("<@%s>", entry.AcceptedBy), Inline: true},
+			{Name: locale.T("faq.thread"), Value: fmt.Sprintf("<#%s>", entry.ThreadID), Inline: true},
+		},
+		Timestamp: entry.AcceptedAt.Format(time.RFC3339),
+	}
+}
+
+// FAQChoiceName returns the name of an FAQ entry as an autocomplete choice.
+func FAQChoiceName(entry clients.FAQEntry) string {
+	name := entry.Question
+	if runes := []rune(name); len(runes) > 100 {
+		// discord limits choice names to 100 characters
+		name = string(runes[:100])
+	}
+	return name
+}
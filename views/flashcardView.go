@@ -0,0 +1,140 @@
+// Package views provides Discord message embeds, components and modals for flashcards.
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+)
+
+// FlashcardDeckModal returns Discord modal components for a new flashcard deck's name and cards, one card per line.
+func FlashcardDeckModal(locale locales.Locale, name string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:  "deckName",
+					Label:     locale.T("modal.deck_name"),
+					Style:     discordgo.TextInputShort,
+					Value:     name,
+					Required:  true,
+					MaxLength: 100,
+				},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "deckCards",
+					Label:       locale.T("modal.deck_cards"),
+					Style:       discordgo.TextInputParagraph,
+					Placeholder: locale.T("modal.deck_cards_placeholder"),
+					Required:    true,
+					MaxLength:   4000,
+				},
+			},
+		},
+	}
+}
+
+// FlashcardDeckChoiceName returns the name of a deck as an autocomplete choice, marking personal decks.
+func FlashcardDeckChoiceName(locale locales.Locale, deck clients.FlashcardDeck) string {
+	if deck.Shared() {
+		return deck.Name
+	}
+	return locale.T("flashcards.personal_choice", deck.Name)
+}
+
+// FlashcardDecksView returns a Discord message embed listing the decks a student can study, with how many of their cards are due at now.
+func FlashcardDecksView(locale locales.Locale, decks []clients.FlashcardDeck, reviews []clients.FlashcardReview, now time.Time) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:  locale.T("flashcards.decks_title"),
+		Fields: []*discordgo.MessageEmbedField{},
+		Footer: &discordgo.MessageEmbedFooter{Text: locale.T("flashcards.decks_footer")},
+	}
+	if len(decks) == 0 {
+		embed.Description = locale.T("flashcards.no_decks")
+	}
+
+	for _, deck := range decks {
+		_, remaining, _ := clients.NextFlashcard(deck, reviews, now)
+		kind := locale.T("flashcards.shared")
+		if !deck.Shared() {
+			kind = locale.T("flashcards.personal")
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  deck.Name,
+			Value: locale.T("flashcards.deck_summary", kind, len(deck.Cards), remaining),
+		})
+	}
+	return embed
+}
+
+// FlashcardView returns a Discord message embed showing a card's front, and its back once flipped, with how many cards are left to study.
+func FlashcardView(locale locales.Locale, deck clients.FlashcardDeck, card clients.Flashcard, flipped bool, remaining int) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       deck.Name,
+		Description: card.Front,
+		Footer:      &discordgo.MessageEmbedFooter{Text: locale.T("flashcards.remaining", remaining)},
+	}
+	if flipped {
+		embed.Fields = []*discordgo.MessageEmbedField{{Name: locale.T("flashcards.answer"), Value: card.Back}}
+	}
+	return embed
+}
+
+// FlashcardActions returns Discord message components for a card being studied: a button to flip it,
+// then buttons to grade how well the student recalled it.
+func FlashcardActions(locale locales.Locale, deck clients.FlashcardDeck, card clients.Flashcard, flipped bool) []discordgo.MessageComponent {
+	if !flipped {
+		return []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Emoji:    &discordgo.ComponentEmoji{Name: "🔄"},
+						Label:    locale.T("button.flip_flashcard"),
+						Style:    discordgo.PrimaryButton,
+						CustomID: fmt.Sprintf("flipFlashcard_%d_%d", deck.ID, card.ID),
+					},
+				},
+			},
+		}
+	}
+
+	grades := []struct {
+		grade string
+		style discordgo.ButtonStyle
+	}{
+		{clients.FlashcardAgain, discordgo.DangerButton},
+		{clients.FlashcardGood, discordgo.PrimaryButton},
+		{clients.FlashcardEasy, discordgo.SuccessButton},
+	}
+	buttons := make([]discordgo.MessageComponent, len(grades))
+	for index, grade := range grades {
+		buttons[index] = discordgo.Button{
+			Label:    locale.T(fmt.Sprintf("button.flashcard_%s", grade.grade)),
+			Style:    grade.style,
+			CustomID: fmt.Sprintf("gradeFlashcard_%d_%d_%s", deck.ID, card.ID, grade.grade),
+		}
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// FlashcardsDoneView returns the message shown when a student has no cards left to study in a deck, with when their next card is due.
+func FlashcardsDoneView(locale locales.Locale, deck clients.FlashcardDeck, reviews []clients.FlashcardReview) string {
+	deckReviews := []clients.FlashcardReview{}
+	for _, review := range reviews {
+		if review.DeckID == deck.ID {
+			deckReviews = append(deckReviews, review)
+		}
+	}
+	next, exists := clients.NextFlashcardReview(deckReviews)
+	if !exists {
+		return locale.T("flashcards.done", deck.Name)
+	}
+	return strings.Join([]string{locale.T("flashcards.done", deck.Name), locale.T("flashcards.next_review", fmt.Sprintf("<t:%d:R>", next.Unix()))}, " ")
+}
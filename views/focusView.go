@@ -0,0 +1,82 @@
+// Package views provides Discord message embeds and components for focus timers.
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+)
+
+// FocusTimerView returns a Discord message embed showing a focus timer's phase and when it ends at now, with its participants.
+// Discord counts down to the end of the phase, so the embed is only edited when the timer changes.
+func FocusTimerView(locale locales.Locale, timer clients.FocusTimer, now time.Time) *discordgo.MessageEmbed {
+	participants := make([]string, len(timer.Participants))
+	for index, participant := range timer.Participants {
+		participants[index] = fmt.Sprintf("<@%s>", participant)
+	}
+	if len(participants) == 0 {
+		participants = []string{locale.T("focus.no_participants")}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: locale.T(fmt.Sprintf("focus.title.%s", timer.Phase)),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: locale.T("focus.participants"), Value: strings.Join(participants, ", ")},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: locale.T("focus.footer", timer.FocusMinutes, timer.BreakMinutes, timer.Cycles)},
+	}
+
+	switch {
+	case timer.Phase == clients.FocusDone:
+		embed.Description = locale.T("focus.done", timer.Cycles)
+	case timer.Phase == clients.FocusStopped:
+		embed.Description = locale.T("focus.stopped", timer.Cycle, timer.Cycles)
+	case timer.Paused():
+		embed.Description = locale.T("focus.paused", timer.Cycle, timer.Cycles, locale.Duration(timer.Remaining(now).Round(time.Minute)))
+	default:
+		embed.Description = locale.T(fmt.Sprintf("focus.running.%s", timer.Phase), timer.Cycle, timer.Cycles, fmt.Sprintf("<t:%d:R>", timer.PhaseEndsAt.Unix()))
+	}
+	return embed
+}
+
+// FocusTimerActions returns Discord message components for joining, pausing or resuming, and stopping a running focus timer.
+func FocusTimerActions(locale locales.Locale, timer clients.FocusTimer) []discordgo.MessageComponent {
+	if !timer.Running() {
+		return []discordgo.MessageComponent{}
+	}
+
+	pause := discordgo.Button{
+		Emoji:    &discordgo.ComponentEmoji{Name: "⏸️"},
+		Label:    locale.T("button.pause_focus"),
+		Style:    discordgo.SecondaryButton,
+		CustomID: fmt.Sprintf("pauseFocus_%s", timer.ChannelID),
+	}
+	if timer.Paused() {
+		pause.Emoji = &discordgo.ComponentEmoji{Name: "▶️"}
+		pause.Label = locale.T("button.resume_focus")
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Emoji:    &discordgo.ComponentEmoji{Name: "🍅"},
+					Label:    locale.T("button.join_focus"),
+					Style:    discordgo.PrimaryButton,
+					CustomID: fmt.Sprintf("joinFocus_%s", timer.ChannelID),
+				},
+				pause,
+				discordgo.Button{
+					Emoji:    &discordgo.ComponentEmoji{Name: "⏹️"},
+					Label:    locale.T("button.stop_focus"),
+					Style:    discordgo.DangerButton,
+					CustomID: fmt.Sprintf("stopFocus_%s", timer.ChannelID),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,135 @@
+// Package views provides Discord message embeds and components for office hours queues.
+package views
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+)
+
+// queueShown is the most waiting students listed in the queue message, which stays well under the embed description limit.
+const queueShown = 25
+
+// OfficeHoursQueueView returns a Discord message embed displaying an office hours queue,
+// with each waiting student's topic and estimated wait, or that the session is closed.
+func OfficeHoursQueueView(locale locales.Locale, officeHours clients.OfficeHours) *discordgo.MessageEmbed {
+	if !officeHours.Open() {
+		return &discordgo.MessageEmbed{
+			Title:       locale.T("officehours.title"),
+			Description: locale.T("officehours.closed", fmt.Sprintf("<@%s>", officeHours.HostID)),
+			Timestamp:   officeHours.ClosedAt.Format(time.RFC3339),
+		}
+	}
+
+	waiting := officeHours.Waiting()
+	lines := []string{}
+	for index, entry := range waiting[:min(len(waiting), queueShown)] {
+		wait := locale.Duration(officeHours.EstimatedWait(index + 1))
+		lines = append(lines, locale.T("officehours.entry", index+1, fmt.Sprintf("<@%s>", entry.UserID), entry.Topic, wait))
+	}
+	if len(waiting) > queueShown {
+		lines = append(lines, locale.T("officehours.more", len(waiting)-queueShown))
+	}
+	queue := locale.T("officehours.empty")
+	if len(lines) > 0 {
+		queue = strings.Join(lines, "\n")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       locale.T("officehours.title"),
+		Description: locale.T("officehours.description", fmt.Sprintf("<@%s>", officeHours.HostID), fmt.Sprintf("<#%s>", officeHours.VoiceChannelID), queue),
+		Footer:      &discordgo.MessageEmbedFooter{Text: locale.T("officehours.footer", len(waiting), locale.Duration(officeHours.HelpTime()))},
+		Timestamp:   officeHours.OpenedAt.Format(time.RFC3339),
+	}
+}
+
+// OfficeHoursActions returns Discord message components for students to join and leave an office hours queue,
+// and for staff to pull the next student and close the session. A closed session has no components.
+func OfficeHoursActions(locale locales.Locale, officeHours clients.OfficeHours) []discordgo.MessageComponent {
+	if !officeHours.Open() {
+		return []discordgo.MessageComponent{}
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Emoji:    &discordgo.ComponentEmoji{Name: "✋"},
+					Label:    locale.T("button.join_queue"),
+					Style:    discordgo.PrimaryButton,
+					CustomID: fmt.Sprintf("joinOfficeHours_%d", officeHours.ID),
+				},
+				discordgo.Button{
+					Emoji:    &discordgo.ComponentEmoji{Name: "🚪"},
+					Label:    locale.T("button.leave_queue"),
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("leaveOfficeHours_%d", officeHours.ID),
+				},
+				discordgo.Button{
+					Emoji:    &discordgo.ComponentEmoji{Name: "⏭️"},
+					Label:    locale.T("button.next_student"),
+					Style:    discordgo.SuccessButton,
+					CustomID: fmt.Sprintf("nextOfficeHours_%d", officeHours.ID),
+				},
+				discordgo.Button{
+					Emoji:    &discordgo.ComponentEmoji{Name: "🔒"},
+					Label:    locale.T("button.close_queue"),
+					Style:    discordgo.DangerButton,
+					CustomID: fmt.Sprintf("closeOfficeHours_%d", officeHours.ID),
+				},
+			},
+		},
+	}
+}
+
+// OfficeHoursModal returns modal components for joining an office hours queue with a short topic.
+func OfficeHoursModal(locale locales.Locale) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "officeHoursTopic",
+					Label:       locale.T("modal.queue_topic"),
+					Style:       discordgo.TextInputShort,
+					Placeholder: locale.T("modal.queue_topic_placeholder"),
+					Required:    true,
+					MaxLength:   100,
+				},
+			},
+		},
+	}
+}
+
+// OfficeHoursStatisticsView returns a Discord message embed displaying a course's office hours statistics,
+// with the staff who helped the most students first.
+func OfficeHoursStatisticsView(locale locales.Locale, statistics clients.OfficeHoursStatistics) *discordgo.MessageEmbed {
+	helpers := slices.SortedFunc(maps.Keys(statistics.HelpedBy), func(a string, b string) int {
+		return cmp.Or(cmp.Compare(statistics.HelpedBy[b], statistics.HelpedBy[a]), cmp.Compare(a, b))
+	})
+	lines := make([]string, 0, len(helpers))
+	for _, helper := range helpers[:min(len(helpers), 10)] {
+		lines = append(lines, locale.T("officehours.stats_helper", fmt.Sprintf("<@%s>", helper), statistics.HelpedBy[helper]))
+	}
+	staff := locale.T("officehours.stats_no_helpers")
+	if len(lines) > 0 {
+		staff = strings.Join(lines, "\n")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: locale.T("officehours.stats_title"),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: locale.T("officehours.stats_sessions"), Value: fmt.Sprint(statistics.Sessions), Inline: true},
+			{Name: locale.T("officehours.stats_duration"), Value: locale.Duration(statistics.Duration), Inline: true},
+			{Name: locale.T("officehours.stats_helped"), Value: fmt.Sprint(statistics.Helped), Inline: true},
+			{Name: locale.T("officehours.stats_unhelped"), Value: fmt.Sprint(statistics.Unhelped), Inline: true},
+			{Name: locale.T("officehours.stats_average_wait"), Value: locale.Duration(statistics.AverageWait), Inline: true},
+			{Name: locale.T("officehours.stats_staff"), Value: staff},
+		},
+	}
+}
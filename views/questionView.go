@@ -0,0 +1,85 @@
+// Package views provides Discord message embeds and components for anonymous questions.
+package views
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+)
+
+// QuestionModal returns modal components for asking a question anonymously.
+func QuestionModal(locale locales.Locale) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "questionText",
+					Label:       locale.T("modal.question_text"),
+					Style:       discordgo.TextInputParagraph,
+					Placeholder: locale.T("modal.question_text_placeholder"),
+					Required:    true,
+					MaxLength:   2000,
+				},
+			},
+		},
+	}
+}
+
+// QuestionView returns a Discord message embed displaying an anonymous question in the course's question channel.
+func QuestionView(locale locales.Locale, question clients.Question) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       locale.T("question.title", question.ID),
+		Description: question.Text,
+		Footer:      &discordgo.MessageEmbedFooter{Text: locale.T("question.footer")},
+		Timestamp:   question.AskedAt.Format(time.RFC3339),
+	}
+}
+
+// QuestionReviewView returns a Discord message embed displaying a question for staff to review, with its status.
+func QuestionReviewView(locale locales.Locale, question clients.Question) *discordgo.MessageEmbed {
+	status := locale.T("question.pending")
+	switch question.Status {
+	case clients.QuestionApproved:
+		status = locale.T("question.approved_by", fmt.Sprintf("<@%s>", question.ReviewedBy))
+	case clients.QuestionRejected:
+		status = locale.T("question.rejected_by", fmt.Sprintf("<@%s>", question.ReviewedBy))
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       locale.T("question.review_title", question.ID),
+		Description: question.Text,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: locale.T("question.status"), Value: status},
+		},
+		Timestamp: question.AskedAt.Format(time.RFC3339),
+	}
+}
+
+// QuestionReviewActions returns Discord message components for staff to approve or reject a pending question.
+// A reviewed question has no components.
+func QuestionReviewActions(locale locales.Locale, question clients.Question) []discordgo.MessageComponent {
+	if question.Status != clients.QuestionPending {
+		return []discordgo.MessageComponent{}
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Emoji:    &discordgo.ComponentEmoji{Name: "✅"},
+					Label:    locale.T("button.approve_question"),
+					Style:    discordgo.SuccessButton,
+					CustomID: fmt.Sprintf("approveQuestion_%d", question.ID),
+				},
+				discordgo.Button{
+					Emoji:    &discordgo.ComponentEmoji{Name: "🚫"},
+					Label:    locale.T("button.reject_question"),
+					Style:    discordgo.DangerButton,
+					CustomID: fmt.Sprintf("rejectQuestion_%d", question.ID),
+				},
+			},
+		},
+	}
+}
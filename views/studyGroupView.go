@@ -0,0 +1,74 @@
+// Package views provides Discord message embeds and components for study groups.
+package views
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dragonejt/hakase-discord/clients"
+	"github.com/dragonejt/hakase-discord/locales"
+)
+
+// StudyGroupAvailabilityMenu returns Discord message components for a waiting student to choose when they can study,
+// with their current availability chosen.
+func StudyGroupAvailabilityMenu(locale locales.Locale, availability []string) []discordgo.MessageComponent {
+	options := make([]discordgo.SelectMenuOption, len(clients.StudyGroupSlots))
+	for index, slot := range clients.StudyGroupSlots {
+		options[index] = discordgo.SelectMenuOption{
+			Label:   locale.T(fmt.Sprintf("studygroup.slot.%s", slot)),
+			Value:   slot,
+			Default: slices.Contains(availability, slot),
+		}
+	}
+	minValues := 1
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					MenuType:    discordgo.StringSelectMenu,
+					CustomID:    "studyGroupAvailability",
+					Placeholder: locale.T("studygroup.availability_placeholder"),
+					MinValues:   &minValues,
+					MaxValues:   len(options),
+					Options:     options,
+				},
+			},
+		},
+	}
+}
+
+// StudyGroupSlotNames returns the localized names of availability slots, separated by commas.
+func StudyGroupSlotNames(locale locales.Locale, slots []string) string {
+	names := make([]string, len(slots))
+	for index, slot := range slots {
+		names[index] = locale.T(fmt.Sprintf("studygroup.slot.%s", slot))
+	}
+	return strings.Join(names, ", ")
+}
+
+// StudyGroupView returns a Discord message embed welcoming the members of a new study group,
+// with the topics they share and when they are all available.
+func StudyGroupView(locale locales.Locale, group clients.StudyGroup) *discordgo.MessageEmbed {
+	members := make([]string, len(group.Members))
+	for index, member := range group.Members {
+		members[index] = fmt.Sprintf("<@%s>", member.UserID)
+	}
+	topics := locale.T("studygroup.no_shared_topics")
+	if len(group.Topics) > 0 {
+		topics = strings.Join(group.Topics, ", ")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       locale.T("studygroup.title", group.Name),
+		Description: locale.T("studygroup.welcome", strings.Join(members, ", ")),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: locale.T("studygroup.topics"), Value: topics},
+			{Name: locale.T("studygroup.availability"), Value: StudyGroupSlotNames(locale, group.Availability)},
+		},
+		Footer:    &discordgo.MessageEmbedFooter{Text: locale.T("studygroup.footer")},
+		Timestamp: group.CreatedAt.Format(time.RFC3339),
+	}
+}